@@ -0,0 +1,259 @@
+// backend/attachment/service.go
+package attachment
+
+import (
+	"ProtocolManager/backend/models"
+	"ProtocolManager/backend/repository"
+	"ProtocolManager/backend/storage"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+)
+
+var (
+	// ErrScanPending indica que o antivírus ainda não liberou o arquivo
+	ErrScanPending = errors.New("anexo ainda em verificação")
+	// ErrInfected indica que o antivírus encontrou uma ameaça no arquivo
+	ErrInfected = errors.New("anexo reprovado na verificação antivírus")
+	// ErrChecksumMismatch indica que o arquivo armazenado não corresponde
+	// ao SHA-256 gravado no upload
+	ErrChecksumMismatch = errors.New("checksum do anexo não confere")
+	// ErrNoThumbnail indica que a versão não tem miniatura
+	ErrNoThumbnail = errors.New("anexo sem miniatura")
+)
+
+// Service reúne as operações sobre anexos: gravação do conteúdo com
+// checksum, metadados, leitura verificada e remoção. Os handlers e o
+// arquivo ZIP usam só ele; registros e arquivos nunca são tratados em
+// separado.
+type Service struct {
+	Repo   *repository.ProtocolAttachmentRepository
+	Files  storage.Storage
+	Policy models.AttachmentPolicy
+}
+
+func NewService(
+	repo *repository.ProtocolAttachmentRepository, files storage.Storage,
+	policy models.AttachmentPolicy,
+) *Service {
+	return &Service{Repo: repo, Files: files, Policy: policy}
+}
+
+// UploadTarget devolve a política de upload do protocolo, já com as listas
+// do tipo aplicadas, e o espaço ocupado pelos anexos
+func (s *Service) UploadTarget(
+	scope repository.Scope, protocolID int,
+) (models.AttachmentPolicy, int64, error) {
+	protocolType, used, err := s.Repo.UploadTarget(scope, protocolID)
+	if err != nil {
+		return models.AttachmentPolicy{}, 0, err
+	}
+	return s.Policy.ForType(protocolType), used, nil
+}
+
+// Store grava o conteúdo em key e devolve o SHA-256 em hexadecimal. Em
+// caso de falha o objeto parcial é removido.
+func (s *Service) Store(
+	ctx context.Context, key string, r io.Reader, contentType string,
+) (string, error) {
+	hash := sha256.New()
+	if err := s.Files.Put(
+		ctx, key, io.TeeReader(r, hash), -1, contentType,
+	); err != nil {
+		// A failed put may still leave a partial object behind
+		s.RemoveFiles(ctx, []string{key})
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// Create grava o registro de um anexo já armazenado. Sem o registro o
+// arquivo é removido, para não ficar órfão.
+func (s *Service) Create(
+	ctx context.Context, scope repository.Scope,
+	attachment models.ProtocolAttachment, quota int64,
+) (models.ProtocolAttachment, error) {
+	created, err := s.Repo.Create(scope, attachment, quota)
+	if err != nil {
+		s.RemoveFiles(ctx, []string{attachment.FilePath})
+	}
+	return created, err
+}
+
+// CreateVersion grava uma nova versão já armazenada, com a mesma limpeza
+// de Create
+func (s *Service) CreateVersion(
+	ctx context.Context, scope repository.Scope, attachmentID int,
+	version models.AttachmentVersion, quota int64,
+) (models.ProtocolAttachment, error) {
+	updated, err := s.Repo.CreateVersion(scope, attachmentID, version, quota)
+	if err != nil {
+		s.RemoveFiles(ctx, []string{version.FilePath})
+	}
+	return updated, err
+}
+
+func (s *Service) List(
+	scope repository.Scope, protocolID int,
+) ([]models.ProtocolAttachment, error) {
+	return s.Repo.GetByProtocolID(scope, protocolID)
+}
+
+func (s *Service) Get(
+	scope repository.Scope, id int,
+) (models.ProtocolAttachment, error) {
+	return s.Repo.GetByID(scope, id)
+}
+
+func (s *Service) Versions(
+	scope repository.Scope, id int,
+) ([]models.AttachmentVersion, error) {
+	return s.Repo.GetVersions(scope, id)
+}
+
+// ArchiveEntries devolve o protocolo e as versões do arquivo ZIP
+func (s *Service) ArchiveEntries(
+	scope repository.Scope, protocolID int, allVersions bool,
+) (models.Protocol, []models.AttachmentVersion, error) {
+	return s.Repo.GetArchiveEntries(scope, protocolID, allVersions)
+}
+
+// Delete apaga o anexo com todas as versões; os arquivos só são removidos
+// depois do commit, para uma falha nunca deixar registro sem arquivo
+func (s *Service) Delete(
+	ctx context.Context, scope repository.Scope, id int,
+) error {
+	filePaths, err := s.Repo.Delete(scope, id)
+	if err != nil {
+		return err
+	}
+	s.RemoveFiles(ctx, filePaths)
+	return nil
+}
+
+// RemoveFiles apaga arquivos cujos registros já foram removidos. Falhas só
+// são registradas no log: o arquivo órfão não afeta mais ninguém.
+func (s *Service) RemoveFiles(ctx context.Context, keys []string) {
+	for _, key := range keys {
+		if err := s.Files.Delete(ctx, key); err != nil {
+			log.Printf("Failed to remove attachment file %s: %v", key, err)
+		}
+	}
+}
+
+// Servable devolve a versão do anexo (0 para a atual) se ela pode ser
+// servida: só arquivos liberados pelo antivírus saem do armazenamento
+func (s *Service) Servable(
+	scope repository.Scope, id, version int,
+) (models.AttachmentVersion, error) {
+	v, err := s.Repo.GetVersion(scope, id, version)
+	if err != nil {
+		return v, err
+	}
+	switch v.ScanStatus {
+	case models.ScanClean:
+		return v, nil
+	case models.ScanInfected:
+		return v, ErrInfected
+	default:
+		return v, ErrScanPending
+	}
+}
+
+// Open abre o conteúdo da versão. Quando há checksum gravado a leitura é
+// conferida: se o arquivo não corresponder, o último byte é retido e a
+// leitura termina com ErrChecksumMismatch, deixando a resposta incompleta
+// em vez de entregar um arquivo adulterado.
+func (s *Service) Open(
+	ctx context.Context, v models.AttachmentVersion,
+) (io.ReadCloser, storage.ObjectInfo, error) {
+	file, info, err := s.Files.Get(ctx, v.FilePath)
+	if err != nil || v.Checksum == "" {
+		return file, info, err
+	}
+	return newVerifyingReader(file, v), info, nil
+}
+
+// OpenThumbnail abre a miniatura da versão
+func (s *Service) OpenThumbnail(
+	ctx context.Context, v models.AttachmentVersion,
+) (io.ReadCloser, storage.ObjectInfo, error) {
+	if v.ThumbnailPath == "" {
+		return nil, storage.ObjectInfo{}, ErrNoThumbnail
+	}
+	return s.Files.Get(ctx, v.ThumbnailPath)
+}
+
+// Situação de uma versão após Verify
+const (
+	IntegrityOK       = "ok"
+	IntegrityMismatch = "mismatch"
+	IntegrityRecorded = "recorded"
+	IntegrityMissing  = "missing"
+)
+
+// IntegrityResult é o resultado da conferência de uma versão
+type IntegrityResult struct {
+	Version  int    `json:"version"`
+	Status   string `json:"status"`
+	Expected string `json:"expected,omitempty"`
+	Actual   string `json:"actual,omitempty"`
+}
+
+// Verify recalcula o SHA-256 de todas as versões do anexo e compara com o
+// gravado. Versões anteriores à verificação de integridade passam a ter o
+// checksum gravado.
+func (s *Service) Verify(
+	ctx context.Context, scope repository.Scope, id int,
+) ([]IntegrityResult, error) {
+	versions, err := s.Repo.GetVersions(scope, id)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]IntegrityResult, 0, len(versions))
+	for _, v := range versions {
+		result := IntegrityResult{Version: v.Version, Expected: v.Checksum}
+		actual, err := s.checksum(ctx, v.FilePath)
+		switch {
+		case errors.Is(err, storage.ErrNotFound):
+			result.Status = IntegrityMissing
+		case err != nil:
+			return nil, fmt.Errorf("version %d: %w", v.Version, err)
+		case v.Checksum == "":
+			if err := s.Repo.RecordChecksum(v, actual); err != nil {
+				return nil, err
+			}
+			result.Status = IntegrityRecorded
+		case v.Checksum != actual:
+			log.Printf(
+				"Attachment %d version %d: checksum mismatch (expected %s, got %s)",
+				v.AttachmentID, v.Version, v.Checksum, actual,
+			)
+			result.Status = IntegrityMismatch
+		default:
+			result.Status = IntegrityOK
+		}
+		result.Actual = actual
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+func (s *Service) checksum(ctx context.Context, key string) (string, error) {
+	file, _, err := s.Files.Get(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
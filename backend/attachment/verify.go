@@ -0,0 +1,74 @@
+// backend/attachment/verify.go
+package attachment
+
+import (
+	"ProtocolManager/backend/models"
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"io"
+	"log"
+)
+
+// verifyingReader confere o SHA-256 enquanto o arquivo é lido. O último
+// byte só é entregue depois da conferência: com Content-Length já enviado,
+// uma divergência deixa o download incompleto e o cliente o descarta.
+type verifyingReader struct {
+	file    io.ReadCloser
+	r       *bufio.Reader
+	hash    hash.Hash
+	version models.AttachmentVersion
+	done    bool
+}
+
+func newVerifyingReader(
+	file io.ReadCloser, version models.AttachmentVersion,
+) *verifyingReader {
+	return &verifyingReader{
+		file:    file,
+		r:       bufio.NewReaderSize(file, 32<<10),
+		hash:    sha256.New(),
+		version: version,
+	}
+}
+
+func (v *verifyingReader) Read(p []byte) (int, error) {
+	if v.done {
+		return 0, io.EOF
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+	// Peek só enxerga até o tamanho do buffer
+	want := min(len(p), v.r.Size()-1)
+
+	buf, err := v.r.Peek(want + 1)
+	if len(buf) > 1 {
+		// Ainda há mais um byte depois deste trecho
+		n := copy(p, buf[:len(buf)-1])
+		v.r.Discard(n)
+		v.hash.Write(p[:n])
+		return n, nil
+	}
+	if err != io.EOF {
+		return 0, err
+	}
+
+	// Fim do arquivo: buf tem no máximo o último byte
+	v.hash.Write(buf)
+	if hex.EncodeToString(v.hash.Sum(nil)) != v.version.Checksum {
+		log.Printf(
+			"Attachment %d version %d: checksum mismatch on read",
+			v.version.AttachmentID, v.version.Version,
+		)
+		return 0, ErrChecksumMismatch
+	}
+	v.done = true
+	n := copy(p, buf)
+	return n, io.EOF
+}
+
+func (v *verifyingReader) Close() error {
+	return v.file.Close()
+}
@@ -1,22 +1,225 @@
 package config
 
 import (
+	"errors"
 	"os"
+	"time"
+)
+
+// Valores de desenvolvimento; em produção o servidor não sobe com eles
+const (
+	defaultDatabaseDSN = "host=localhost user=postgres password=123 dbname=protocol port=5432 sslmode=disable"
+	defaultJWTSecret   = "sua_chave_secreta_aqui"
+)
+
+const (
+	EnvDevelopment = "development"
+	EnvProduction  = "production"
 )
 
 type Config struct {
+	// "development" ou "production"
+	Environment string
+	Port        string
+
+	ProtocolNumberFormat string
+
+	// Banco de dados. Aplica as migrações pendentes ao iniciar; desligado,
+	// o servidor recusa subir enquanto houver migração pendente.
+	DatabaseDSN       string
+	DBMaxOpenConns    int
+	DBMaxIdleConns    int
+	DBConnMaxLifetime time.Duration
+	DBMigrateOnStart  bool
+
+	// Autenticação
+	JWTSecret string
+	JWTTTL    time.Duration
+
+	// Origens aceitas pelo CORS; "*" libera qualquer origem e só é aceito
+	// fora de produção
+	CORSAllowedOrigins []string
+
+	// Log das requisições: "text" ou "json"
+	LogRequests bool
+	LogFormat   string
+
+	// Armazenamento dos anexos: "local" (FileStoragePath) ou "s3"
+	StorageDriver   string
 	FileStoragePath string
+	S3Endpoint      string
+	S3Region        string
+	S3Bucket        string
+	S3AccessKey     string
+	S3SecretKey     string
+	S3UseSSL        bool
+	S3PathStyle     bool
+	S3CreateBucket  bool
+
+	// Limites de upload em bytes (0 não limita) e tipos MIME aceitos e
+	// negados, separados por vírgula; cada tipo de protocolo pode
+	// substituir as listas
+	AttachmentMaxSize       int64
+	AttachmentProtocolQuota int64
+	AttachmentAllowedTypes  string
+	AttachmentDeniedTypes   string
+
+	// Antivírus dos anexos (clamd via TCP), obrigatório em produção.
+	// ScanFailOpen libera os anexos quando o clamd falha ou, fora de
+	// produção, quando ClamdAddr está vazio; do contrário eles ficam
+	// pendentes até uma varredura dar certo.
+	ClamdAddr    string
+	ClamdTimeout time.Duration
+	ScanFailOpen bool
+	ScanInterval time.Duration
+	ScanBackoff  time.Duration
+
+	// Miniaturas dos anexos. A primeira página dos PDFs é renderizada pelo
+	// pdftoppm (poppler-utils); vazio ou ausente no PATH desativa só as
+	// miniaturas de PDF.
+	PDFToPPMPath      string
+	ThumbnailInterval time.Duration
+
+	// Prazos de SLA
+	SLABusinessDays  bool
+	SLAAtRiskWindow  time.Duration
+	SLACheckInterval time.Duration
+
+	// Despacho de lembretes
+	ReminderInterval    time.Duration
+	ReminderMaxAttempts int
+	ReminderBackoff     time.Duration
+
+	// Envio de e-mails; desabilitado quando SMTPHost está vazio
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
+	SMTPFrom     string
+	SMTPTimeout  time.Duration
+	EmailLocale  string
+
+	// Fila das notificações por e-mail geradas a partir do outbox
+	NotificationInterval    time.Duration
+	NotificationMaxAttempts int
+	NotificationBackoff     time.Duration
+
+	// Entrega de webhooks
+	WebhookInterval    time.Duration
+	WebhookMaxAttempts int
+	WebhookBackoff     time.Duration
+	WebhookTimeout     time.Duration
+
+	// Relay do outbox de eventos
+	OutboxInterval    time.Duration
+	OutboxMaxAttempts int
+	OutboxRetention   time.Duration
+
+	// Intervalo de leitura do outbox para o stream de eventos e até quando
+	// um cliente pode retomar o stream pelo Last-Event-ID; OutboxRetention
+	// precisa ser ao menos RealtimeReplayWindow
+	RealtimePollInterval time.Duration
+	RealtimeReplayWindow time.Duration
 }
 
-// NewConfig carrega configurações do ambiente ou define valores padrão
-func NewConfig() *Config {
-	path := os.Getenv("FILE_STORAGE_PATH")
-	if path == "" {
-		// Caminho padrão para arquivos salvos localmente
-		path = "./storage"
+// Load lê a configuração das variáveis de ambiente e, se CONFIG_FILE
+// apontar para um arquivo YAML ou TOML, dele; o ambiente tem prioridade.
+// Todos os problemas encontrados voltam juntos no erro.
+func Load() (*Config, error) {
+	src, err := newSource(os.Getenv("CONFIG_FILE"))
+	if err != nil {
+		return nil, err
 	}
+	cfg := newConfig(src)
 
+	errs := append(src.errs, src.unknown()...)
+	if err := cfg.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	return cfg, errors.Join(errs...)
+}
+
+func newConfig(src *source) *Config {
 	return &Config{
-		FileStoragePath: path,
+		Environment: src.str("APP_ENV", EnvDevelopment),
+		Port:        src.str("PORT", "8080"),
+
+		// Formato do número do protocolo, ex.: "{BRANCH}-{YYYY}-{SEQ:05}"
+		ProtocolNumberFormat: src.str("PROTOCOL_NUMBER_FORMAT", "{YYYY}-{SEQ:04}"),
+
+		DatabaseDSN:       src.str("DATABASE_DSN", defaultDatabaseDSN),
+		DBMaxOpenConns:    src.integer("DB_MAX_OPEN_CONNS", 25),
+		DBMaxIdleConns:    src.integer("DB_MAX_IDLE_CONNS", 5),
+		DBConnMaxLifetime: src.duration("DB_CONN_MAX_LIFETIME", 30*time.Minute),
+		DBMigrateOnStart:  src.boolean("DB_MIGRATE_ON_START", true),
+
+		JWTSecret: src.str("JWT_SECRET", defaultJWTSecret),
+		JWTTTL:    src.duration("JWT_TTL", 24*time.Hour),
+
+		CORSAllowedOrigins: src.list("CORS_ALLOWED_ORIGINS", []string{"*"}),
+
+		LogRequests: src.boolean("LOG_REQUESTS", true),
+		LogFormat:   src.str("LOG_FORMAT", "text"),
+
+		StorageDriver: src.str("STORAGE_DRIVER", "local"),
+		// Caminho padrão para arquivos salvos localmente
+		FileStoragePath: src.str("FILE_STORAGE_PATH", "./storage"),
+		S3Endpoint:      src.str("S3_ENDPOINT", ""),
+		S3Region:        src.str("S3_REGION", "us-east-1"),
+		S3Bucket:        src.str("S3_BUCKET", ""),
+		S3AccessKey:     src.str("S3_ACCESS_KEY", ""),
+		S3SecretKey:     src.str("S3_SECRET_KEY", ""),
+		S3UseSSL:        src.boolean("S3_USE_SSL", true),
+		// MinIO e a maioria dos serviços compatíveis usam o bucket no caminho
+		S3PathStyle:    src.boolean("S3_PATH_STYLE", true),
+		S3CreateBucket: src.boolean("S3_CREATE_BUCKET", false),
+
+		AttachmentMaxSize:       src.integer64("ATTACHMENT_MAX_SIZE", 25<<20),
+		AttachmentProtocolQuota: src.integer64("ATTACHMENT_PROTOCOL_QUOTA", 200<<20),
+		AttachmentAllowedTypes:  src.str("ATTACHMENT_ALLOWED_TYPES", ""),
+		// HTML enviado como anexo pode ser aberto no navegador
+		AttachmentDeniedTypes: src.str("ATTACHMENT_DENIED_TYPES", "text/html"),
+
+		ClamdAddr:    src.str("CLAMD_ADDR", ""),
+		ClamdTimeout: src.duration("CLAMD_TIMEOUT", time.Minute),
+		ScanFailOpen: src.boolean("ATTACHMENT_SCAN_FAIL_OPEN", false),
+		ScanInterval: src.duration("ATTACHMENT_SCAN_INTERVAL", 5*time.Second),
+		ScanBackoff:  src.duration("ATTACHMENT_SCAN_BACKOFF", 30*time.Second),
+
+		PDFToPPMPath:      src.str("PDFTOPPM_PATH", "pdftoppm"),
+		ThumbnailInterval: src.duration("ATTACHMENT_THUMBNAIL_INTERVAL", 5*time.Second),
+
+		SLABusinessDays:  src.boolean("SLA_BUSINESS_DAYS", false),
+		SLAAtRiskWindow:  src.duration("SLA_AT_RISK_WINDOW", 24*time.Hour),
+		SLACheckInterval: src.duration("SLA_CHECK_INTERVAL", 5*time.Minute),
+
+		ReminderInterval:    src.duration("REMINDER_INTERVAL", time.Minute),
+		ReminderMaxAttempts: src.integer("REMINDER_MAX_ATTEMPTS", 5),
+		ReminderBackoff:     src.duration("REMINDER_BACKOFF", time.Minute),
+
+		SMTPHost:     src.str("SMTP_HOST", ""),
+		SMTPPort:     src.integer("SMTP_PORT", 587),
+		SMTPUsername: src.str("SMTP_USERNAME", ""),
+		SMTPPassword: src.str("SMTP_PASSWORD", ""),
+		SMTPFrom:     src.str("SMTP_FROM", ""),
+		SMTPTimeout:  src.duration("SMTP_TIMEOUT", 30*time.Second),
+		// Idioma das mensagens: "pt-BR" ou "en"
+		EmailLocale: src.str("EMAIL_LOCALE", "pt-BR"),
+
+		NotificationInterval:    src.duration("NOTIFICATION_INTERVAL", 5*time.Second),
+		NotificationMaxAttempts: src.integer("NOTIFICATION_MAX_ATTEMPTS", 5),
+		NotificationBackoff:     src.duration("NOTIFICATION_BACKOFF", time.Minute),
+
+		WebhookInterval:    src.duration("WEBHOOK_INTERVAL", 10*time.Second),
+		WebhookMaxAttempts: src.integer("WEBHOOK_MAX_ATTEMPTS", 8),
+		WebhookBackoff:     src.duration("WEBHOOK_BACKOFF", 30*time.Second),
+		WebhookTimeout:     src.duration("WEBHOOK_TIMEOUT", 10*time.Second),
+
+		OutboxInterval:    src.duration("OUTBOX_INTERVAL", 2*time.Second),
+		OutboxMaxAttempts: src.integer("OUTBOX_MAX_ATTEMPTS", 10),
+		OutboxRetention:   src.duration("OUTBOX_RETENTION", 7*24*time.Hour),
+
+		RealtimePollInterval: src.duration("REALTIME_POLL_INTERVAL", time.Second),
+		RealtimeReplayWindow: src.duration("REALTIME_REPLAY_WINDOW", 24*time.Hour),
 	}
 }
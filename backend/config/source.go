@@ -0,0 +1,187 @@
+// backend/config/source.go
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// source resolve cada chave primeiro no ambiente e depois no arquivo de
+// configuração. Erros de conversão são acumulados para serem informados
+// todos de uma vez.
+type source struct {
+	file map[string]string
+	used map[string]bool
+	errs []error
+}
+
+// newSource carrega o arquivo em path (YAML ou TOML, pela extensão); sem
+// path só o ambiente é usado. No arquivo as seções viram prefixos:
+// smtp.host equivale a SMTP_HOST.
+func newSource(path string) (*source, error) {
+	s := &source{file: map[string]string{}, used: map[string]bool{}}
+	if path == "" {
+		return s, nil
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("config file: %w", err)
+	}
+	values := map[string]any{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(content, &values)
+	case ".toml":
+		err = toml.Unmarshal(content, &values)
+	default:
+		return nil, fmt.Errorf(
+			"config file %s: unsupported format, use .yaml, .yml or .toml", path,
+		)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("config file %s: %w", path, err)
+	}
+	flatten(s.file, "", values)
+	return s, nil
+}
+
+// flatten converte as seções em chaves no formato das variáveis de
+// ambiente; listas viram valores separados por vírgula
+func flatten(out map[string]string, prefix string, values map[string]any) {
+	for name, value := range values {
+		key := strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+		if prefix != "" {
+			key = prefix + "_" + key
+		}
+		switch v := value.(type) {
+		case map[string]any:
+			flatten(out, key, v)
+		case []any:
+			items := make([]string, len(v))
+			for i, item := range v {
+				items[i] = fmt.Sprint(item)
+			}
+			out[key] = strings.Join(items, ",")
+		case nil:
+			out[key] = ""
+		default:
+			out[key] = fmt.Sprint(v)
+		}
+	}
+}
+
+// lookup devolve o valor da chave. Variável de ambiente vazia conta como
+// ausente; no arquivo, um valor vazio é explícito e limpa o padrão.
+func (s *source) lookup(key string) (string, bool) {
+	s.used[key] = true
+	if value := os.Getenv(key); value != "" {
+		return value, true
+	}
+	value, ok := s.file[key]
+	return value, ok
+}
+
+func (s *source) invalid(key, kind, value string) {
+	s.errs = append(s.errs, fmt.Errorf("%s: invalid %s %q", key, kind, value))
+}
+
+func (s *source) str(key, fallback string) string {
+	if value, ok := s.lookup(key); ok {
+		return value
+	}
+	return fallback
+}
+
+func (s *source) boolean(key string, fallback bool) bool {
+	value, ok := s.lookup(key)
+	if !ok {
+		return fallback
+	}
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		s.invalid(key, "boolean", value)
+		return fallback
+	}
+	return parsed
+}
+
+func (s *source) integer(key string, fallback int) int {
+	value, ok := s.lookup(key)
+	if !ok {
+		return fallback
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		s.invalid(key, "integer", value)
+		return fallback
+	}
+	return parsed
+}
+
+func (s *source) integer64(key string, fallback int64) int64 {
+	value, ok := s.lookup(key)
+	if !ok {
+		return fallback
+	}
+	parsed, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		s.invalid(key, "integer", value)
+		return fallback
+	}
+	return parsed
+}
+
+func (s *source) duration(key string, fallback time.Duration) time.Duration {
+	value, ok := s.lookup(key)
+	if !ok {
+		return fallback
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		s.invalid(key, "duration", value)
+		return fallback
+	}
+	return parsed
+}
+
+// list lê valores separados por vírgula, ignorando os vazios
+func (s *source) list(key string, fallback []string) []string {
+	value, ok := s.lookup(key)
+	if !ok {
+		return fallback
+	}
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// unknown aponta as chaves do arquivo que nenhuma configuração lê,
+// normalmente erros de digitação
+func (s *source) unknown() []error {
+	var keys []string
+	for key := range s.file {
+		if !s.used[key] {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	errs := make([]error, len(keys))
+	for i, key := range keys {
+		errs[i] = fmt.Errorf("config file: unknown setting %s", key)
+	}
+	return errs
+}
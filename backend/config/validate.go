@@ -0,0 +1,195 @@
+// backend/config/validate.go
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// Tamanho mínimo do JWT_SECRET em produção
+const minJWTSecretLength = 32
+
+// Validate confere a configuração carregada e devolve todos os problemas de
+// uma vez. Em produção os segredos de desenvolvimento são recusados.
+func (c *Config) Validate() error {
+	var errs []error
+	fail := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	switch c.Environment {
+	case EnvDevelopment, EnvProduction:
+	default:
+		fail(
+			"APP_ENV: must be %q or %q, got %q",
+			EnvDevelopment, EnvProduction, c.Environment,
+		)
+	}
+	if port, err := strconv.Atoi(c.Port); err != nil || port < 1 || port > 65535 {
+		fail("PORT: invalid port %q", c.Port)
+	}
+
+	if c.DatabaseDSN == "" {
+		fail("DATABASE_DSN: required")
+	}
+	if c.DBMaxOpenConns < 0 {
+		fail("DB_MAX_OPEN_CONNS: must not be negative")
+	}
+	if c.DBMaxIdleConns < 0 {
+		fail("DB_MAX_IDLE_CONNS: must not be negative")
+	}
+
+	if c.JWTSecret == "" {
+		fail("JWT_SECRET: required")
+	}
+
+	if len(c.CORSAllowedOrigins) == 0 {
+		fail("CORS_ALLOWED_ORIGINS: at least one origin is required")
+	}
+	for _, origin := range c.CORSAllowedOrigins {
+		if origin == "*" {
+			continue
+		}
+		u, err := url.Parse(origin)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") ||
+			u.Host == "" || (u.Path != "" && u.Path != "/") {
+			fail(
+				"CORS_ALLOWED_ORIGINS: invalid origin %q, use scheme://host[:port]",
+				origin,
+			)
+		}
+	}
+
+	switch c.LogFormat {
+	case "text", "json":
+	default:
+		fail("LOG_FORMAT: must be \"text\" or \"json\", got %q", c.LogFormat)
+	}
+
+	switch c.StorageDriver {
+	case "local":
+		if c.FileStoragePath == "" {
+			fail("FILE_STORAGE_PATH: required with STORAGE_DRIVER=local")
+		}
+	case "s3":
+		if c.S3Endpoint == "" {
+			fail("S3_ENDPOINT: required with STORAGE_DRIVER=s3")
+		}
+		if c.S3Bucket == "" {
+			fail("S3_BUCKET: required with STORAGE_DRIVER=s3")
+		}
+	default:
+		fail("STORAGE_DRIVER: must be \"local\" or \"s3\", got %q", c.StorageDriver)
+	}
+	if c.AttachmentMaxSize < 0 {
+		fail("ATTACHMENT_MAX_SIZE: must not be negative")
+	}
+	if c.AttachmentProtocolQuota < 0 {
+		fail("ATTACHMENT_PROTOCOL_QUOTA: must not be negative")
+	}
+
+	if c.SMTPHost != "" {
+		if c.SMTPPort < 1 || c.SMTPPort > 65535 {
+			fail("SMTP_PORT: invalid port %d", c.SMTPPort)
+		}
+		if c.SMTPFrom == "" {
+			fail("SMTP_FROM: required with SMTP_HOST")
+		} else if _, err := mail.ParseAddress(c.SMTPFrom); err != nil {
+			fail("SMTP_FROM: invalid address %q: %v", c.SMTPFrom, err)
+		}
+	}
+	switch c.EmailLocale {
+	case "pt-BR", "en":
+	default:
+		fail("EMAIL_LOCALE: must be \"pt-BR\" or \"en\", got %q", c.EmailLocale)
+	}
+
+	// Intervalos dos jobs e tempos limite precisam ser positivos
+	for _, d := range []struct {
+		key   string
+		value time.Duration
+	}{
+		{"JWT_TTL", c.JWTTTL},
+		{"CLAMD_TIMEOUT", c.ClamdTimeout},
+		{"ATTACHMENT_SCAN_INTERVAL", c.ScanInterval},
+		{"ATTACHMENT_SCAN_BACKOFF", c.ScanBackoff},
+		{"ATTACHMENT_THUMBNAIL_INTERVAL", c.ThumbnailInterval},
+		{"SLA_CHECK_INTERVAL", c.SLACheckInterval},
+		{"REMINDER_INTERVAL", c.ReminderInterval},
+		{"REMINDER_BACKOFF", c.ReminderBackoff},
+		{"WEBHOOK_INTERVAL", c.WebhookInterval},
+		{"WEBHOOK_BACKOFF", c.WebhookBackoff},
+		{"WEBHOOK_TIMEOUT", c.WebhookTimeout},
+		{"SMTP_TIMEOUT", c.SMTPTimeout},
+		{"NOTIFICATION_INTERVAL", c.NotificationInterval},
+		{"NOTIFICATION_BACKOFF", c.NotificationBackoff},
+		{"OUTBOX_INTERVAL", c.OutboxInterval},
+		{"OUTBOX_RETENTION", c.OutboxRetention},
+		{"REALTIME_POLL_INTERVAL", c.RealtimePollInterval},
+		{"REALTIME_REPLAY_WINDOW", c.RealtimeReplayWindow},
+	} {
+		if d.value <= 0 {
+			fail("%s: must be positive, got %v", d.key, d.value)
+		}
+	}
+	if c.OutboxRetention < c.RealtimeReplayWindow {
+		fail(
+			"OUTBOX_RETENTION: must be at least REALTIME_REPLAY_WINDOW (%v), got %v",
+			c.RealtimeReplayWindow, c.OutboxRetention,
+		)
+	}
+	if c.SLAAtRiskWindow < 0 {
+		fail("SLA_AT_RISK_WINDOW: must not be negative")
+	}
+	if c.DBConnMaxLifetime < 0 {
+		fail("DB_CONN_MAX_LIFETIME: must not be negative")
+	}
+	if c.ReminderMaxAttempts < 1 {
+		fail("REMINDER_MAX_ATTEMPTS: must be at least 1")
+	}
+	if c.WebhookMaxAttempts < 1 {
+		fail("WEBHOOK_MAX_ATTEMPTS: must be at least 1")
+	}
+	if c.NotificationMaxAttempts < 1 {
+		fail("NOTIFICATION_MAX_ATTEMPTS: must be at least 1")
+	}
+	if c.OutboxMaxAttempts < 1 {
+		fail("OUTBOX_MAX_ATTEMPTS: must be at least 1")
+	}
+
+	if c.IsProduction() {
+		const defaultInProduction = "%s: the development default cannot be used in production"
+		if c.DatabaseDSN == defaultDatabaseDSN {
+			fail(defaultInProduction, "DATABASE_DSN")
+		}
+		if c.JWTSecret == defaultJWTSecret {
+			fail(defaultInProduction, "JWT_SECRET")
+		} else if len(c.JWTSecret) < minJWTSecretLength {
+			fail(
+				"JWT_SECRET: must have at least %d characters in production",
+				minJWTSecretLength,
+			)
+		}
+		// "*" é o padrão de desenvolvimento: em produção as origens são
+		// listadas uma a uma
+		if slices.Contains(c.CORSAllowedOrigins, "*") {
+			fail("CORS_ALLOWED_ORIGINS: \"*\" cannot be used in production")
+		}
+		// Sem antivírus os anexos nunca seriam verificados
+		if c.ClamdAddr == "" {
+			fail("CLAMD_ADDR: required in production")
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// IsProduction indica APP_ENV=production
+func (c *Config) IsProduction() bool {
+	return c.Environment == EnvProduction
+}
@@ -0,0 +1,50 @@
+// backend/config/validate_test.go
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExampleConfigIsValid(t *testing.T) {
+	t.Setenv("CONFIG_FILE", "../config.example.yaml")
+	if _, err := Load(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestProductionRejectsDevelopmentDefaults(t *testing.T) {
+	t.Setenv("APP_ENV", EnvProduction)
+
+	err := newConfig(&source{file: map[string]string{}, used: map[string]bool{}}).Validate()
+	if err == nil {
+		t.Fatal("expected production to reject the development defaults")
+	}
+	for _, key := range []string{
+		"DATABASE_DSN", "JWT_SECRET", "CORS_ALLOWED_ORIGINS", "CLAMD_ADDR",
+	} {
+		if !strings.Contains(err.Error(), key+":") {
+			t.Errorf("no error for %s in %v", key, err)
+		}
+	}
+}
+
+func TestOutboxRetentionCoversTheReplayWindow(t *testing.T) {
+	t.Setenv("OUTBOX_RETENTION", "1h")
+	t.Setenv("REALTIME_REPLAY_WINDOW", "2h")
+
+	_, err := Load()
+	if err == nil || !strings.Contains(err.Error(), "OUTBOX_RETENTION:") {
+		t.Fatalf("got %v, want an OUTBOX_RETENTION error", err)
+	}
+}
+
+func TestSMTPFromMustBeAnAddress(t *testing.T) {
+	t.Setenv("SMTP_HOST", "smtp.example.com")
+	t.Setenv("SMTP_FROM", "Protocolos protocolos@example")
+
+	_, err := Load()
+	if err == nil || !strings.Contains(err.Error(), "SMTP_FROM:") {
+		t.Fatalf("got %v, want an SMTP_FROM error", err)
+	}
+}
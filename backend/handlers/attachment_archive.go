@@ -0,0 +1,218 @@
+// backend/handlers/attachment_archive.go
+package handlers
+
+import (
+	"ProtocolManager/backend/middleware"
+	"ProtocolManager/backend/models"
+	"ProtocolManager/backend/storage"
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const archiveManifestName = "manifest.json"
+
+// archiveManifest lista o conteúdo do ZIP para auditoria
+type archiveManifest struct {
+	ProtocolID     int                    `json:"protocol_id"`
+	ProtocolNumber string                 `json:"protocol_number"`
+	GeneratedAt    time.Time              `json:"generated_at"`
+	AllVersions    bool                   `json:"all_versions"`
+	Files          []archiveManifestEntry `json:"files"`
+}
+
+type archiveManifestEntry struct {
+	AttachmentID int    `json:"attachment_id"`
+	Version      int    `json:"version"`
+	FileName     string `json:"file_name"`
+	// Caminho dentro do ZIP; vazio quando o arquivo não foi incluído
+	ArchivePath  string    `json:"archive_path"`
+	Size         int64     `json:"size"`
+	ContentType  string    `json:"content_type"`
+	UploadedBy   int       `json:"uploaded_by"`
+	UploaderName string    `json:"uploader_name"`
+	UploadedAt   time.Time `json:"uploaded_at"`
+	SHA256       string    `json:"sha256"`
+	ScanStatus   string    `json:"scan_status"`
+	Included     bool      `json:"included"`
+	// Motivo de o arquivo ter ficado de fora
+	Error string `json:"error,omitempty"`
+}
+
+// DownloadArchive envia um ZIP com os anexos do protocolo e um
+// manifest.json com nome, tamanho, autor, data de envio e SHA-256 de cada
+// arquivo. Nada passa pelo disco: cada arquivo é copiado do armazenamento
+// para a resposta enquanto o hash é calculado, e o manifesto vai por último.
+// Como no download avulso, só entram arquivos liberados pelo antivírus; os
+// demais aparecem no manifesto com included=false. ?versions=all inclui
+// todas as versões em vez de só as atuais.
+func (h *ProtocolAttachmentHandler) DownloadArchive(c *gin.Context) {
+	protocolID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid protocol ID"})
+		return
+	}
+
+	allVersions := false
+	switch c.Query("versions") {
+	case "", "current":
+	case "all":
+		allVersions = true
+	default:
+		c.JSON(
+			http.StatusBadRequest,
+			gin.H{"error": "versions must be current or all"},
+		)
+		return
+	}
+
+	protocol, versions, err := h.Attachments.ArchiveEntries(
+		middleware.CurrentScope(c), protocolID, allVersions,
+	)
+	if err != nil {
+		c.JSON(statusForError(err), gin.H{"error": "Protocol not found"})
+		return
+	}
+
+	name := protocol.ProtocolNumber
+	if name == "" {
+		name = strconv.Itoa(protocol.ProtocolID)
+	}
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", contentDisposition(
+		"attachment", fmt.Sprintf("protocolo-%s-anexos.zip", name),
+	))
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Status(http.StatusOK)
+
+	manifest := archiveManifest{
+		ProtocolID:     protocol.ProtocolID,
+		ProtocolNumber: protocol.ProtocolNumber,
+		GeneratedAt:    time.Now(),
+		AllVersions:    allVersions,
+		Files:          make([]archiveManifestEntry, 0, len(versions)),
+	}
+
+	ctx := c.Request.Context()
+	zw := zip.NewWriter(c.Writer)
+	for _, version := range versions {
+		entry, err := h.writeArchiveEntry(c, zw, version, allVersions)
+		if err != nil {
+			// A resposta já começou: só resta parar, deixando um ZIP truncado
+			// que o cliente vai recusar
+			if ctx.Err() == nil {
+				log.Printf(
+					"Archive of protocol %d: attachment %d: %v",
+					protocolID, version.AttachmentID, err,
+				)
+			}
+			return
+		}
+		manifest.Files = append(manifest.Files, entry)
+	}
+
+	w, err := zw.Create(archiveManifestName)
+	if err == nil {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(manifest)
+	}
+	if err == nil {
+		err = zw.Close()
+	}
+	if err != nil && ctx.Err() == nil {
+		log.Printf("Archive of protocol %d: manifest: %v", protocolID, err)
+	}
+}
+
+// writeArchiveEntry copia um arquivo para o ZIP. Arquivos que não podem
+// ser servidos só são descritos no manifesto; um erro significa que a
+// própria resposta falhou.
+func (h *ProtocolAttachmentHandler) writeArchiveEntry(
+	c *gin.Context, zw *zip.Writer, version models.AttachmentVersion,
+	allVersions bool,
+) (archiveManifestEntry, error) {
+	entry := archiveManifestEntry{
+		AttachmentID: version.AttachmentID,
+		Version:      version.Version,
+		FileName:     version.FileName,
+		Size:         version.FileSize,
+		ContentType:  version.ContentType,
+		UploadedBy:   version.UploadedBy,
+		UploaderName: strings.TrimSpace(
+			version.UploadedByAgent.FirstName + " " +
+				version.UploadedByAgent.LastName,
+		),
+		UploadedAt: version.UploadedAt,
+		ScanStatus: version.ScanStatus,
+	}
+	if version.ScanStatus != models.ScanClean {
+		entry.Error = "not available: virus scan " + version.ScanStatus
+		return entry, nil
+	}
+
+	// O checksum gravado é conferido durante a cópia: uma divergência
+	// interrompe a entrada e, com ela, o ZIP
+	file, _, err := h.Attachments.Open(c.Request.Context(), version)
+	if err != nil {
+		log.Printf(
+			"Archive: attachment %d version %d: %v",
+			version.AttachmentID, version.Version, err,
+		)
+		entry.Error = "file not found in storage"
+		return entry, nil
+	}
+	defer file.Close()
+
+	entry.ArchivePath = archivePath(version, allVersions)
+	w, err := zw.CreateHeader(&zip.FileHeader{
+		Name:     entry.ArchivePath,
+		Method:   archiveMethod(version.ContentType),
+		Modified: version.UploadedAt,
+	})
+	if err != nil {
+		return entry, err
+	}
+
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(w, hash), file)
+	if err != nil {
+		return entry, err
+	}
+	entry.Size = size
+	entry.SHA256 = hex.EncodeToString(hash.Sum(nil))
+	entry.Included = true
+	return entry, nil
+}
+
+// archivePath prefixa o ID do anexo (e a versão) para dois arquivos com o
+// mesmo nome nunca colidirem dentro do ZIP
+func archivePath(version models.AttachmentVersion, allVersions bool) string {
+	name := storage.SafeFileName(version.FileName)
+	if allVersions {
+		return fmt.Sprintf("%d-v%d-%s", version.AttachmentID, version.Version, name)
+	}
+	return fmt.Sprintf("%d-%s", version.AttachmentID, name)
+}
+
+// archiveMethod só comprime texto: PDFs, imagens e arquivos do Office já
+// são compactados e comprimi-los de novo só gasta CPU
+func archiveMethod(contentType string) uint16 {
+	if strings.HasPrefix(contentType, "text/") ||
+		contentType == "application/json" ||
+		contentType == "application/xml" {
+		return zip.Deflate
+	}
+	return zip.Store
+}
@@ -2,79 +2,280 @@
 package handlers
 
 import (
-	"ProtocolManager/backend/config"
-	"ProtocolManager/backend/repository"
+	"ProtocolManager/backend/attachment"
+	"ProtocolManager/backend/middleware"
+	"ProtocolManager/backend/models"
+	"ProtocolManager/backend/preview"
+	"ProtocolManager/backend/storage"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
-	"os"
 	"strconv"
+	"strings"
+	"unicode/utf8"
 
 	"github.com/gin-gonic/gin"
 )
 
-type Handler struct {
-	Repo   *repository.FileRepository
-	Config *config.Config
-}
+// DownloadAttachment handles file downloads. It serves the current version
+// of the attachment; ?version=N serves an earlier one.
+func (h *ProtocolAttachmentHandler) DownloadAttachment(c *gin.Context) {
+	version, ok := h.servableVersion(c)
+	if !ok {
+		return
+	}
 
-func NewAttachmentHandler(
-	repo *repository.FileRepository, cfg *config.Config,
-) *Handler {
-	return &Handler{
-		Repo:   repo,
-		Config: cfg,
+	contentType := version.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	// Set headers for file download
+	headers := map[string]string{
+		"Content-Description":       "File Transfer",
+		"Content-Disposition":       contentDisposition("attachment", version.FileName),
+		"Content-Transfer-Encoding": "binary",
+		"Cache-Control":             "no-cache",
+		"X-Content-Type-Options":    "nosniff",
+	}
+	if digest := reprDigest(version.Checksum); digest != "" {
+		headers["Repr-Digest"] = digest
 	}
+	file, info, err := h.Attachments.Open(c.Request.Context(), version)
+	h.serveFile(c, version, file, info, err, contentType, headers)
 }
 
-// DownloadAttachment handles file downloads
-func (h *Handler) DownloadAttachment(c *gin.Context) {
-	// Extract attachment ID from URL parameter
-	id := c.Param("id")
-	log.Println("Received attachment ID:", id)
+// PreviewAttachment shows an image attachment in the browser instead of
+// downloading it. ?size=thumb serves the generated thumbnail, available
+// for images and PDFs; ?version=N works as in DownloadAttachment.
+func (h *ProtocolAttachmentHandler) PreviewAttachment(c *gin.Context) {
+	version, ok := h.servableVersion(c)
+	if !ok {
+		return
+	}
+
+	headers := map[string]string{
+		"Content-Disposition":    contentDisposition("inline", version.FileName),
+		"X-Content-Type-Options": "nosniff",
+		// O conteúdo é do usuário: nada nele pode executar no navegador
+		"Content-Security-Policy": "default-src 'none'; sandbox",
+		"Cache-Control":           "private, no-cache",
+	}
+
+	ctx := c.Request.Context()
+	if c.Query("size") == "thumb" {
+		file, info, err := h.Attachments.OpenThumbnail(ctx, version)
+		if errors.Is(err, attachment.ErrNoThumbnail) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Thumbnail not available"})
+			return
+		}
+		h.serveFile(
+			c, version, file, info, err, preview.ThumbnailContentType, headers,
+		)
+		return
+	}
 
-	attachmentID, err := strconv.Atoi(id)
+	if !preview.IsInlineImage(version.ContentType) {
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{
+			"error":        "Preview not available for this file type",
+			"content_type": version.ContentType,
+		})
+		return
+	}
+	file, info, err := h.Attachments.Open(ctx, version)
+	h.serveFile(c, version, file, info, err, version.ContentType, headers)
+}
+
+// VerifyAttachment recomputes the SHA-256 of every version of the
+// attachment and compares it with the one recorded at upload. Versions
+// uploaded before checksums existed get theirs recorded.
+func (h *ProtocolAttachmentHandler) VerifyAttachment(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid attachment ID"})
 		return
 	}
 
-	// Get attachment from database
-	attachment, err := h.Repo.GetAttachmentByID(attachmentID)
+	results, err := h.Attachments.Verify(
+		c.Request.Context(), middleware.CurrentScope(c), id,
+	)
 	if err != nil {
-		log.Printf("Erro ao buscar anexo ID %d: %v", attachmentID, err)
-		c.JSON(http.StatusNotFound, gin.H{"error": "Attachment not found"})
+		log.Printf("Erro ao verificar anexo ID %d: %v", id, err)
+		c.JSON(statusForError(err), gin.H{"error": "Failed to verify attachment"})
 		return
 	}
 
-	// Get file path (modify this according to your storage system)
-	filePath := attachment.FilePath
+	intact := true
+	for _, result := range results {
+		if result.Status == attachment.IntegrityMismatch ||
+			result.Status == attachment.IntegrityMissing {
+			intact = false
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"attachment_id": id,
+		"intact":        intact,
+		"versions":      results,
+	})
+}
+
+// servableVersion loads the attachment version in ?version=N, or the
+// current one, and checks it passed the virus scan. It writes the error
+// response itself and returns false when the file can't be served.
+func (h *ProtocolAttachmentHandler) servableVersion(
+	c *gin.Context,
+) (models.AttachmentVersion, bool) {
+	// Extract attachment ID from URL parameter
+	attachmentID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid attachment ID"})
+		return models.AttachmentVersion{}, false
+	}
 
-	// Check if file exists
-	log.Println("Verificando arquivo em:", filePath)
-	if _, err := os.Stat(filePath); os.IsNotExist(err) {
-		log.Println("Arquivo não encontrado no caminho:", filePath)
+	// The latest version unless ?version=N
+	number := 0
+	if raw := c.Query("version"); raw != "" {
+		number, err = strconv.Atoi(raw)
+		if err != nil || number < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid version"})
+			return models.AttachmentVersion{}, false
+		}
+	}
+
+	// Only files the antivirus marked clean are served
+	version, err := h.Attachments.Servable(
+		middleware.CurrentScope(c), attachmentID, number,
+	)
+	switch {
+	case err == nil:
+		return version, true
+	case errors.Is(err, attachment.ErrInfected):
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":       "Attachment failed the virus scan",
+			"scan_status": version.ScanStatus,
+		})
+	case errors.Is(err, attachment.ErrScanPending):
+		c.JSON(http.StatusConflict, gin.H{
+			"error":       "Attachment is still being scanned",
+			"scan_status": version.ScanStatus,
+		})
+	default:
+		log.Printf("Erro ao buscar anexo ID %d: %v", attachmentID, err)
+		c.JSON(statusForError(err), gin.H{"error": "Attachment not found"})
+	}
+	return models.AttachmentVersion{}, false
+}
+
+// serveFile streams a file opened from storage with the given headers,
+// answering the open error when there is one
+func (h *ProtocolAttachmentHandler) serveFile(
+	c *gin.Context, version models.AttachmentVersion, file io.ReadCloser,
+	info storage.ObjectInfo, err error, contentType string,
+	headers map[string]string,
+) {
+	if errors.Is(err, storage.ErrInvalidKey) {
+		// Caminho gravado fora da raiz do armazenamento: nunca é servido
+		log.Printf(
+			"Anexo ID %d versão %d com caminho inválido",
+			version.AttachmentID, version.Version,
+		)
 		c.JSON(http.StatusNotFound, gin.H{"error": "File not found on server"})
 		return
 	}
+	if errors.Is(err, storage.ErrNotFound) {
+		log.Printf(
+			"Arquivo do anexo ID %d versão %d não encontrado no armazenamento",
+			version.AttachmentID, version.Version,
+		)
+		c.JSON(http.StatusNotFound, gin.H{"error": "File not found on server"})
+		return
+	}
+	if err != nil {
+		log.Printf("Erro ao abrir anexo ID %d: %v", version.AttachmentID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read file"})
+		return
+	}
+	defer file.Close()
 
-	// Set headers for file download
-	// Set headers for file download
-	c.Header("Content-Description", "File Transfer")
-	c.Header(
-		"Content-Disposition",
-		fmt.Sprintf("attachment; filename=%s", attachment.FileName),
+	// A checksum mismatch surfaces as a read error: the response ends
+	// short of Content-Length and the client discards it
+	c.DataFromReader(http.StatusOK, info.Size, contentType, file, headers)
+}
+
+// reprDigest monta o cabeçalho Repr-Digest (RFC 9530) a partir do SHA-256
+// em hexadecimal, para o cliente conferir o arquivo recebido
+func reprDigest(checksum string) string {
+	sum, err := hex.DecodeString(checksum)
+	if err != nil || len(sum) != sha256.Size {
+		return ""
+	}
+	return "sha-256=:" + base64.StdEncoding.EncodeToString(sum) + ":"
+}
+
+// contentDisposition monta o cabeçalho conforme a RFC 6266, com disposition
+// "attachment" ou "inline": filename traz uma versão ASCII para clientes
+// antigos e filename* (RFC 5987) o nome em UTF-8, para que nomes
+// acentuados cheguem intactos
+func contentDisposition(disposition, name string) string {
+	name = storage.SafeFileName(name)
+
+	var ascii, encoded strings.Builder
+	for _, r := range name {
+		switch {
+		case r == '"' || r == '\\':
+			ascii.WriteByte('_')
+		case r < utf8.RuneSelf:
+			ascii.WriteRune(r)
+		default:
+			ascii.WriteRune(asciiFallback(r))
+		}
+	}
+	for _, b := range []byte(name) {
+		if isAttrChar(b) {
+			encoded.WriteByte(b)
+		} else {
+			fmt.Fprintf(&encoded, "%%%02X", b)
+		}
+	}
+	return fmt.Sprintf(
+		`%s; filename="%s"; filename*=UTF-8''%s`,
+		disposition, ascii.String(), encoded.String(),
 	)
+}
 
-	contentType := "application/octet-stream"
-	if attachment.ContentType != nil {
-		contentType = *attachment.ContentType
+// asciiFallback troca letras acentuadas do português pela letra sem acento
+func asciiFallback(r rune) rune {
+	if base, ok := unaccented[r]; ok {
+		return base
 	}
-	c.Header("Content-Type", contentType)
+	return '_'
+}
 
-	c.Header("Content-Transfer-Encoding", "binary")
-	c.Header("Cache-Control", "no-cache")
+var unaccented = func() map[rune]rune {
+	m := make(map[rune]rune)
+	for base, accented := range map[rune]string{
+		'a': "áàâãä", 'e': "éèêë", 'i': "íìîï", 'o': "óòôõö", 'u': "úùûü",
+		'c': "ç", 'n': "ñ",
+		'A': "ÁÀÂÃÄ", 'E': "ÉÈÊË", 'I': "ÍÌÎÏ", 'O': "ÓÒÔÕÖ", 'U': "ÚÙÛÜ",
+		'C': "Ç", 'N': "Ñ",
+	} {
+		for _, r := range accented {
+			m[r] = base
+		}
+	}
+	return m
+}()
 
-	// Serve the file
-	c.File(filePath)
+// isAttrChar reports whether b may appear unencoded in an RFC 5987 value
+func isAttrChar(b byte) bool {
+	switch {
+	case 'a' <= b && b <= 'z', 'A' <= b && b <= 'Z', '0' <= b && b <= '9':
+		return true
+	}
+	return strings.IndexByte("!#$&+-.^_`|~", b) >= 0
 }
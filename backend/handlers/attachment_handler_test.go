@@ -0,0 +1,115 @@
+// backend/handlers/attachment_handler_test.go
+package handlers
+
+import (
+	"ProtocolManager/backend/attachment"
+	"ProtocolManager/backend/middleware"
+	"ProtocolManager/backend/models"
+	"ProtocolManager/backend/repository"
+	"ProtocolManager/backend/storage"
+	"ProtocolManager/backend/testdb"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestDownloadRequiresCleanScan(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := testdb.Open(t)
+	refs := testdb.SeedProtocolRefs(t, db)
+
+	numbers, err := repository.NewProtocolNumberGenerator(
+		repository.DefaultProtocolNumberFormat,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	actor := repository.Actor{PersonnelID: refs.PersonnelID, Role: models.RoleAdmin}
+	protocol, err := repository.NewProtocolRepository(
+		db, numbers, repository.NewDeadlineCalculator(false), 24*time.Hour,
+	).Create(repository.AllBranchesScope(), actor, models.Protocol{
+		Title:      "Download",
+		TypeID:     refs.TypeID,
+		StatusID:   refs.StatusID,
+		CustomerID: refs.CustomerID,
+		AssignedTo: refs.PersonnelID,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := storage.NewLocal(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	const content = "conteúdo do anexo"
+	if err := files.Put(
+		context.Background(), "protocols/download/file.txt",
+		strings.NewReader(content), int64(len(content)), "text/plain",
+	); err != nil {
+		t.Fatal(err)
+	}
+	repo := repository.NewProtocolAttachmentRepository(db)
+	created, err := repo.Create(repository.AllBranchesScope(), models.ProtocolAttachment{
+		ProtocolID:      protocol.ProtocolID,
+		FileName:        "file.txt",
+		FilePath:        "protocols/download/file.txt",
+		FileSize:        int64(len(content)),
+		ContentType:     "text/plain",
+		UploadedBy:      refs.PersonnelID,
+		UploadedAt:      time.Now(),
+		ScanStatus:      models.ScanPending,
+		ThumbnailStatus: models.ThumbnailPending,
+	}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handler := NewProtocolAttachmentHandler(
+		attachment.NewService(repo, files, models.AttachmentPolicy{}),
+	)
+	r := gin.New()
+	r.GET("/api/attachments/:id/download", func(c *gin.Context) {
+		c.Set(middleware.ContextScopeKey, repository.AllBranchesScope())
+	}, handler.DownloadAttachment)
+
+	download := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(
+			http.MethodGet,
+			fmt.Sprintf("/api/attachments/%d/download", created.AttachmentID), nil,
+		))
+		return w
+	}
+	setStatus := func(status string) {
+		t.Helper()
+		for _, table := range []string{"protocol_attachments", "protocol_attachment_versions"} {
+			if err := db.Table(table).
+				Where("attachment_id = ?", created.AttachmentID).
+				Update("scan_status", status).Error; err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	if w := download(); w.Code != http.StatusConflict {
+		t.Errorf("pending: status %d, want %d", w.Code, http.StatusConflict)
+	}
+
+	setStatus(models.ScanInfected)
+	if w := download(); w.Code != http.StatusForbidden {
+		t.Errorf("infected: status %d, want %d", w.Code, http.StatusForbidden)
+	}
+
+	setStatus(models.ScanClean)
+	w := download()
+	if w.Code != http.StatusOK || w.Body.String() != content {
+		t.Errorf("clean: status %d body %q", w.Code, w.Body)
+	}
+}
@@ -16,12 +16,14 @@ import (
 type AuthHandler struct {
 	Repo      *repository.UserRepository
 	JWTSecret []byte
+	// Validade dos tokens emitidos
+	TokenTTL time.Duration
 }
 
 func NewAuthHandler(
-	repo *repository.UserRepository, secret string,
+	repo *repository.UserRepository, secret string, ttl time.Duration,
 ) *AuthHandler {
-	return &AuthHandler{Repo: repo, JWTSecret: []byte(secret)}
+	return &AuthHandler{Repo: repo, JWTSecret: []byte(secret), TokenTTL: ttl}
 }
 
 func (h *AuthHandler) Login(c *gin.Context) {
@@ -60,7 +62,7 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		jwt.SigningMethodHS256, jwt.MapClaims{
 			"user_id": user.UserID,
 			"role":    user.Role,
-			"exp":     time.Now().Add(time.Hour * 24).Unix(),
+			"exp":     time.Now().Add(h.TokenTTL).Unix(),
 		},
 	)
 
@@ -90,7 +92,6 @@ func (h *AuthHandler) Register(c *gin.Context) {
 	var input struct {
 		Email    string `json:"email" binding:"required,email"`
 		Password string `json:"password" binding:"required,min=6"`
-		Role     string `json:"role"`
 	}
 
 	if err := c.ShouldBindJSON(&input); err != nil {
@@ -120,10 +121,11 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		return
 	}
 
+	// O papel nunca vem do cliente; apenas um admin pode promover o usuário
 	user := models.User{
 		Email:        input.Email,
 		PasswordHash: string(hashedPassword),
-		Role:         input.Role,
+		Role:         models.RoleReadOnly,
 		Active:       true,
 	}
 
@@ -2,6 +2,7 @@
 package handlers
 
 import (
+	"ProtocolManager/backend/middleware"
 	"ProtocolManager/backend/models"
 	"ProtocolManager/backend/repository"
 	"net/http"
@@ -19,7 +20,7 @@ func NewCustomerHandler(repo *repository.CustomerRepository) *CustomerHandler {
 }
 
 func (h *CustomerHandler) GetAllCustomers(c *gin.Context) {
-	customers, err := h.Repo.GetAll()
+	customers, err := h.Repo.GetAll(middleware.CurrentScope(c))
 	if err != nil {
 		c.JSON(
 			http.StatusInternalServerError,
@@ -37,7 +38,7 @@ func (h *CustomerHandler) GetCustomerByID(c *gin.Context) {
 		return
 	}
 
-	customer, err := h.Repo.GetByID(id)
+	customer, err := h.Repo.GetByID(middleware.CurrentScope(c), id)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Customer not found"})
 		return
@@ -52,10 +53,13 @@ func (h *CustomerHandler) CreateCustomer(c *gin.Context) {
 		return
 	}
 
-	created, err := h.Repo.Create(customer)
+	scope := middleware.CurrentScope(c)
+	customer.BranchID = scopedBranch(scope, customer.BranchID)
+
+	created, err := h.Repo.Create(scope, customer)
 	if err != nil {
 		c.JSON(
-			http.StatusInternalServerError,
+			statusForError(err),
 			gin.H{"error": "Failed to create customer: " + err.Error()},
 		)
 		return
@@ -76,10 +80,13 @@ func (h *CustomerHandler) UpdateCustomer(c *gin.Context) {
 		return
 	}
 
-	err = h.Repo.Update(id, customer)
+	scope := middleware.CurrentScope(c)
+	customer.BranchID = scopedBranch(scope, customer.BranchID)
+
+	err = h.Repo.Update(scope, id, customer)
 	if err != nil {
 		c.JSON(
-			http.StatusInternalServerError,
+			statusForError(err),
 			gin.H{"error": "Failed to update customer: " + err.Error()},
 		)
 		return
@@ -94,10 +101,10 @@ func (h *CustomerHandler) DeleteCustomer(c *gin.Context) {
 		return
 	}
 
-	err = h.Repo.Delete(id)
+	err = h.Repo.Delete(middleware.CurrentScope(c), id)
 	if err != nil {
 		c.JSON(
-			http.StatusInternalServerError,
+			statusForError(err),
 			gin.H{"error": "Failed to delete customer: " + err.Error()},
 		)
 		return
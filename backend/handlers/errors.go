@@ -0,0 +1,60 @@
+// backend/handlers/errors.go
+package handlers
+
+import (
+	"ProtocolManager/backend/middleware"
+	"ProtocolManager/backend/repository"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// statusForError maps repository errors to HTTP status codes
+func statusForError(err error) int {
+	var readOnly *repository.ReadOnlyFieldError
+	switch {
+	case errors.As(err, &readOnly):
+		return http.StatusBadRequest
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, repository.ErrOutOfScope):
+		return http.StatusForbidden
+	case errors.Is(err, repository.ErrTransitionNotAllowed),
+		errors.Is(err, repository.ErrLastAdmin):
+		return http.StatusConflict
+	case errors.Is(err, repository.ErrNoteRequired),
+		errors.Is(err, repository.ErrInvalidInitialStatus):
+		return http.StatusUnprocessableEntity
+	// Registro ainda referenciado, referência inexistente ou duplicado
+	case errors.Is(err, gorm.ErrForeignKeyViolated),
+		errors.Is(err, gorm.ErrDuplicatedKey):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// scopedBranch forces the caller's branch on records created by users that
+// cannot see every branch
+func scopedBranch(scope repository.Scope, branchID *int) *int {
+	if scope.AllBranches {
+		return branchID
+	}
+	return scope.BranchID
+}
+
+// requireActor resolves the authenticated personnel performing a write and
+// aborts with 403 when the user is not linked to any personnel record
+func requireActor(c *gin.Context) (repository.Actor, bool) {
+	actor, ok := middleware.CurrentActor(c)
+	if !ok {
+		c.JSON(
+			http.StatusForbidden,
+			gin.H{"error": "Usuário sem colaborador vinculado"},
+		)
+		return actor, false
+	}
+	return actor, true
+}
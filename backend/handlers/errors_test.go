@@ -0,0 +1,35 @@
+// backend/handlers/errors_test.go
+package handlers
+
+import (
+	"ProtocolManager/backend/repository"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+func TestStatusForError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{gorm.ErrRecordNotFound, http.StatusNotFound},
+		{repository.ErrOutOfScope, http.StatusForbidden},
+		{fmt.Errorf("%w: status 1 para 2", repository.ErrTransitionNotAllowed), http.StatusConflict},
+		{repository.ErrLastAdmin, http.StatusConflict},
+		{repository.ErrNoteRequired, http.StatusUnprocessableEntity},
+		{fmt.Errorf("%w: status 3", repository.ErrInvalidInitialStatus), http.StatusUnprocessableEntity},
+		// Transição repetida para o mesmo tipo e par de status
+		{gorm.ErrDuplicatedKey, http.StatusConflict},
+		{gorm.ErrForeignKeyViolated, http.StatusConflict},
+		{errors.New("boom"), http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		if got := statusForError(tt.err); got != tt.want {
+			t.Errorf("%v: got %d, want %d", tt.err, got, tt.want)
+		}
+	}
+}
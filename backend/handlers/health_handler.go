@@ -0,0 +1,29 @@
+// backend/handlers/health_handler.go
+package handlers
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type HealthHandler struct {
+	DB *sql.DB
+}
+
+func NewHealthHandler(db *sql.DB) *HealthHandler {
+	return &HealthHandler{DB: db}
+}
+
+// Health reports whether the API and its database are reachable
+func (h *HealthHandler) Health(c *gin.Context) {
+	if err := h.DB.PingContext(c.Request.Context()); err != nil {
+		c.JSON(
+			http.StatusServiceUnavailable,
+			gin.H{"status": "unavailable", "error": "Database unreachable"},
+		)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
@@ -0,0 +1,71 @@
+// backend/handlers/holiday_handler.go
+package handlers
+
+import (
+	"ProtocolManager/backend/models"
+	"ProtocolManager/backend/repository"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type HolidayHandler struct {
+	Repo *repository.HolidayRepository
+}
+
+func NewHolidayHandler(repo *repository.HolidayRepository) *HolidayHandler {
+	return &HolidayHandler{Repo: repo}
+}
+
+func (h *HolidayHandler) GetAllHolidays(c *gin.Context) {
+	holidays, err := h.Repo.GetAll()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, holidays)
+}
+
+func (h *HolidayHandler) CreateHoliday(c *gin.Context) {
+	var holiday models.Holiday
+	if err := c.ShouldBindJSON(&holiday); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if holiday.Date.IsZero() || holiday.Name == "" {
+		c.JSON(
+			http.StatusBadRequest,
+			gin.H{"error": "date and name are required"},
+		)
+		return
+	}
+
+	created, err := h.Repo.Create(holiday)
+	if err != nil {
+		c.JSON(
+			http.StatusInternalServerError,
+			gin.H{"error": "Failed to create holiday: " + err.Error()},
+		)
+		return
+	}
+	c.JSON(http.StatusCreated, created)
+}
+
+func (h *HolidayHandler) DeleteHoliday(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	if err := h.Repo.Delete(id); err != nil {
+		c.JSON(
+			statusForError(err),
+			gin.H{"error": "Failed to delete holiday: " + err.Error()},
+		)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Holiday deleted successfully"})
+}
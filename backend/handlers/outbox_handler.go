@@ -0,0 +1,29 @@
+// backend/handlers/outbox_handler.go
+package handlers
+
+import (
+	"ProtocolManager/backend/repository"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type OutboxHandler struct {
+	Repo *repository.OutboxRepository
+}
+
+func NewOutboxHandler(repo *repository.OutboxRepository) *OutboxHandler {
+	return &OutboxHandler{Repo: repo}
+}
+
+// GetMetrics reports, per consumer, how far the relay is behind the outbox:
+// pending events and the age in seconds of the oldest one
+func (h *OutboxHandler) GetMetrics(c *gin.Context) {
+	lags, err := h.Repo.Lag(time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"consumers": lags})
+}
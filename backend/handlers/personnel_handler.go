@@ -2,6 +2,7 @@
 package handlers
 
 import (
+	"ProtocolManager/backend/middleware"
 	"ProtocolManager/backend/models"
 	"ProtocolManager/backend/repository"
 	"net/http"
@@ -19,7 +20,7 @@ func NewPersonnelHandler(repo *repository.PersonnelRepository) *PersonnelHandler
 }
 
 func (h *PersonnelHandler) GetAllPersonnel(c *gin.Context) {
-	personnel, err := h.Repo.GetAll()
+	personnel, err := h.Repo.GetAll(middleware.CurrentScope(c))
 	if err != nil {
 		c.JSON(
 			http.StatusInternalServerError,
@@ -37,7 +38,7 @@ func (h *PersonnelHandler) GetPersonnelByID(c *gin.Context) {
 		return
 	}
 
-	personnel, err := h.Repo.GetByID(id)
+	personnel, err := h.Repo.GetByID(middleware.CurrentScope(c), id)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Personnel not found"})
 		return
@@ -52,10 +53,13 @@ func (h *PersonnelHandler) CreatePersonnel(c *gin.Context) {
 		return
 	}
 
-	created, err := h.Repo.Create(personnel)
+	scope := middleware.CurrentScope(c)
+	personnel.BranchID = scopedBranch(scope, personnel.BranchID)
+
+	created, err := h.Repo.Create(scope, personnel)
 	if err != nil {
 		c.JSON(
-			http.StatusInternalServerError,
+			statusForError(err),
 			gin.H{"error": "Failed to create personnel"},
 		)
 		return
@@ -76,10 +80,13 @@ func (h *PersonnelHandler) UpdatePersonnel(c *gin.Context) {
 		return
 	}
 
-	err = h.Repo.Update(id, personnel)
+	scope := middleware.CurrentScope(c)
+	personnel.BranchID = scopedBranch(scope, personnel.BranchID)
+
+	err = h.Repo.Update(scope, id, personnel)
 	if err != nil {
 		c.JSON(
-			http.StatusInternalServerError,
+			statusForError(err),
 			gin.H{"error": "Failed to update personnel"},
 		)
 		return
@@ -94,10 +101,10 @@ func (h *PersonnelHandler) DeletePersonnel(c *gin.Context) {
 		return
 	}
 
-	err = h.Repo.Delete(id)
+	err = h.Repo.Delete(middleware.CurrentScope(c), id)
 	if err != nil {
 		c.JSON(
-			http.StatusInternalServerError,
+			statusForError(err),
 			gin.H{"error": "Failed to delete personnel"},
 		)
 		return
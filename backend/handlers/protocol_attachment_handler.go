@@ -2,12 +2,18 @@
 package handlers
 
 import (
+	"ProtocolManager/backend/attachment"
+	"ProtocolManager/backend/middleware"
 	"ProtocolManager/backend/models"
+	"ProtocolManager/backend/preview"
 	"ProtocolManager/backend/repository"
+	"ProtocolManager/backend/storage"
+	"bufio"
+	"errors"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
-	"os"
 	"strconv"
 	"time"
 
@@ -15,11 +21,13 @@ import (
 )
 
 type ProtocolAttachmentHandler struct {
-	Repo *repository.ProtocolAttachmentRepository
+	Attachments *attachment.Service
 }
 
-func NewProtocolAttachmentHandler(repo *repository.ProtocolAttachmentRepository) *ProtocolAttachmentHandler {
-	return &ProtocolAttachmentHandler{Repo: repo}
+func NewProtocolAttachmentHandler(
+	attachments *attachment.Service,
+) *ProtocolAttachmentHandler {
+	return &ProtocolAttachmentHandler{Attachments: attachments}
 }
 
 func (h *ProtocolAttachmentHandler) GetAttachmentsByProtocolID(c *gin.Context) {
@@ -29,11 +37,17 @@ func (h *ProtocolAttachmentHandler) GetAttachmentsByProtocolID(c *gin.Context) {
 		return
 	}
 
-	attachments, err := h.Repo.GetByProtocolID(id)
+	attachments, err := h.Attachments.List(middleware.CurrentScope(c), id)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	for i := range attachments {
+		a := &attachments[i]
+		a.ThumbnailURL, a.PreviewURL = previewURLs(
+			a.AttachmentID, a.Version, a.ScanStatus, a.ContentType, a.ThumbnailPath,
+		)
+	}
 	c.JSON(http.StatusOK, attachments)
 }
 
@@ -44,111 +58,266 @@ func (h *ProtocolAttachmentHandler) UploadAttachment(c *gin.Context) {
 		return
 	}
 
-	// Get file
-	file, header, err := c.Request.FormFile("file")
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "No file uploaded"})
+	actor, ok := requireActor(c)
+	if !ok {
 		return
 	}
-	defer file.Close()
 
-	// Parse uploaded by
-	uploadedByStr := c.PostForm("uploaded_by")
-	uploadedBy, err := strconv.Atoi(uploadedByStr)
-	if err != nil {
-		c.JSON(
-			http.StatusBadRequest,
-			gin.H{"error": "Invalid uploaded_by parameter"},
-		)
+	scope := middleware.CurrentScope(c)
+	upload, ok := h.receiveUpload(c, scope, protocolID)
+	if !ok {
 		return
 	}
 
-	// Create directory if it doesn't exist
-	uploadDir := "./uploads"
-	if err := os.MkdirAll(uploadDir, 0755); err != nil {
-		c.JSON(
-			http.StatusInternalServerError,
-			gin.H{"error": "Failed to create upload directory"},
-		)
+	// Create attachment record
+	record := models.ProtocolAttachment{
+		ProtocolID:  protocolID,
+		FileName:    upload.FileName,
+		FilePath:    upload.Key,
+		FileSize:    upload.Size,
+		ContentType: upload.ContentType,
+		UploadedBy:  actor.PersonnelID, // never trust an uploaded_by form field
+		UploadedAt:  time.Now(),
+		Checksum:    upload.Checksum,
+		// Downloads are refused until the antivirus marks the file clean;
+		// the thumbnail is generated in the background after that
+		ScanStatus:      models.ScanPending,
+		ThumbnailStatus: models.ThumbnailPending,
+	}
+
+	created, err := h.Attachments.Create(
+		c.Request.Context(), scope, record, upload.Limit.Policy.ProtocolQuota,
+	)
+	if err != nil {
+		abortRecordFailed(c, upload, err)
 		return
 	}
 
-	// Generate unique filename
-	filename := fmt.Sprintf("%d-%s", time.Now().Unix(), header.Filename)
-	filepath := fmt.Sprintf("%s/%s", uploadDir, filename)
+	c.JSON(http.StatusCreated, created)
+}
 
-	// Save file
-	out, err := os.Create(filepath)
+// UploadVersion stores a corrected document as a new version of the
+// attachment; earlier versions stay available
+func (h *ProtocolAttachmentHandler) UploadVersion(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(
-			http.StatusInternalServerError,
-			gin.H{"error": "Failed to save file"},
-		)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid attachment ID"})
+		return
+	}
+
+	actor, ok := requireActor(c)
+	if !ok {
 		return
 	}
-	defer out.Close()
 
-	_, err = io.Copy(out, file)
+	scope := middleware.CurrentScope(c)
+	current, err := h.Attachments.Get(scope, id)
 	if err != nil {
-		c.JSON(
-			http.StatusInternalServerError,
-			gin.H{"error": "Failed to copy file"},
-		)
+		c.JSON(statusForError(err), gin.H{"error": "Attachment not found"})
 		return
 	}
 
-	// Create attachment record
-	attachment := models.ProtocolAttachment{
-		ProtocolID:  protocolID,
-		FileName:    header.Filename,
-		FilePath:    filepath,
-		FileSize:    header.Size,                       // header.Size is already int64, no need to cast
-		ContentType: header.Header.Get("Content-Type"), // Use ContentType instead of FileType
-		UploadedBy:  uploadedBy,
+	upload, ok := h.receiveUpload(c, scope, current.ProtocolID)
+	if !ok {
+		return
+	}
+
+	version := models.AttachmentVersion{
+		FileName:    upload.FileName,
+		FilePath:    upload.Key,
+		FileSize:    upload.Size,
+		ContentType: upload.ContentType,
+		UploadedBy:  actor.PersonnelID,
+		UploadedAt:  time.Now(),
+		Checksum:    upload.Checksum,
+		ScanStatus:  models.ScanPending,
+
+		ThumbnailStatus: models.ThumbnailPending,
 	}
 
-	created, err := h.Repo.Create(attachment)
+	updated, err := h.Attachments.CreateVersion(
+		c.Request.Context(), scope, id, version,
+		upload.Limit.Policy.ProtocolQuota,
+	)
 	if err != nil {
-		c.JSON(
-			http.StatusInternalServerError,
-			gin.H{"error": "Failed to save attachment record"},
-		)
+		abortRecordFailed(c, upload, err)
 		return
 	}
 
-	c.JSON(http.StatusCreated, created)
+	c.JSON(http.StatusCreated, updated)
 }
 
-func (h *ProtocolAttachmentHandler) DeleteAttachment(c *gin.Context) {
+func (h *ProtocolAttachmentHandler) GetVersions(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid attachment ID"})
 		return
 	}
 
-	// Get attachment to find file path
-	attachment, err := h.Repo.GetByID(id)
+	versions, err := h.Attachments.Versions(middleware.CurrentScope(c), id)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Attachment not found"})
+		c.JSON(statusForError(err), gin.H{"error": "Attachment not found"})
 		return
 	}
+	for i := range versions {
+		v := &versions[i]
+		v.ThumbnailURL, v.PreviewURL = previewURLs(
+			v.AttachmentID, v.Version, v.ScanStatus, v.ContentType, v.ThumbnailPath,
+		)
+	}
+	c.JSON(http.StatusOK, versions)
+}
 
-	// Delete file if it exists
-	if _, err := os.Stat(attachment.FilePath); err == nil {
-		if err := os.Remove(attachment.FilePath); err != nil {
-			c.JSON(
-				http.StatusInternalServerError,
-				gin.H{"error": "Failed to delete file"},
-			)
-			return
+// previewURLs monta os links da miniatura, quando já gerada, e da
+// visualização no navegador, para imagens liberadas pelo antivírus. A
+// versão no link evita que o navegador mostre a miniatura de uma anterior.
+func previewURLs(
+	attachmentID, version int, scanStatus, contentType, thumbnailPath string,
+) (thumbnailURL, previewURL string) {
+	if scanStatus != models.ScanClean {
+		return "", ""
+	}
+	base := fmt.Sprintf("/api/attachments/%d/preview?version=%d", attachmentID, version)
+	if thumbnailPath != "" {
+		thumbnailURL = base + "&size=thumb"
+	}
+	if preview.IsInlineImage(contentType) {
+		previewURL = base
+	}
+	return thumbnailURL, previewURL
+}
+
+// storedUpload is a file already written to storage, not yet recorded
+type storedUpload struct {
+	FileName    string
+	Key         string
+	Size        int64
+	ContentType string
+	Checksum    string
+	Limit       uploadLimit
+}
+
+// receiveUpload streams the "file" part of the request into storage,
+// enforcing the size limit, the protocol quota and the MIME policy of the
+// protocol type. It writes the error response itself and returns false on
+// any rejection.
+func (h *ProtocolAttachmentHandler) receiveUpload(
+	c *gin.Context, scope repository.Scope, protocolID int,
+) (storedUpload, bool) {
+	policy, used, err := h.Attachments.UploadTarget(scope, protocolID)
+	if err != nil {
+		c.JSON(statusForError(err), gin.H{"error": "Protocol not found"})
+		return storedUpload{}, false
+	}
+	limit := uploadLimit{Policy: policy, Used: used}
+	maxBytes, _ := limit.Bytes()
+
+	// The body is streamed part by part; the request as a whole is capped
+	// as well so oversized uploads fail before reaching storage
+	if maxBytes >= 0 {
+		if c.Request.ContentLength > maxBytes+multipartOverhead {
+			limit.abortTooLarge(c)
+			return storedUpload{}, false
+		}
+		c.Request.Body = http.MaxBytesReader(
+			c.Writer, c.Request.Body, maxBytes+multipartOverhead,
+		)
+	}
+
+	reader, err := c.Request.MultipartReader()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Expected multipart/form-data"})
+		return storedUpload{}, false
+	}
+	part, err := nextFilePart(reader, "file")
+	if isTooLarge(err) {
+		limit.abortTooLarge(c)
+		return storedUpload{}, false
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No file uploaded"})
+		return storedUpload{}, false
+	}
+	defer part.Close()
+
+	// The client's name is only displayed; the storage key never uses it
+	fileName := storage.SafeFileName(part.FileName())
+	key := storage.NewAttachmentKey(protocolID, fileName)
+
+	// The type comes from the file's first bytes, never from the client
+	body := bufio.NewReaderSize(part, storage.SniffLength)
+	head, err := body.Peek(storage.SniffLength)
+	if err != nil && err != io.EOF {
+		if isTooLarge(err) {
+			limit.abortTooLarge(c)
+			return storedUpload{}, false
 		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read upload"})
+		return storedUpload{}, false
+	}
+	contentType := storage.DetectContentType(head, fileName)
+	if !policy.AllowsType(contentType) {
+		abortUnsupportedType(c, policy, contentType)
+		return storedUpload{}, false
 	}
 
-	// Delete record
-	if err := h.Repo.Delete(id); err != nil {
+	file := &limitedReader{R: body, Limit: maxBytes}
+	checksum, err := h.Attachments.Store(c.Request.Context(), key, file, contentType)
+	if err != nil {
+		if isTooLarge(err) {
+			limit.abortTooLarge(c)
+			return storedUpload{}, false
+		}
+		log.Printf("Failed to store attachment %s: %v", key, err)
 		c.JSON(
 			http.StatusInternalServerError,
-			gin.H{"error": "Failed to delete attachment record"},
+			gin.H{"error": "Failed to save file"},
+		)
+		return storedUpload{}, false
+	}
+
+	return storedUpload{
+		FileName:    fileName,
+		Key:         key,
+		Size:        file.N,
+		ContentType: contentType,
+		Checksum:    checksum,
+		Limit:       limit,
+	}, true
+}
+
+// abortRecordFailed answers an upload whose record couldn't be saved; the
+// service has already removed the stored file
+func abortRecordFailed(c *gin.Context, upload storedUpload, err error) {
+	if errors.Is(err, repository.ErrAttachmentQuotaExceeded) {
+		// Another upload took the remaining space meanwhile
+		limit := upload.Limit
+		limit.Used = limit.Policy.ProtocolQuota
+		limit.abortTooLarge(c)
+		return
+	}
+	c.JSON(
+		statusForError(err),
+		gin.H{"error": "Failed to save attachment record"},
+	)
+}
+
+func (h *ProtocolAttachmentHandler) DeleteAttachment(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid attachment ID"})
+		return
+	}
+
+	// Records go first so a failure never leaves a row without its file;
+	// the files of every version are removed once the rows are gone
+	err = h.Attachments.Delete(
+		c.Request.Context(), middleware.CurrentScope(c), id,
+	)
+	if err != nil {
+		c.JSON(
+			statusForError(err),
+			gin.H{"error": "Failed to delete attachment: " + err.Error()},
 		)
 		return
 	}
@@ -2,6 +2,8 @@
 package handlers
 
 import (
+	"ProtocolManager/backend/attachment"
+	"ProtocolManager/backend/middleware"
 	"ProtocolManager/backend/models"
 	"ProtocolManager/backend/repository"
 	"net/http"
@@ -11,20 +13,34 @@ import (
 )
 
 type ProtocolHandler struct {
-	Repo *repository.ProtocolRepository
+	Repo        *repository.ProtocolRepository
+	Attachments *attachment.Service
 }
 
-func NewProtocolHandler(repo *repository.ProtocolRepository) *ProtocolHandler {
-	return &ProtocolHandler{Repo: repo}
+func NewProtocolHandler(
+	repo *repository.ProtocolRepository, attachments *attachment.Service,
+) *ProtocolHandler {
+	return &ProtocolHandler{Repo: repo, Attachments: attachments}
 }
 
+// GetAllProtocols lists protocols one page at a time. Query parameters:
+// page, page_size, sort, order (asc|desc), status_id, type_id, customer_id,
+// branch_id, assigned_to, priority, is_closed, sla_state, created_from,
+// created_to, deadline_from and deadline_to (dates as YYYY-MM-DD or
+// RFC 3339).
 func (h *ProtocolHandler) GetAllProtocols(c *gin.Context) {
-	protocols, err := h.Repo.GetAll()
+	query, err := parseProtocolQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	page, err := h.Repo.List(middleware.CurrentScope(c), query)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, protocols)
+	c.JSON(http.StatusOK, page)
 }
 
 func (h *ProtocolHandler) GetProtocolByID(c *gin.Context) {
@@ -34,7 +50,7 @@ func (h *ProtocolHandler) GetProtocolByID(c *gin.Context) {
 		return
 	}
 
-	protocol, err := h.Repo.GetByID(id)
+	protocol, err := h.Repo.GetByID(middleware.CurrentScope(c), id)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Protocol not found"})
 		return
@@ -49,25 +65,18 @@ func (h *ProtocolHandler) CreateProtocol(c *gin.Context) {
 		return
 	}
 
-	if protocol.CreatedBy == 0 && protocol.AssignedTo > 0 {
-		protocol.CreatedBy = protocol.AssignedTo
-	} else {
-		// Try to find at least one valid personnel ID in the database
-		var personnel models.SalesPersonnel
-		if err := h.Repo.DB.First(&personnel).Error; err != nil {
-			c.JSON(
-				http.StatusInternalServerError,
-				gin.H{"error": "No valid personnel found in the database"},
-			)
-			return
-		}
-		protocol.CreatedBy = personnel.PersonnelID
+	actor, ok := requireActor(c)
+	if !ok {
+		return
 	}
 
-	created, err := h.Repo.Create(protocol)
+	scope := middleware.CurrentScope(c)
+	protocol.BranchID = scopedBranch(scope, protocol.BranchID)
+
+	created, err := h.Repo.Create(scope, actor, protocol)
 	if err != nil {
 		c.JSON(
-			http.StatusInternalServerError,
+			statusForError(err),
 			gin.H{"error": "Failed to create protocol: " + err.Error()},
 		)
 		return
@@ -84,6 +93,29 @@ func (h *ProtocolHandler) UpdateProtocol(c *gin.Context) {
 		return
 	}
 
+	actor, ok := requireActor(c)
+	if !ok {
+		return
+	}
+
+	scope := middleware.CurrentScope(c)
+
+	// Agentes só podem alterar protocolos atribuídos a eles
+	if actor.Role == models.RoleAgent {
+		current, err := h.Repo.GetByID(scope, id)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Protocolo não encontrado"})
+			return
+		}
+		if actor.PersonnelID != current.AssignedTo {
+			c.JSON(
+				http.StatusForbidden,
+				gin.H{"error": "Protocolo não está atribuído a você"},
+			)
+			return
+		}
+	}
+
 	var payload map[string]interface{}
 	if err := c.ShouldBindJSON(&payload); err != nil {
 		c.JSON(
@@ -93,16 +125,16 @@ func (h *ProtocolHandler) UpdateProtocol(c *gin.Context) {
 		return
 	}
 
-	err = h.Repo.UpdateFields(id, payload)
+	err = h.Repo.UpdateFields(scope, actor, id, payload)
 	if err != nil {
 		c.JSON(
-			http.StatusInternalServerError,
+			statusForError(err),
 			gin.H{"error": "Erro ao atualizar protocolo: " + err.Error()},
 		)
 		return
 	}
 
-	updated, err := h.Repo.GetByID(id)
+	updated, err := h.Repo.GetByID(scope, id)
 	if err != nil {
 		c.JSON(
 			http.StatusInternalServerError,
@@ -121,13 +153,17 @@ func (h *ProtocolHandler) DeleteProtocol(c *gin.Context) {
 		return
 	}
 
-	if err := h.Repo.Delete(id); err != nil {
+	filePaths, err := h.Repo.Delete(middleware.CurrentScope(c), id)
+	if err != nil {
 		c.JSON(
-			http.StatusInternalServerError,
+			statusForError(err),
 			gin.H{"error": "Failed to delete protocol: " + err.Error()},
 		)
 		return
 	}
 
+	// Files are only removed once the rows are gone for good
+	h.Attachments.RemoveFiles(c.Request.Context(), filePaths)
+
 	c.JSON(http.StatusOK, gin.H{"message": "Protocol deleted successfully"})
 }
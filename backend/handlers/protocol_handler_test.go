@@ -0,0 +1,134 @@
+// backend/handlers/protocol_handler_test.go
+package handlers
+
+import (
+	"ProtocolManager/backend/middleware"
+	"ProtocolManager/backend/models"
+	"ProtocolManager/backend/repository"
+	"ProtocolManager/backend/testdb"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestCreateProtocolParallelNumbersAreUnique(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := testdb.Open(t)
+	refs := testdb.SeedProtocolRefs(t, db)
+
+	numbers, err := repository.NewProtocolNumberGenerator(
+		repository.DefaultProtocolNumberFormat,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := NewProtocolHandler(
+		repository.NewProtocolRepository(
+			db, numbers, repository.NewDeadlineCalculator(false), 24*time.Hour,
+		),
+		nil,
+	)
+
+	// Usuário já autenticado, como a RequireAuth deixaria no contexto
+	user := models.User{
+		UserID: 1, Role: models.RoleAdmin, Active: true,
+		PersonnelID: &refs.PersonnelID,
+	}
+	r := gin.New()
+	r.POST("/api/protocols", func(c *gin.Context) {
+		c.Set(middleware.ContextUserKey, user)
+		c.Set(middleware.ContextScopeKey, repository.AllBranchesScope())
+	}, handler.CreateProtocol)
+
+	body, _ := json.Marshal(map[string]any{
+		"title":       "Criação paralela",
+		"type_id":     refs.TypeID,
+		"status_id":   refs.StatusID,
+		"customer_id": refs.CustomerID,
+		"assigned_to": refs.PersonnelID,
+	})
+
+	const requests = 50
+	got := make([]string, requests)
+	var wg sync.WaitGroup
+	for i := range requests {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(
+				http.MethodPost, "/api/protocols", bytes.NewReader(body),
+			)
+			req.Header.Set("Content-Type", "application/json")
+			r.ServeHTTP(w, req)
+
+			if w.Code != http.StatusCreated {
+				t.Errorf("request %d: status %d: %s", i, w.Code, w.Body)
+				return
+			}
+			var created models.Protocol
+			if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil {
+				t.Errorf("request %d: %v", i, err)
+				return
+			}
+			got[i] = created.ProtocolNumber
+		}()
+	}
+	wg.Wait()
+	if t.Failed() {
+		return
+	}
+
+	seen := map[string]bool{}
+	for i, number := range got {
+		if number == "" || seen[number] {
+			t.Fatalf("request %d: duplicate or empty number %q", i, number)
+		}
+		seen[number] = true
+	}
+}
+
+func TestUpdateProtocolRejectsReadOnlyFields(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	personnelID := 1
+	user := models.User{
+		UserID: 1, Role: models.RoleAdmin, Active: true,
+		PersonnelID: &personnelID,
+	}
+	// O campo é recusado antes de qualquer acesso ao banco
+	handler := NewProtocolHandler(&repository.ProtocolRepository{}, nil)
+	r := gin.New()
+	r.PUT("/api/protocols/:id", func(c *gin.Context) {
+		c.Set(middleware.ContextUserKey, user)
+		c.Set(middleware.ContextScopeKey, repository.AllBranchesScope())
+	}, handler.UpdateProtocol)
+
+	for _, field := range []string{"deadline", "type_id", "closed_at"} {
+		t.Run(field, func(t *testing.T) {
+			body, _ := json.Marshal(map[string]any{
+				"title": "Novo título",
+				field:   "2026-01-01T00:00:00Z",
+			})
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(
+				http.MethodPut, "/api/protocols/1", bytes.NewReader(body),
+			)
+			req.Header.Set("Content-Type", "application/json")
+			r.ServeHTTP(w, req)
+
+			if w.Code != http.StatusBadRequest {
+				t.Fatalf("status %d, want 400: %s", w.Code, w.Body)
+			}
+			if !strings.Contains(w.Body.String(), field) {
+				t.Errorf("error does not name %s: %s", field, w.Body)
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@
 package handlers
 
 import (
+	"ProtocolManager/backend/middleware"
 	"ProtocolManager/backend/models"
 	"ProtocolManager/backend/repository"
 	"log"
@@ -20,7 +21,7 @@ func NewProtocolHistoryHandler(repo *repository.ProtocolHistoryRepository) *Prot
 }
 
 func (h *ProtocolHistoryHandler) GetAllHistory(c *gin.Context) {
-	history, err := h.Repo.GetAll()
+	history, err := h.Repo.GetAll(middleware.CurrentScope(c))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -35,7 +36,7 @@ func (h *ProtocolHistoryHandler) GetHistoryByID(c *gin.Context) {
 		return
 	}
 
-	history, err := h.Repo.GetByID(id)
+	history, err := h.Repo.GetByID(middleware.CurrentScope(c), id)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "History not found"})
 		return
@@ -50,7 +51,7 @@ func (h *ProtocolHistoryHandler) GetHistoryByProtocolID(c *gin.Context) {
 		return
 	}
 
-	history, err := h.Repo.GetByProtocolID(id)
+	history, err := h.Repo.GetByProtocolID(middleware.CurrentScope(c), id)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -69,8 +70,17 @@ func (h *ProtocolHistoryHandler) CreateHistory(c *gin.Context) {
 
 	log.Printf("Recebido: %+v\n", history)
 
+	actor, ok := requireActor(c)
+	if !ok {
+		return
+	}
+	// Autor e tipo vêm do servidor: entradas automáticas não são criadas
+	// pela API
+	history.CreatedBy = &actor.PersonnelID
+	history.EntryType = models.HistoryStatusChange
+
 	// Validar dados obrigatórios
-	if history.ProtocolID == 0 || history.NewStatusID == 0 || history.CreatedBy == 0 {
+	if history.ProtocolID == 0 || history.NewStatusID == 0 {
 		c.JSON(
 			http.StatusBadRequest,
 			gin.H{"error": "Campos obrigatórios ausentes"},
@@ -78,10 +88,10 @@ func (h *ProtocolHistoryHandler) CreateHistory(c *gin.Context) {
 		return
 	}
 
-	created, err := h.Repo.Create(history)
+	created, err := h.Repo.Create(middleware.CurrentScope(c), history)
 	if err != nil {
 		c.JSON(
-			http.StatusInternalServerError,
+			statusForError(err),
 			gin.H{"error": "Failed to create history: " + err.Error()},
 		)
 		return
@@ -0,0 +1,136 @@
+// backend/handlers/protocol_query.go
+package handlers
+
+import (
+	"ProtocolManager/backend/models"
+	"ProtocolManager/backend/repository"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// parseProtocolQuery reads the list parameters of GET /api/protocols
+func parseProtocolQuery(c *gin.Context) (repository.ProtocolQuery, error) {
+	var q repository.ProtocolQuery
+	var err error
+
+	if q.Page, err = optionalInt(c, "page", 1); err != nil {
+		return q, err
+	}
+	// Larger pages would overflow the OFFSET even at the maximum page size
+	if maxPage := math.MaxInt32 / repository.MaxProtocolPageSize; q.Page > maxPage {
+		return q, fmt.Errorf("invalid page: must be at most %d", maxPage)
+	}
+	if q.PageSize, err = optionalInt(
+		c, "page_size", repository.DefaultProtocolPageSize,
+	); err != nil {
+		return q, err
+	}
+
+	q.SortField = c.DefaultQuery("sort", "created_at")
+	if !repository.ValidProtocolSort(q.SortField) {
+		return q, fmt.Errorf("invalid sort field: %s", q.SortField)
+	}
+
+	switch strings.ToLower(c.DefaultQuery("order", "desc")) {
+	case "asc":
+		q.SortDesc = false
+	case "desc":
+		q.SortDesc = true
+	default:
+		return q, fmt.Errorf("invalid order: must be asc or desc")
+	}
+
+	intFilters := map[string]**int{
+		"status_id":   &q.StatusID,
+		"type_id":     &q.TypeID,
+		"customer_id": &q.CustomerID,
+		"branch_id":   &q.BranchID,
+		"assigned_to": &q.AssignedTo,
+	}
+	for name, target := range intFilters {
+		if *target, err = intFilter(c, name); err != nil {
+			return q, err
+		}
+	}
+
+	q.Priority = c.Query("priority")
+
+	switch q.SLAState = c.Query("sla_state"); q.SLAState {
+	case "", models.SLAOnTrack, models.SLAAtRisk, models.SLABreached:
+	default:
+		return q, fmt.Errorf("invalid sla_state: %s", q.SLAState)
+	}
+
+	if raw := c.Query("is_closed"); raw != "" {
+		closed, err := strconv.ParseBool(raw)
+		if err != nil {
+			return q, fmt.Errorf("invalid is_closed: %s", raw)
+		}
+		q.IsClosed = &closed
+	}
+
+	dateFilters := map[string]**time.Time{
+		"created_from":  &q.CreatedFrom,
+		"created_to":    &q.CreatedTo,
+		"deadline_from": &q.DeadlineFrom,
+		"deadline_to":   &q.DeadlineTo,
+	}
+	for name, target := range dateFilters {
+		if *target, err = dateFilter(c, name); err != nil {
+			return q, err
+		}
+	}
+
+	return q, nil
+}
+
+func optionalInt(c *gin.Context, name string, fallback int) (int, error) {
+	raw := c.Query(name)
+	if raw == "" {
+		return fallback, nil
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %s", name, raw)
+	}
+	return value, nil
+}
+
+func intFilter(c *gin.Context, name string) (*int, error) {
+	raw := c.Query(name)
+	if raw == "" {
+		return nil, nil
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %s", name, raw)
+	}
+	return &value, nil
+}
+
+// dateFilter accepts a plain date or a full RFC 3339 timestamp. A plain
+// date used as an upper bound (*_to) covers the whole day.
+func dateFilter(c *gin.Context, name string) (*time.Time, error) {
+	raw := c.Query(name)
+	if raw == "" {
+		return nil, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return &t, nil
+	}
+
+	t, err := time.ParseInLocation("2006-01-02", raw, time.Local)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %s", name, raw)
+	}
+	if strings.HasSuffix(name, "_to") {
+		t = t.Add(24*time.Hour - time.Nanosecond)
+	}
+	return &t, nil
+}
@@ -0,0 +1,35 @@
+// backend/handlers/protocol_query_test.go
+package handlers
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestParseProtocolQueryRejectsOverflowingPage(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	for _, tt := range []struct {
+		page string
+		ok   bool
+	}{
+		{"1", true},
+		{"21474836", true},
+		{"21474837", false},
+		{"9223372036854775807", false},
+	} {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest("GET", "/api/protocols?page="+tt.page, nil)
+
+		_, err := parseProtocolQuery(c)
+		if tt.ok && err != nil {
+			t.Errorf("page %s: %v", tt.page, err)
+		}
+		if !tt.ok && (err == nil || !strings.Contains(err.Error(), "page")) {
+			t.Errorf("page %s: got %v, want a page error", tt.page, err)
+		}
+	}
+}
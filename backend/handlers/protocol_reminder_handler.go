@@ -2,6 +2,7 @@
 package handlers
 
 import (
+	"ProtocolManager/backend/middleware"
 	"ProtocolManager/backend/models"
 	"ProtocolManager/backend/repository"
 	"net/http"
@@ -25,7 +26,7 @@ func (h *ProtocolReminderHandler) GetRemindersByProtocolID(c *gin.Context) {
 		return
 	}
 
-	reminders, err := h.Repo.GetByProtocolID(id)
+	reminders, err := h.Repo.GetByProtocolID(middleware.CurrentScope(c), id)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -41,7 +42,9 @@ func (h *ProtocolReminderHandler) GetUpcomingReminders(c *gin.Context) {
 		return
 	}
 
-	reminders, err := h.Repo.GetUpcomingReminders(hours)
+	reminders, err := h.Repo.GetUpcomingReminders(
+		middleware.CurrentScope(c), hours,
+	)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -62,12 +65,18 @@ func (h *ProtocolReminderHandler) CreateReminder(c *gin.Context) {
 		return
 	}
 
+	actor, ok := requireActor(c)
+	if !ok {
+		return
+	}
+
 	reminder.ProtocolID = protocolID
+	reminder.CreatedBy = actor.PersonnelID
 
-	created, err := h.Repo.Create(reminder)
+	created, err := h.Repo.Create(middleware.CurrentScope(c), reminder)
 	if err != nil {
 		c.JSON(
-			http.StatusInternalServerError,
+			statusForError(err),
 			gin.H{"error": "Failed to create reminder"},
 		)
 		return
@@ -88,9 +97,11 @@ func (h *ProtocolReminderHandler) UpdateReminder(c *gin.Context) {
 		return
 	}
 
-	if err := h.Repo.Update(id, reminder); err != nil {
+	if err := h.Repo.Update(
+		middleware.CurrentScope(c), id, reminder,
+	); err != nil {
 		c.JSON(
-			http.StatusInternalServerError,
+			statusForError(err),
 			gin.H{"error": "Failed to update reminder"},
 		)
 		return
@@ -105,9 +116,9 @@ func (h *ProtocolReminderHandler) MarkReminderAsSent(c *gin.Context) {
 		return
 	}
 
-	if err := h.Repo.MarkAsSent(id); err != nil {
+	if err := h.Repo.MarkAsSent(middleware.CurrentScope(c), id); err != nil {
 		c.JSON(
-			http.StatusInternalServerError,
+			statusForError(err),
 			gin.H{"error": "Failed to mark reminder as sent"},
 		)
 		return
@@ -122,9 +133,9 @@ func (h *ProtocolReminderHandler) DeleteReminder(c *gin.Context) {
 		return
 	}
 
-	if err := h.Repo.Delete(id); err != nil {
+	if err := h.Repo.Delete(middleware.CurrentScope(c), id); err != nil {
 		c.JSON(
-			http.StatusInternalServerError,
+			statusForError(err),
 			gin.H{"error": "Failed to delete reminder"},
 		)
 		return
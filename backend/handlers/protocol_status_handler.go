@@ -102,9 +102,10 @@ func (h *ProtocolStatusHandler) DeleteStatus(c *gin.Context) {
 		return
 	}
 
+	// Status ainda usado por protocolos ou transições devolve 409
 	if err := h.Repo.Delete(id); err != nil {
 		c.JSON(
-			http.StatusInternalServerError,
+			statusForError(err),
 			gin.H{"error": "Failed to delete status: " + err.Error()},
 		)
 		return
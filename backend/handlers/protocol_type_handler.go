@@ -0,0 +1,101 @@
+// backend/handlers/protocol_type_handler.go
+package handlers
+
+import (
+	"ProtocolManager/backend/models"
+	"ProtocolManager/backend/repository"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ProtocolTypeHandler struct {
+	Repo *repository.ProtocolTypeRepository
+	// Política global; as listas do tipo a substituem
+	Policy models.AttachmentPolicy
+}
+
+func NewProtocolTypeHandler(
+	repo *repository.ProtocolTypeRepository, policy models.AttachmentPolicy,
+) *ProtocolTypeHandler {
+	return &ProtocolTypeHandler{Repo: repo, Policy: policy}
+}
+
+// attachmentPolicyRequest lists the MIME types of a protocol type; an empty
+// list falls back to the global configuration
+type attachmentPolicyRequest struct {
+	AllowedMimeTypes []string `json:"allowed_mime_types"`
+	DeniedMimeTypes  []string `json:"denied_mime_types"`
+}
+
+// GetAttachmentPolicy returns the type's own lists and the policy that is
+// actually applied to uploads of its protocols
+func (h *ProtocolTypeHandler) GetAttachmentPolicy(c *gin.Context) {
+	typeID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid type ID"})
+		return
+	}
+
+	protocolType, err := h.Repo.GetByID(typeID)
+	if err != nil {
+		c.JSON(statusForError(err), gin.H{"error": "Protocol type not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"type_id":            protocolType.TypeID,
+		"allowed_mime_types": models.ParseMimeList(protocolType.AllowedMimeTypes),
+		"denied_mime_types":  models.ParseMimeList(protocolType.DeniedMimeTypes),
+		"effective":          h.Policy.ForType(protocolType),
+	})
+}
+
+func (h *ProtocolTypeHandler) UpdateAttachmentPolicy(c *gin.Context) {
+	typeID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid type ID"})
+		return
+	}
+
+	var req attachmentPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	allowed, msg := mimeList(req.AllowedMimeTypes)
+	if msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+	denied, msg := mimeList(req.DeniedMimeTypes)
+	if msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	if err := h.Repo.UpdateAttachmentPolicy(typeID, allowed, denied); err != nil {
+		c.JSON(
+			statusForError(err),
+			gin.H{"error": "Failed to update attachment policy: " + err.Error()},
+		)
+		return
+	}
+
+	h.GetAttachmentPolicy(c)
+}
+
+// mimeList validates and joins the list into the column format
+func mimeList(types []string) (string, string) {
+	var cleaned []string
+	for _, t := range types {
+		t = strings.ToLower(strings.TrimSpace(t))
+		if !models.IsValidMimePattern(t) {
+			return "", "Invalid MIME type: " + t
+		}
+		cleaned = append(cleaned, t)
+	}
+	return strings.Join(cleaned, ","), ""
+}
@@ -0,0 +1,87 @@
+// backend/handlers/search_handler.go
+package handlers
+
+import (
+	"ProtocolManager/backend/middleware"
+	"ProtocolManager/backend/repository"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+)
+
+type SearchHandler struct {
+	Repo *repository.SearchRepository
+}
+
+func NewSearchHandler(repo *repository.SearchRepository) *SearchHandler {
+	return &SearchHandler{Repo: repo}
+}
+
+// Search handles GET /api/search?q=...&types=protocol,customer,history&limit=20
+func (h *SearchHandler) Search(c *gin.Context) {
+	term := strings.TrimSpace(c.Query("q"))
+	if term == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Parâmetro q é obrigatório"})
+		return
+	}
+
+	limit := defaultSearchLimit
+	if raw := c.Query("limit"); raw != "" {
+		value, err := strconv.Atoi(raw)
+		if err != nil || value < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+			return
+		}
+		limit = value
+		if limit > maxSearchLimit {
+			limit = maxSearchLimit
+		}
+	}
+
+	types := []string{
+		repository.SearchTypeProtocol,
+		repository.SearchTypeCustomer,
+		repository.SearchTypeHistory,
+	}
+	if raw := c.Query("types"); raw != "" {
+		types = strings.Split(raw, ",")
+	}
+
+	// Protocolos e histórico exigem leitura de protocolos; clientes, de clientes
+	user, _ := middleware.CurrentUser(c)
+	allowed := []string{}
+	for _, t := range types {
+		t = strings.TrimSpace(t)
+
+		var perm middleware.Permission
+		switch t {
+		case repository.SearchTypeProtocol, repository.SearchTypeHistory:
+			perm = middleware.PermProtocolsRead
+		case repository.SearchTypeCustomer:
+			perm = middleware.PermCustomersRead
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid type: " + t})
+			return
+		}
+
+		if middleware.HasPermission(user.Role, perm) {
+			allowed = append(allowed, t)
+		}
+	}
+
+	results, err := h.Repo.Search(
+		middleware.CurrentScope(c), term, allowed, limit,
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"query": term, "results": results})
+}
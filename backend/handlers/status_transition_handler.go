@@ -0,0 +1,134 @@
+// backend/handlers/status_transition_handler.go
+package handlers
+
+import (
+	"ProtocolManager/backend/models"
+	"ProtocolManager/backend/repository"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type StatusTransitionHandler struct {
+	Repo *repository.StatusTransitionRepository
+}
+
+func NewStatusTransitionHandler(repo *repository.StatusTransitionRepository) *StatusTransitionHandler {
+	return &StatusTransitionHandler{Repo: repo}
+}
+
+// GetTransitionsByTypeID lists the workflow graph of a protocol type
+func (h *StatusTransitionHandler) GetTransitionsByTypeID(c *gin.Context) {
+	typeID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid type ID"})
+		return
+	}
+
+	transitions, err := h.Repo.GetByTypeID(typeID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, transitions)
+}
+
+func (h *StatusTransitionHandler) CreateTransition(c *gin.Context) {
+	typeID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid type ID"})
+		return
+	}
+
+	var transition models.StatusTransition
+	if err := c.ShouldBindJSON(&transition); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	transition.TypeID = typeID
+
+	if msg := validateTransition(transition); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	created, err := h.Repo.Create(transition)
+	if err != nil {
+		c.JSON(
+			statusForError(err),
+			gin.H{"error": "Failed to create transition: " + err.Error()},
+		)
+		return
+	}
+	c.JSON(http.StatusCreated, created)
+}
+
+func (h *StatusTransitionHandler) UpdateTransition(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	var transition models.StatusTransition
+	if err := c.ShouldBindJSON(&transition); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if msg := validateTransition(transition); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	if err := h.Repo.Update(id, transition); err != nil {
+		c.JSON(
+			statusForError(err),
+			gin.H{"error": "Failed to update transition: " + err.Error()},
+		)
+		return
+	}
+
+	updated, err := h.Repo.GetByID(id)
+	if err != nil {
+		c.JSON(
+			http.StatusInternalServerError,
+			gin.H{"error": "Transition updated but failed to retrieve"},
+		)
+		return
+	}
+	c.JSON(http.StatusOK, updated)
+}
+
+func (h *StatusTransitionHandler) DeleteTransition(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	if err := h.Repo.Delete(id); err != nil {
+		c.JSON(
+			statusForError(err),
+			gin.H{"error": "Failed to delete transition: " + err.Error()},
+		)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Transition deleted successfully"})
+}
+
+func validateTransition(t models.StatusTransition) string {
+	if t.FromStatusID == 0 || t.ToStatusID == 0 {
+		return "from_status_id and to_status_id are required"
+	}
+	if t.FromStatusID == t.ToStatusID {
+		return "from_status_id and to_status_id must differ"
+	}
+	for _, role := range t.Roles() {
+		if !models.IsValidRole(role) {
+			return "Invalid role: " + role
+		}
+	}
+	return ""
+}
@@ -0,0 +1,214 @@
+// backend/handlers/stream_handler.go
+package handlers
+
+import (
+	"ProtocolManager/backend/middleware"
+	"ProtocolManager/backend/models"
+	"ProtocolManager/backend/realtime"
+	"ProtocolManager/backend/repository"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	streamReplayBatch = 500
+	streamHeartbeat   = 25 * time.Second
+)
+
+type StreamHandler struct {
+	Hub  *realtime.Hub
+	Repo *repository.OutboxRepository
+	// How far back a client can resume with Last-Event-ID; the outbox
+	// retention is validated to be at least as long
+	ReplayWindow time.Duration
+}
+
+func NewStreamHandler(
+	hub *realtime.Hub, repo *repository.OutboxRepository,
+	replayWindow time.Duration,
+) *StreamHandler {
+	return &StreamHandler{Hub: hub, Repo: repo, ReplayWindow: replayWindow}
+}
+
+// StreamProtocolEvents pushes protocol events as Server-Sent Events.
+// Query parameters: protocol_id (a single protocol), status_id, type_id,
+// assigned_to, branch_id and priority (a filtered list) and events (comma
+// separated; defaults to realtime.DefaultEvents: protocol created, updated
+// and status_changed, and attachment added, scanned, version_added and
+// thumbnail_ready). The SSE id is the outbox event id: reconnecting with a
+// Last-Event-ID header (or last_event_id parameter) replays what was
+// missed. When that event is older than ReplayWindow or no longer in the
+// outbox, a "reset" event is sent instead: the client must reload its data,
+// and the stream continues from now. Only events of branches visible to the
+// caller are sent.
+func (h *StreamHandler) StreamProtocolEvents(c *gin.Context) {
+	filter, err := parseStreamFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	lastID, err := lastEventID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+	sub, err := h.Hub.Subscribe(ctx)
+	if err != nil {
+		return
+	}
+	defer h.Hub.Unsubscribe(sub)
+
+	// Sem Last-Event-ID o cliente começa a partir de agora
+	last, reset := sub.Start, false
+	if lastID >= 0 {
+		var found bool
+		last, found, err = h.Repo.PositionOf(
+			lastID, time.Now().Add(-h.ReplayWindow),
+		)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if !found {
+			// Eventos perdidos podem já ter saído do outbox
+			last, reset = sub.Start, true
+		}
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	if reset {
+		writeReset(c.Writer, sub.Start)
+	}
+
+	// Eventos perdidos até o início da assinatura vêm do banco; os
+	// seguintes chegam pelo hub
+replay:
+	for last.Before(sub.Start) {
+		events, err := h.Repo.EventsAfter(h.Repo.DB, last, streamReplayBatch)
+		if err != nil || len(events) == 0 {
+			break
+		}
+		for _, event := range events {
+			if sub.Start.Before(event.Position()) {
+				break replay
+			}
+			if filter.Match(event) {
+				writeSSE(c.Writer, event)
+			}
+			last = event.Position()
+		}
+	}
+	if last.Before(sub.Start) {
+		last = sub.Start
+	}
+	fmt.Fprint(c.Writer, ": connected\n\n")
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-heartbeat.C:
+			fmt.Fprint(c.Writer, ": ping\n\n")
+			c.Writer.Flush()
+		case event, ok := <-sub.C:
+			if !ok {
+				// Cliente lento ou servidor encerrando: o navegador reconecta
+				// com o Last-Event-ID
+				return
+			}
+			if !last.Before(event.Position()) {
+				continue
+			}
+			last = event.Position()
+			if filter.Match(event) {
+				writeSSE(c.Writer, event)
+				c.Writer.Flush()
+			}
+		}
+	}
+}
+
+func writeSSE(w io.Writer, event models.OutboxEvent) {
+	fmt.Fprintf(w, "id: %d\n", event.EventID)
+	fmt.Fprintf(w, "event: %s\n", event.EventType)
+	// O payload é JSON compacto, sem quebras de linha
+	fmt.Fprintf(w, "data: %s\n\n", event.Payload)
+}
+
+// writeReset tells the client to reload its data. The id moves the
+// client's Last-Event-ID to start, or clears it when the outbox is empty.
+func writeReset(w io.Writer, start models.OutboxPosition) {
+	id := ""
+	if start.EventID > 0 {
+		id = strconv.FormatInt(start.EventID, 10)
+	}
+	fmt.Fprintf(w, "id: %s\nevent: reset\ndata: {}\n\n", id)
+}
+
+// lastEventID returns -1 when the client is not resuming
+func lastEventID(c *gin.Context) (int64, error) {
+	raw := c.GetHeader("Last-Event-ID")
+	if raw == "" {
+		raw = c.Query("last_event_id")
+	}
+	if raw == "" {
+		return -1, nil
+	}
+	id, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || id < 0 {
+		return 0, fmt.Errorf("invalid Last-Event-ID: %s", raw)
+	}
+	return id, nil
+}
+
+func parseStreamFilter(c *gin.Context) (realtime.Filter, error) {
+	filter := realtime.Filter{
+		Scope:    middleware.CurrentScope(c),
+		Events:   make(map[string]bool),
+		Priority: c.Query("priority"),
+	}
+
+	intFilters := map[string]**int{
+		"protocol_id": &filter.ProtocolID,
+		"status_id":   &filter.StatusID,
+		"type_id":     &filter.TypeID,
+		"assigned_to": &filter.AssignedTo,
+		"branch_id":   &filter.BranchID,
+	}
+	for name, target := range intFilters {
+		var err error
+		if *target, err = intFilter(c, name); err != nil {
+			return filter, err
+		}
+	}
+
+	events := realtime.DefaultEvents
+	if raw := c.Query("events"); raw != "" {
+		events = strings.Split(raw, ",")
+	}
+	for _, event := range events {
+		event = strings.TrimSpace(event)
+		if !realtime.StreamableEvents[event] {
+			return filter, fmt.Errorf("invalid event: %s", event)
+		}
+		filter.Events[event] = true
+	}
+	return filter, nil
+}
@@ -0,0 +1,117 @@
+// backend/handlers/upload.go
+package handlers
+
+import (
+	"ProtocolManager/backend/models"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Folga para os cabeçalhos do multipart além do próprio arquivo
+const multipartOverhead = 1 << 20
+
+// Códigos das recusas de upload, para o cliente distinguir os casos
+const (
+	uploadFileTooLarge    = "file_too_large"
+	uploadQuotaExceeded   = "quota_exceeded"
+	uploadUnsupportedType = "unsupported_media_type"
+)
+
+var errUploadTooLarge = errors.New("upload excede o limite")
+
+// uploadLimit é o máximo que um upload pode gravar: o menor entre o tamanho
+// máximo do arquivo e o que resta da cota do protocolo
+type uploadLimit struct {
+	Policy models.AttachmentPolicy
+	Used   int64
+}
+
+// Bytes devolve o limite em bytes e se ele vem da cota; -1 não limita
+func (l uploadLimit) Bytes() (int64, bool) {
+	limit, byQuota := int64(-1), false
+	if l.Policy.MaxFileSize > 0 {
+		limit = l.Policy.MaxFileSize
+	}
+	if l.Policy.ProtocolQuota > 0 {
+		remaining := max(l.Policy.ProtocolQuota-l.Used, 0)
+		if limit < 0 || remaining < limit {
+			limit, byQuota = remaining, true
+		}
+	}
+	return limit, byQuota
+}
+
+// abortTooLarge responde 413 com o limite que foi atingido
+func (l uploadLimit) abortTooLarge(c *gin.Context) {
+	if _, byQuota := l.Bytes(); byQuota {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+			"error": "Protocol attachment quota exceeded",
+			"code":  uploadQuotaExceeded,
+			"quota": l.Policy.ProtocolQuota,
+			"used":  l.Used,
+		})
+		return
+	}
+	c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+		"error": fmt.Sprintf(
+			"File exceeds the maximum size of %d bytes", l.Policy.MaxFileSize,
+		),
+		"code":     uploadFileTooLarge,
+		"max_size": l.Policy.MaxFileSize,
+	})
+}
+
+func abortUnsupportedType(
+	c *gin.Context, policy models.AttachmentPolicy, contentType string,
+) {
+	c.JSON(http.StatusUnsupportedMediaType, gin.H{
+		"error":        "File type not allowed: " + contentType,
+		"code":         uploadUnsupportedType,
+		"content_type": contentType,
+		"allowed":      policy.Allowed,
+		"denied":       policy.Denied,
+	})
+}
+
+// limitedReader conta os bytes lidos e falha com errUploadTooLarge ao
+// passar do limite, em vez de truncar o arquivo como io.LimitReader
+type limitedReader struct {
+	R     io.Reader
+	Limit int64 // -1 não limita
+	N     int64
+}
+
+func (r *limitedReader) Read(p []byte) (int, error) {
+	n, err := r.R.Read(p)
+	r.N += int64(n)
+	if r.Limit >= 0 && r.N > r.Limit {
+		return n, errUploadTooLarge
+	}
+	return n, err
+}
+
+// nextFilePart avança o multipart até o campo indicado, descartando os
+// demais, sem carregar o arquivo em memória como FormFile faz
+func nextFilePart(reader *multipart.Reader, field string) (*multipart.Part, error) {
+	for {
+		part, err := reader.NextPart()
+		if err != nil {
+			return nil, err
+		}
+		if part.FormName() == field && part.FileName() != "" {
+			return part, nil
+		}
+		part.Close()
+	}
+}
+
+// isTooLarge reconhece tanto o limite do arquivo quanto o do corpo inteiro
+func isTooLarge(err error) bool {
+	var maxBytes *http.MaxBytesError
+	return errors.Is(err, errUploadTooLarge) || errors.As(err, &maxBytes)
+}
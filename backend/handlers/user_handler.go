@@ -0,0 +1,95 @@
+// backend/handlers/user_handler.go
+package handlers
+
+import (
+	"ProtocolManager/backend/middleware"
+	"ProtocolManager/backend/models"
+	"ProtocolManager/backend/repository"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type UserHandler struct {
+	Repo *repository.UserRepository
+}
+
+func NewUserHandler(repo *repository.UserRepository) *UserHandler {
+	return &UserHandler{Repo: repo}
+}
+
+func (h *UserHandler) GetAllUsers(c *gin.Context) {
+	users, err := h.Repo.GetAll()
+	if err != nil {
+		c.JSON(
+			http.StatusInternalServerError,
+			gin.H{"error": "Failed to retrieve users"},
+		)
+		return
+	}
+	c.JSON(http.StatusOK, users)
+}
+
+// UpdateUser lets an administrator set a user's role, link to personnel
+// and active flag. Only the fields present in the body change; sending
+// "personnel_id": null unlinks the personnel record.
+func (h *UserHandler) UpdateUser(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var input struct {
+		Role        *string         `json:"role"`
+		Active      *bool           `json:"active"`
+		PersonnelID json.RawMessage `json:"personnel_id"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	changes := repository.UserChanges{Role: input.Role, Active: input.Active}
+	if input.Role != nil && !models.IsValidRole(*input.Role) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid role"})
+		return
+	}
+	if len(input.PersonnelID) > 0 {
+		changes.SetPersonnel = true
+		if err := json.Unmarshal(input.PersonnelID, &changes.PersonnelID); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid personnel ID"})
+			return
+		}
+	}
+
+	// Um administrador não rebaixa nem desativa a própria conta
+	if current, ok := middleware.CurrentUser(c); ok && current.UserID == id {
+		demoted := input.Role != nil && *input.Role != models.RoleAdmin
+		deactivated := input.Active != nil && !*input.Active
+		if demoted || deactivated {
+			c.JSON(
+				http.StatusForbidden,
+				gin.H{"error": "You cannot remove your own admin access"},
+			)
+			return
+		}
+	}
+
+	if err := h.Repo.Update(id, changes); err != nil {
+		c.JSON(statusForError(err), gin.H{"error": "Failed to update user: " + err.Error()})
+		return
+	}
+
+	updated, err := h.Repo.GetByID(id)
+	if err != nil {
+		c.JSON(
+			http.StatusInternalServerError,
+			gin.H{"error": "User updated but failed to retrieve"},
+		)
+		return
+	}
+	c.JSON(http.StatusOK, updated)
+}
@@ -0,0 +1,40 @@
+// backend/handlers/user_handler_test.go
+package handlers
+
+import (
+	"ProtocolManager/backend/middleware"
+	"ProtocolManager/backend/models"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestUpdateUserRefusesSelfDemotion(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	admin := models.User{UserID: 7, Role: models.RoleAdmin, Active: true}
+
+	// O repositório não é usado: a recusa acontece antes da gravação
+	r := gin.New()
+	r.PUT("/api/users/:id", func(c *gin.Context) {
+		c.Set(middleware.ContextUserKey, admin)
+	}, NewUserHandler(nil).UpdateUser)
+
+	for _, body := range []string{
+		`{"role":"agent"}`,
+		`{"active":false}`,
+		`{"role":"admin","active":false}`,
+	} {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(
+			http.MethodPut, "/api/users/7", strings.NewReader(body),
+		)
+		req.Header.Set("Content-Type", "application/json")
+		r.ServeHTTP(w, req)
+		if w.Code != http.StatusForbidden {
+			t.Errorf("%s: status %d, want 403", body, w.Code)
+		}
+	}
+}
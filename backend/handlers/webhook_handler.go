@@ -0,0 +1,254 @@
+// backend/handlers/webhook_handler.go
+package handlers
+
+import (
+	"ProtocolManager/backend/models"
+	"ProtocolManager/backend/repository"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultDeliveryLimit = 50
+	maxDeliveryLimit     = 500
+)
+
+type WebhookHandler struct {
+	Repo *repository.WebhookRepository
+}
+
+func NewWebhookHandler(repo *repository.WebhookRepository) *WebhookHandler {
+	return &WebhookHandler{Repo: repo}
+}
+
+// webhookRequest é o corpo aceito na criação e na alteração. O segredo não
+// faz parte do JSON do modelo para nunca ser devolvido nas listagens.
+type webhookRequest struct {
+	URL    string   `json:"url"`
+	Secret string   `json:"secret"`
+	Events []string `json:"events"`
+	Active *bool    `json:"active"`
+}
+
+func (r webhookRequest) subscription() models.WebhookSubscription {
+	active := true
+	if r.Active != nil {
+		active = *r.Active
+	}
+	return models.WebhookSubscription{
+		URL:    strings.TrimSpace(r.URL),
+		Secret: r.Secret,
+		Events: strings.Join(r.Events, ","),
+		Active: active,
+	}
+}
+
+func (h *WebhookHandler) GetAllWebhooks(c *gin.Context) {
+	subscriptions, err := h.Repo.GetAll()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, subscriptions)
+}
+
+func (h *WebhookHandler) GetWebhookByID(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	subscription, err := h.Repo.GetByID(id)
+	if err != nil {
+		c.JSON(statusForError(err), gin.H{"error": "Webhook not found"})
+		return
+	}
+	c.JSON(http.StatusOK, subscription)
+}
+
+// CreateWebhook cadastra a assinatura. Sem segredo informado, um é gerado;
+// em ambos os casos ele só é devolvido nesta resposta.
+func (h *WebhookHandler) CreateWebhook(c *gin.Context) {
+	var req webhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	subscription := req.subscription()
+	if subscription.Secret == "" {
+		secret, err := newWebhookSecret()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		subscription.Secret = secret
+	}
+
+	if msg := validateWebhook(subscription); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	created, err := h.Repo.Create(subscription)
+	if err != nil {
+		c.JSON(
+			http.StatusInternalServerError,
+			gin.H{"error": "Failed to create webhook: " + err.Error()},
+		)
+		return
+	}
+	c.JSON(http.StatusCreated, struct {
+		models.WebhookSubscription
+		Secret string `json:"secret"`
+	}{created, created.Secret})
+}
+
+func (h *WebhookHandler) UpdateWebhook(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	var req webhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	subscription := req.subscription()
+	if msg := validateWebhook(subscription); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	if err := h.Repo.Update(id, subscription); err != nil {
+		c.JSON(
+			statusForError(err),
+			gin.H{"error": "Failed to update webhook: " + err.Error()},
+		)
+		return
+	}
+
+	updated, err := h.Repo.GetByID(id)
+	if err != nil {
+		c.JSON(
+			http.StatusInternalServerError,
+			gin.H{"error": "Webhook updated but failed to retrieve"},
+		)
+		return
+	}
+	c.JSON(http.StatusOK, updated)
+}
+
+func (h *WebhookHandler) DeleteWebhook(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	if err := h.Repo.Delete(id); err != nil {
+		c.JSON(
+			statusForError(err),
+			gin.H{"error": "Failed to delete webhook: " + err.Error()},
+		)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Webhook deleted successfully"})
+}
+
+// GetDeliveries lista o log de entregas da assinatura. Parâmetros: status
+// (pending|delivered|dead) e limit.
+func (h *WebhookHandler) GetDeliveries(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	status := c.Query("status")
+	switch status {
+	case "", models.DeliveryPending, models.DeliveryDelivered,
+		models.DeliveryDead:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status"})
+		return
+	}
+
+	limit := defaultDeliveryLimit
+	if raw := c.Query("limit"); raw != "" {
+		limit, err = strconv.Atoi(raw)
+		if err != nil || limit < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+			return
+		}
+		if limit > maxDeliveryLimit {
+			limit = maxDeliveryLimit
+		}
+	}
+
+	if _, err := h.Repo.GetByID(id); err != nil {
+		c.JSON(statusForError(err), gin.H{"error": "Webhook not found"})
+		return
+	}
+
+	deliveries, err := h.Repo.GetDeliveries(id, status, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, deliveries)
+}
+
+// RedeliverDelivery recoloca uma entrega (normalmente dead) na fila
+func (h *WebhookHandler) RedeliverDelivery(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	deliveryID, err := strconv.Atoi(c.Param("deliveryId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid delivery ID"})
+		return
+	}
+
+	if err := h.Repo.Redeliver(id, deliveryID); err != nil {
+		c.JSON(
+			statusForError(err),
+			gin.H{"error": "Failed to requeue delivery: " + err.Error()},
+		)
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"message": "Delivery requeued"})
+}
+
+func validateWebhook(s models.WebhookSubscription) string {
+	u, err := url.Parse(s.URL)
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return "url must be an absolute http or https URL"
+	}
+	for _, event := range s.EventList() {
+		if !models.IsValidEvent(event) {
+			return "Invalid event: " + event
+		}
+	}
+	return ""
+}
+
+func newWebhookSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -4,37 +4,82 @@ package main
 import (
 	"ProtocolManager/backend/config"
 	"ProtocolManager/backend/models"
+	"context"
 	"database/sql"
 	"log"
 	"os"
+	"slices"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 
+	"ProtocolManager/backend/attachment"
 	"ProtocolManager/backend/handlers"
+	"ProtocolManager/backend/middleware"
+	"ProtocolManager/backend/notify"
+	"ProtocolManager/backend/preview"
+	"ProtocolManager/backend/realtime"
 	"ProtocolManager/backend/repository"
+	"ProtocolManager/backend/scan"
+	"ProtocolManager/backend/scheduler"
+	"ProtocolManager/backend/storage"
+	"ProtocolManager/backend/webhook"
 )
 
 func main() {
-	cfg := config.NewConfig()
-	// Connection string
-	dsn := "host=localhost user=postgres password=123 dbname=protocol port=5432 sslmode=disable"
+	// Ambiente e, opcionalmente, o arquivo em CONFIG_FILE
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Invalid configuration:\n%v", err)
+	}
+	dsn := cfg.DatabaseDSN
 
-	// Connect using standard SQL for BranchRepository
-	sqlDB, err := sql.Open("postgres", dsn)
+	// Connect using standard SQL for BranchRepository. Avisos do PostgreSQL
+	// (RAISE WARNING das migrações, por exemplo) vão para o log.
+	connector, err := pq.NewConnector(dsn)
 	if err != nil {
 		log.Fatal("Error connecting to database (SQL): ", err)
 	}
+	sqlDB := sql.OpenDB(pq.ConnectorWithNoticeHandler(
+		connector, func(notice *pq.Error) {
+			log.Printf("Database %s: %s", notice.Severity, notice.Message)
+		},
+	))
 	defer sqlDB.Close()
+	configurePool(sqlDB, cfg)
+
+	// "migrate up|down [N]|status" só mexe no schema e encerra
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(sqlDB, os.Args[2:]); err != nil {
+			log.Fatal("Migration failed: ", err)
+		}
+		return
+	}
+	migrateSchema(sqlDB, cfg.DBMigrateOnStart)
 
-	// Connect using GORM for PersonnelRepository
-	gormDB, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	// Connect using GORM for PersonnelRepository. TranslateError turns
+	// constraint violations into gorm.ErrForeignKeyViolated and
+	// gorm.ErrDuplicatedKey.
+	gormDB, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+		TranslateError: true,
+	})
 	if err != nil {
 		log.Fatal("Error connecting to database (GORM): ", err)
 	}
+	if pool, err := gormDB.DB(); err == nil {
+		configurePool(pool, cfg)
+	}
+
+	// "user promote <email>" cria o primeiro administrador e encerra
+	if len(os.Args) > 1 && os.Args[1] == "user" {
+		if err := runUser(repository.NewUserRepository(gormDB), os.Args[2:]); err != nil {
+			log.Fatal("User command failed: ", err)
+		}
+		return
+	}
 
 	// Initialize repositories with appropriate DB connections
 	branchRepo := repository.NewBranchRepository(sqlDB)
@@ -48,6 +93,25 @@ func main() {
 	customerRepo := repository.NewCustomerRepository(gormDB)
 	customerHandler := handlers.NewCustomerHandler(customerRepo)
 
+	// Attachment storage (local disk or S3-compatible)
+	files, err := storage.New(context.Background(), storage.Config{
+		Driver:    cfg.StorageDriver,
+		LocalPath: cfg.FileStoragePath,
+		S3: storage.S3Config{
+			Endpoint:     cfg.S3Endpoint,
+			Region:       cfg.S3Region,
+			Bucket:       cfg.S3Bucket,
+			AccessKey:    cfg.S3AccessKey,
+			SecretKey:    cfg.S3SecretKey,
+			UseSSL:       cfg.S3UseSSL,
+			PathStyle:    cfg.S3PathStyle,
+			CreateBucket: cfg.S3CreateBucket,
+		},
+	})
+	if err != nil {
+		log.Fatal("Error initializing attachment storage: ", err)
+	}
+
 	// Add after your other repository initializations
 	protocolHistoryRepo := repository.NewProtocolHistoryRepository(gormDB)
 	protocolAttachmentRepo := repository.NewProtocolAttachmentRepository(gormDB)
@@ -55,109 +119,377 @@ func main() {
 
 	// Add after your other handler initializations
 	protocolHistoryHandler := handlers.NewProtocolHistoryHandler(protocolHistoryRepo)
-	protocolAttachmentHandler := handlers.NewProtocolAttachmentHandler(protocolAttachmentRepo)
+	attachmentPolicy := models.AttachmentPolicy{
+		MaxFileSize:   cfg.AttachmentMaxSize,
+		ProtocolQuota: cfg.AttachmentProtocolQuota,
+		Allowed:       models.ParseMimeList(cfg.AttachmentAllowedTypes),
+		Denied:        models.ParseMimeList(cfg.AttachmentDeniedTypes),
+	}
+	// Upload, download, remoção e integridade dos anexos passam pelo serviço
+	attachments := attachment.NewService(
+		protocolAttachmentRepo, files, attachmentPolicy,
+	)
+	protocolAttachmentHandler := handlers.NewProtocolAttachmentHandler(attachments)
 	protocolReminderHandler := handlers.NewProtocolReminderHandler(protocolReminderRepo)
 
 	// Initialize Protocol repository
-	protocolRepo := repository.NewProtocolRepository(gormDB)
-	protocolHandler := handlers.NewProtocolHandler(protocolRepo)
+	protocolNumbers, err := repository.NewProtocolNumberGenerator(
+		cfg.ProtocolNumberFormat,
+	)
+	if err != nil {
+		log.Fatal("Invalid PROTOCOL_NUMBER_FORMAT: ", err)
+	}
+	protocolDeadlines := repository.NewDeadlineCalculator(cfg.SLABusinessDays)
+	protocolRepo := repository.NewProtocolRepository(
+		gormDB, protocolNumbers, protocolDeadlines, cfg.SLAAtRiskWindow,
+	)
+
+	// Notificações por e-mail com SMTP_HOST definido; sem ele, só no log.
+	// Os lembretes usam um único canal: uma nova tentativa repete o envio
+	// em todos os canais, inclusive nos que já tinham entregado.
+	var protocolNotifier notify.ProtocolNotifier = notify.LogNotifier{}
+	var reminderNotifier notify.ReminderNotifier = notify.LogNotifier{}
+	if cfg.SMTPHost != "" {
+		emailNotifier, err := notify.NewEmailNotifier(gormDB, notify.SMTPConfig{
+			Host:     cfg.SMTPHost,
+			Port:     cfg.SMTPPort,
+			Username: cfg.SMTPUsername,
+			Password: cfg.SMTPPassword,
+			From:     cfg.SMTPFrom,
+			Timeout:  cfg.SMTPTimeout,
+			Locale:   cfg.EmailLocale,
+		})
+		if err != nil {
+			log.Fatal("Invalid SMTP configuration: ", err)
+		}
+		protocolNotifier = emailNotifier
+		reminderNotifier = emailNotifier
+	}
+
+	protocolHandler := handlers.NewProtocolHandler(protocolRepo, attachments)
 
 	protocolStatusRepo := repository.NewProtocolStatusRepository(gormDB)
 	protocolStatusHandler := handlers.NewProtocolStatusHandler(protocolStatusRepo)
 
 	// Initialize Gin router
-	r := gin.Default()
-
-	// Setup CORS
-	config := cors.DefaultConfig()
-	config.AllowAllOrigins = true
-	config.AllowMethods = []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}
-	config.AllowHeaders = []string{"Content-Type", "Authorization"}
-	r.Use(cors.New(config))
-
-	// API routes
-	r.GET("/api/branches", branchHandler.GetAllBranches)
-	r.GET("/api/branches/:id", branchHandler.GetBranchByID)
-	r.POST("/api/branches", branchHandler.CreateBranch)
-	r.PUT("/api/branches/:id", branchHandler.UpdateBranch)
-	r.DELETE("/api/branches/:id", branchHandler.DeleteBranch)
+	if cfg.IsProduction() {
+		gin.SetMode(gin.ReleaseMode)
+	}
+	r := gin.New()
+	if cfg.LogRequests {
+		r.Use(middleware.RequestLogger(cfg.LogFormat))
+	}
+	r.Use(gin.Recovery())
+
+	// Setup CORS: "*" em CORS_ALLOWED_ORIGINS libera qualquer origem
+	corsConfig := cors.DefaultConfig()
+	if slices.Contains(cfg.CORSAllowedOrigins, "*") {
+		corsConfig.AllowAllOrigins = true
+	} else {
+		corsConfig.AllowOrigins = cfg.CORSAllowedOrigins
+	}
+	corsConfig.AllowMethods = []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}
+	corsConfig.AllowHeaders = []string{"Content-Type", "Authorization", "Last-Event-ID"}
+	// O frontend baixa os arquivos via XHR e lê o nome no cabeçalho
+	corsConfig.ExposeHeaders = []string{"Content-Disposition"}
+	r.Use(cors.New(corsConfig))
+
+	userRepo := repository.NewUserRepository(gormDB)
+	authHandler := handlers.NewAuthHandler(userRepo, cfg.JWTSecret, cfg.JWTTTL)
+	healthHandler := handlers.NewHealthHandler(sqlDB)
+
+	// Public routes
+	r.GET("/api/health", healthHandler.Health)
+	r.POST("/api/login", authHandler.Login)
+	r.POST("/api/register", authHandler.Register)
+
+	// Every other /api route requires a valid token
+	api := r.Group("/api")
+	api.Use(middleware.RequireAuth(userRepo, cfg.JWTSecret))
+
+	// API routes, each guarded by the permission matrix in middleware/rbac.go
+	can := middleware.RequirePermission
+
+	api.GET(
+		"/branches", can(middleware.PermBranchesRead),
+		branchHandler.GetAllBranches,
+	)
+	api.GET(
+		"/branches/:id", can(middleware.PermBranchesRead),
+		branchHandler.GetBranchByID,
+	)
+	api.POST(
+		"/branches", can(middleware.PermBranchesWrite),
+		branchHandler.CreateBranch,
+	)
+	api.PUT(
+		"/branches/:id", can(middleware.PermBranchesWrite),
+		branchHandler.UpdateBranch,
+	)
+	api.DELETE(
+		"/branches/:id", can(middleware.PermBranchesDelete),
+		branchHandler.DeleteBranch,
+	)
 
 	// Personnel routes
-	r.GET("/api/personnel", personnelHandler.GetAllPersonnel)
-	r.GET("/api/personnel/:id", personnelHandler.GetPersonnelByID)
-	r.POST("/api/personnel", personnelHandler.CreatePersonnel)
-	r.PUT("/api/personnel/:id", personnelHandler.UpdatePersonnel)
-	r.DELETE("/api/personnel/:id", personnelHandler.DeletePersonnel)
-
-	r.GET("/api/customers", customerHandler.GetAllCustomers)
-	r.GET("/api/customers/:id", customerHandler.GetCustomerByID)
-	r.POST("/api/customers", customerHandler.CreateCustomer)
-	r.PUT("/api/customers/:id", customerHandler.UpdateCustomer)
-	r.DELETE("/api/customers/:id", customerHandler.DeleteCustomer)
-
-	r.GET("/api/protocol-history", protocolHistoryHandler.GetAllHistory)
-	r.GET("/api/protocol-history/:id", protocolHistoryHandler.GetHistoryByID)
-	r.GET(
-		"/api/protocols/:id/history",
+	api.GET(
+		"/personnel", can(middleware.PermPersonnelRead),
+		personnelHandler.GetAllPersonnel,
+	)
+	api.GET(
+		"/personnel/:id", can(middleware.PermPersonnelRead),
+		personnelHandler.GetPersonnelByID,
+	)
+	api.POST(
+		"/personnel", can(middleware.PermPersonnelWrite),
+		personnelHandler.CreatePersonnel,
+	)
+	api.PUT(
+		"/personnel/:id", can(middleware.PermPersonnelWrite),
+		personnelHandler.UpdatePersonnel,
+	)
+	api.DELETE(
+		"/personnel/:id", can(middleware.PermPersonnelDelete),
+		personnelHandler.DeletePersonnel,
+	)
+
+	api.GET(
+		"/customers", can(middleware.PermCustomersRead),
+		customerHandler.GetAllCustomers,
+	)
+	api.GET(
+		"/customers/:id", can(middleware.PermCustomersRead),
+		customerHandler.GetCustomerByID,
+	)
+	api.POST(
+		"/customers", can(middleware.PermCustomersWrite),
+		customerHandler.CreateCustomer,
+	)
+	api.PUT(
+		"/customers/:id", can(middleware.PermCustomersWrite),
+		customerHandler.UpdateCustomer,
+	)
+	api.DELETE(
+		"/customers/:id", can(middleware.PermCustomersDelete),
+		customerHandler.DeleteCustomer,
+	)
+
+	api.GET(
+		"/protocol-history", can(middleware.PermProtocolsRead),
+		protocolHistoryHandler.GetAllHistory,
+	)
+	api.GET(
+		"/protocol-history/:id", can(middleware.PermProtocolsRead),
+		protocolHistoryHandler.GetHistoryByID,
+	)
+	api.GET(
+		"/protocols/:id/history", can(middleware.PermProtocolsRead),
 		protocolHistoryHandler.GetHistoryByProtocolID,
 	)
-	r.POST("/api/protocol-history", protocolHistoryHandler.CreateHistory)
+	api.POST(
+		"/protocol-history", can(middleware.PermProtocolsWrite),
+		protocolHistoryHandler.CreateHistory,
+	)
 
 	// Protocol Attachment routes
-	r.GET(
-		"/api/protocols/:id/attachments",
+	api.GET(
+		"/protocols/:id/attachments", can(middleware.PermProtocolsRead),
 		protocolAttachmentHandler.GetAttachmentsByProtocolID,
 	)
-	r.POST(
-		"/api/protocols/:id/attachments",
+	api.POST(
+		"/protocols/:id/attachments", can(middleware.PermProtocolsWrite),
 		protocolAttachmentHandler.UploadAttachment,
 	)
-	r.DELETE("/api/attachments/:id", protocolAttachmentHandler.DeleteAttachment)
+	api.GET(
+		"/protocols/:id/attachments/archive", can(middleware.PermProtocolsRead),
+		protocolAttachmentHandler.DownloadArchive,
+	)
+	api.DELETE(
+		"/attachments/:id", can(middleware.PermProtocolsWrite),
+		protocolAttachmentHandler.DeleteAttachment,
+	)
+	api.GET(
+		"/attachments/:id/versions", can(middleware.PermProtocolsRead),
+		protocolAttachmentHandler.GetVersions,
+	)
+	api.POST(
+		"/attachments/:id/versions", can(middleware.PermProtocolsWrite),
+		protocolAttachmentHandler.UploadVersion,
+	)
 
 	// Protocol Reminder routes
-	r.GET(
-		"/api/protocols/:id/reminders",
+	api.GET(
+		"/protocols/:id/reminders", can(middleware.PermProtocolsRead),
 		protocolReminderHandler.GetRemindersByProtocolID,
 	)
-	r.GET(
-		"/api/reminders/upcoming", protocolReminderHandler.GetUpcomingReminders,
+	api.GET(
+		"/reminders/upcoming", can(middleware.PermProtocolsRead),
+		protocolReminderHandler.GetUpcomingReminders,
+	)
+	api.POST(
+		"/protocols/:id/reminders", can(middleware.PermProtocolsWrite),
+		protocolReminderHandler.CreateReminder,
 	)
-	r.POST(
-		"/api/protocols/:id/reminders", protocolReminderHandler.CreateReminder,
+	api.PUT(
+		"/reminders/:id", can(middleware.PermProtocolsWrite),
+		protocolReminderHandler.UpdateReminder,
 	)
-	r.PUT("/api/reminders/:id", protocolReminderHandler.UpdateReminder)
-	r.PUT(
-		"/api/reminders/:id/mark-sent",
+	api.PUT(
+		"/reminders/:id/mark-sent", can(middleware.PermProtocolsWrite),
 		protocolReminderHandler.MarkReminderAsSent,
 	)
-	r.DELETE("/api/reminders/:id", protocolReminderHandler.DeleteReminder)
-
-	r.GET("/api/protocols", protocolHandler.GetAllProtocols)
-	r.GET("/api/protocols/:id", protocolHandler.GetProtocolByID)
-	r.POST("/api/protocols", protocolHandler.CreateProtocol)
-	r.PUT("/api/protocols/:id", protocolHandler.UpdateProtocol)
-	r.DELETE("/api/protocols/:id", protocolHandler.DeleteProtocol)
-
-	r.GET("/api/protocol-statuses", protocolStatusHandler.GetAllStatuses)
-	r.GET("/api/protocol-statuses/:id", protocolStatusHandler.GetStatusByID)
-	r.POST("/api/protocol-statuses", protocolStatusHandler.CreateStatus)
-	r.PUT("/api/protocol-statuses/:id", protocolStatusHandler.UpdateStatus)
-	r.DELETE("/api/protocol-statuses/:id", protocolStatusHandler.DeleteStatus)
-
-	fileRepo := repository.NewFileRepository(sqlDB)
-	attachmentHandler := handlers.NewAttachmentHandler(fileRepo, cfg)
-	r.GET("/api/attachments/:id/download", attachmentHandler.DownloadAttachment)
-	// Make sure the table is auto-migrated
-	if err := gormDB.AutoMigrate(
-		&models.Protocol{},
-		&models.ProtocolHistory{},
-		&models.ProtocolAttachment{},
-		&models.ProtocolReminder{},
-		&models.ProtocolStatus{},
-		&models.ProtocolType{}, // Add this line
-	); err != nil {
-		log.Printf("Warning: Migration issue: %v", err)
-	}
+	api.DELETE(
+		"/reminders/:id", can(middleware.PermProtocolsWrite),
+		protocolReminderHandler.DeleteReminder,
+	)
+
+	api.GET(
+		"/protocols", can(middleware.PermProtocolsRead),
+		protocolHandler.GetAllProtocols,
+	)
+	api.GET(
+		"/protocols/:id", can(middleware.PermProtocolsRead),
+		protocolHandler.GetProtocolByID,
+	)
+	api.POST(
+		"/protocols", can(middleware.PermProtocolsWrite),
+		protocolHandler.CreateProtocol,
+	)
+	api.PUT(
+		"/protocols/:id", can(middleware.PermProtocolsWrite),
+		protocolHandler.UpdateProtocol,
+	)
+	api.DELETE(
+		"/protocols/:id", can(middleware.PermProtocolsDelete),
+		protocolHandler.DeleteProtocol,
+	)
+
+	api.GET(
+		"/protocol-statuses", can(middleware.PermStatusesRead),
+		protocolStatusHandler.GetAllStatuses,
+	)
+	api.GET(
+		"/protocol-statuses/:id", can(middleware.PermStatusesRead),
+		protocolStatusHandler.GetStatusByID,
+	)
+	api.POST(
+		"/protocol-statuses", can(middleware.PermStatusesWrite),
+		protocolStatusHandler.CreateStatus,
+	)
+	api.PUT(
+		"/protocol-statuses/:id", can(middleware.PermStatusesWrite),
+		protocolStatusHandler.UpdateStatus,
+	)
+	api.DELETE(
+		"/protocol-statuses/:id", can(middleware.PermStatusesDelete),
+		protocolStatusHandler.DeleteStatus,
+	)
+
+	// Status workflow routes
+	transitionRepo := repository.NewStatusTransitionRepository(gormDB)
+	transitionHandler := handlers.NewStatusTransitionHandler(transitionRepo)
+	api.GET(
+		"/protocol-types/:id/transitions", can(middleware.PermStatusesRead),
+		transitionHandler.GetTransitionsByTypeID,
+	)
+	api.POST(
+		"/protocol-types/:id/transitions", can(middleware.PermStatusesWrite),
+		transitionHandler.CreateTransition,
+	)
+	api.PUT(
+		"/status-transitions/:id", can(middleware.PermStatusesWrite),
+		transitionHandler.UpdateTransition,
+	)
+	api.DELETE(
+		"/status-transitions/:id", can(middleware.PermStatusesDelete),
+		transitionHandler.DeleteTransition,
+	)
+
+	// Tipos de anexo aceitos por tipo de protocolo
+	protocolTypeHandler := handlers.NewProtocolTypeHandler(
+		repository.NewProtocolTypeRepository(gormDB), attachmentPolicy,
+	)
+	api.GET(
+		"/protocol-types/:id/attachment-policy", can(middleware.PermStatusesRead),
+		protocolTypeHandler.GetAttachmentPolicy,
+	)
+	api.PUT(
+		"/protocol-types/:id/attachment-policy", can(middleware.PermStatusesWrite),
+		protocolTypeHandler.UpdateAttachmentPolicy,
+	)
+
+	// Holiday calendar used by business-day deadlines
+	holidayRepo := repository.NewHolidayRepository(gormDB)
+	holidayHandler := handlers.NewHolidayHandler(holidayRepo)
+	api.GET("/holidays", can(middleware.PermStatusesRead), holidayHandler.GetAllHolidays)
+	api.POST("/holidays", can(middleware.PermStatusesWrite), holidayHandler.CreateHoliday)
+	api.DELETE(
+		"/holidays/:id", can(middleware.PermStatusesDelete),
+		holidayHandler.DeleteHoliday,
+	)
+
+	searchRepo := repository.NewSearchRepository(gormDB)
+	searchHandler := handlers.NewSearchHandler(searchRepo)
+	// A busca é feita a partir da tela de protocolos; o handler ainda
+	// filtra os tipos (clientes exigem customers:read)
+	api.GET("/search", can(middleware.PermProtocolsRead), searchHandler.Search)
+
+	// User administration routes
+	userHandler := handlers.NewUserHandler(userRepo)
+	api.GET("/users", can(middleware.PermUsersManage), userHandler.GetAllUsers)
+	api.PUT(
+		"/users/:id", can(middleware.PermUsersManage),
+		userHandler.UpdateUser,
+	)
+
+	// Outbound webhooks
+	webhookRepo := repository.NewWebhookRepository(gormDB)
+	webhookHandler := handlers.NewWebhookHandler(webhookRepo)
+	manageWebhooks := can(middleware.PermWebhooksManage)
+	api.GET("/webhooks", manageWebhooks, webhookHandler.GetAllWebhooks)
+	api.POST("/webhooks", manageWebhooks, webhookHandler.CreateWebhook)
+	api.GET("/webhooks/:id", manageWebhooks, webhookHandler.GetWebhookByID)
+	api.PUT("/webhooks/:id", manageWebhooks, webhookHandler.UpdateWebhook)
+	api.DELETE("/webhooks/:id", manageWebhooks, webhookHandler.DeleteWebhook)
+	api.GET(
+		"/webhooks/:id/deliveries", manageWebhooks,
+		webhookHandler.GetDeliveries,
+	)
+	api.POST(
+		"/webhooks/:id/deliveries/:deliveryId/redeliver", manageWebhooks,
+		webhookHandler.RedeliverDelivery,
+	)
+
+	// Outbox relay monitoring
+	outboxRepo := repository.NewOutboxRepository(gormDB)
+	outboxHandler := handlers.NewOutboxHandler(outboxRepo)
+	api.GET(
+		"/outbox/metrics", can(middleware.PermSystemMonitor),
+		outboxHandler.GetMetrics,
+	)
+
+	// Real-time protocol events (Server-Sent Events)
+	realtimeHub := realtime.NewHub(outboxRepo, cfg.RealtimePollInterval)
+	streamHandler := handlers.NewStreamHandler(
+		realtimeHub, outboxRepo, cfg.RealtimeReplayWindow,
+	)
+	api.GET(
+		"/protocols/events", can(middleware.PermProtocolsRead),
+		streamHandler.StreamProtocolEvents,
+	)
+
+	api.GET(
+		"/attachments/:id/download", can(middleware.PermProtocolsRead),
+		protocolAttachmentHandler.DownloadAttachment,
+	)
+	api.GET(
+		"/attachments/:id/preview", can(middleware.PermProtocolsRead),
+		protocolAttachmentHandler.PreviewAttachment,
+	)
+	api.POST(
+		"/attachments/:id/verify", can(middleware.PermProtocolsWrite),
+		protocolAttachmentHandler.VerifyAttachment,
+	)
 	var count int64
 	if err := gormDB.Model(&models.ProtocolType{}).Count(&count).Error; err != nil {
 		log.Printf("Error checking protocol types: %v", err)
@@ -171,18 +503,91 @@ func main() {
 			log.Printf("Error creating default protocol type: %v", err)
 		}
 	}
-	userRepo := repository.NewUserRepository(gormDB)
-	authHandler := handlers.NewAuthHandler(userRepo, "sua_chave_secreta_aqui")
+	// Background jobs
+	slaMonitor := scheduler.NewSLAMonitor(protocolRepo, cfg.SLACheckInterval)
+	go slaMonitor.Run(context.Background())
 
-	r.POST("/api/login", authHandler.Login)
-	r.POST("/api/register", authHandler.Register)
+	reminderDispatcher := scheduler.NewReminderDispatcher(
+		protocolReminderRepo,
+		reminderNotifier,
+		cfg.ReminderInterval,
+		cfg.ReminderMaxAttempts,
+		cfg.ReminderBackoff,
+	)
+	go reminderDispatcher.Run(context.Background())
 
-	// Start server
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
+	webhookDispatcher := scheduler.NewWebhookDispatcher(
+		webhookRepo,
+		webhook.NewClient(cfg.WebhookTimeout),
+		cfg.WebhookInterval,
+		cfg.WebhookMaxAttempts,
+		cfg.WebhookBackoff,
+	)
+	go webhookDispatcher.Run(context.Background())
+
+	// Antivírus: anexos só podem ser baixados depois de verificados
+	var scanner scan.Scanner
+	if cfg.ClamdAddr != "" {
+		scanner = scan.NewClamd(cfg.ClamdAddr, cfg.ClamdTimeout)
+	} else if cfg.ScanFailOpen {
+		log.Println("CLAMD_ADDR not set: attachments are released without a virus scan (fail-open)")
+	} else {
+		log.Println("CLAMD_ADDR not set: new attachments stay pending and cannot be downloaded")
 	}
+	attachmentScanner := scheduler.NewAttachmentScanner(
+		protocolAttachmentRepo,
+		files,
+		scanner,
+		cfg.ScanInterval,
+		cfg.ScanFailOpen,
+		cfg.ScanBackoff,
+	)
+	go attachmentScanner.Run(context.Background())
+
+	// Miniaturas: geradas depois da varredura, ao lado do arquivo original
+	renderer := preview.NewRenderer(cfg.PDFToPPMPath)
+	if renderer.PDFToPPM == "" {
+		log.Println("pdftoppm not found: PDF attachments get no thumbnail")
+	}
+	thumbnailGenerator := scheduler.NewThumbnailGenerator(
+		protocolAttachmentRepo,
+		files,
+		renderer,
+		cfg.ThumbnailInterval,
+	)
+	go thumbnailGenerator.Run(context.Background())
+
+	// Domain events: consumers read the outbox written with each change
+	notificationRepo := repository.NewNotificationRepository(gormDB)
+	outboxRelay := scheduler.NewOutboxRelay(
+		outboxRepo,
+		cfg.OutboxInterval,
+		cfg.OutboxMaxAttempts,
+		cfg.OutboxRetention,
+		webhook.NewFanout(webhookRepo),
+		notify.NewOutboxConsumer(notificationRepo),
+	)
+	go outboxRelay.Run(context.Background())
+
+	// Event e-mails: sent outside the relay, with retries
+	notificationDispatcher := scheduler.NewNotificationDispatcher(
+		notificationRepo,
+		notify.NewEventNotifier(gormDB, protocolNotifier),
+		cfg.NotificationInterval,
+		cfg.NotificationMaxAttempts,
+		cfg.NotificationBackoff,
+	)
+	go notificationDispatcher.Run(context.Background())
+	go realtimeHub.Run(context.Background())
+
+	// Start server
+	log.Printf("Server starting on port %s (%s)", cfg.Port, cfg.Environment)
+	r.Run("0.0.0.0:" + cfg.Port)
+}
 
-	log.Printf("Server starting on port %s", port)
-	r.Run("0.0.0.0:" + port)
+// configurePool aplica os limites de conexão configurados
+func configurePool(db *sql.DB, cfg *config.Config) {
+	db.SetMaxOpenConns(cfg.DBMaxOpenConns)
+	db.SetMaxIdleConns(cfg.DBMaxIdleConns)
+	db.SetConnMaxLifetime(cfg.DBConnMaxLifetime)
 }
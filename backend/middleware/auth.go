@@ -0,0 +1,126 @@
+// backend/middleware/auth.go
+package middleware
+
+import (
+	"ProtocolManager/backend/models"
+	"ProtocolManager/backend/repository"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Chaves usadas para guardar o usuário autenticado no gin.Context
+const (
+	ContextUserKey  = "auth_user"
+	ContextScopeKey = "auth_scope"
+)
+
+// RequireAuth valida o token emitido por AuthHandler.Login (assinatura
+// HS256 e expiração), carrega o usuário e o coloca no contexto.
+func RequireAuth(
+	repo *repository.UserRepository, secret string,
+) gin.HandlerFunc {
+	key := []byte(secret)
+	parser := jwt.NewParser(
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithExpirationRequired(),
+	)
+
+	return func(c *gin.Context) {
+		header := c.GetHeader("Authorization")
+		tokenString, found := strings.CutPrefix(header, "Bearer ")
+		if !found || tokenString == "" {
+			c.AbortWithStatusJSON(
+				http.StatusUnauthorized, gin.H{"error": "Token ausente"},
+			)
+			return
+		}
+
+		claims := jwt.MapClaims{}
+		_, err := parser.ParseWithClaims(
+			tokenString, claims, func(*jwt.Token) (interface{}, error) {
+				return key, nil
+			},
+		)
+		if err != nil {
+			c.AbortWithStatusJSON(
+				http.StatusUnauthorized, gin.H{"error": "Token inválido"},
+			)
+			return
+		}
+
+		userID, ok := claims["user_id"].(float64)
+		if !ok {
+			c.AbortWithStatusJSON(
+				http.StatusUnauthorized, gin.H{"error": "Token inválido"},
+			)
+			return
+		}
+
+		user, err := repo.GetByID(int(userID))
+		if err != nil {
+			c.AbortWithStatusJSON(
+				http.StatusUnauthorized,
+				gin.H{"error": "Usuário não encontrado"},
+			)
+			return
+		}
+
+		if !user.Active {
+			c.AbortWithStatusJSON(
+				http.StatusForbidden, gin.H{"error": "Usuário inativo"},
+			)
+			return
+		}
+
+		scope, err := repo.ScopeFor(user)
+		if err != nil {
+			c.AbortWithStatusJSON(
+				http.StatusInternalServerError,
+				gin.H{"error": "Erro ao carregar filial do usuário"},
+			)
+			return
+		}
+
+		c.Set(ContextUserKey, user)
+		c.Set(ContextScopeKey, scope)
+		c.Next()
+	}
+}
+
+// CurrentUser retorna o usuário autenticado pela RequireAuth
+func CurrentUser(c *gin.Context) (models.User, bool) {
+	value, exists := c.Get(ContextUserKey)
+	if !exists {
+		return models.User{}, false
+	}
+	user, ok := value.(models.User)
+	return user, ok
+}
+
+// CurrentActor retorna o colaborador vinculado ao usuário autenticado.
+// Retorna false quando o usuário não possui PersonnelID.
+func CurrentActor(c *gin.Context) (repository.Actor, bool) {
+	user, ok := CurrentUser(c)
+	if !ok || user.PersonnelID == nil {
+		return repository.Actor{}, false
+	}
+	return repository.Actor{
+		UserID:      user.UserID,
+		PersonnelID: *user.PersonnelID,
+		Role:        user.Role,
+	}, true
+}
+
+// CurrentScope retorna as filiais visíveis para o usuário autenticado.
+// Sem autenticação o escopo não enxerga nenhum registro.
+func CurrentScope(c *gin.Context) repository.Scope {
+	value, exists := c.Get(ContextScopeKey)
+	if !exists {
+		return repository.Scope{}
+	}
+	scope, _ := value.(repository.Scope)
+	return scope
+}
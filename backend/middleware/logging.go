@@ -0,0 +1,39 @@
+// backend/middleware/logging.go
+package middleware
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestLogger registra cada requisição no formato do gin ("text") ou em
+// uma linha JSON por requisição ("json"), para coletores de log
+func RequestLogger(format string) gin.HandlerFunc {
+	if format != "json" {
+		return gin.Logger()
+	}
+	return gin.LoggerWithFormatter(func(p gin.LogFormatterParams) string {
+		line, _ := json.Marshal(struct {
+			Time      string  `json:"time"`
+			Method    string  `json:"method"`
+			Path      string  `json:"path"`
+			Status    int     `json:"status"`
+			LatencyMS float64 `json:"latency_ms"`
+			ClientIP  string  `json:"client_ip"`
+			BodySize  int     `json:"body_size"`
+			Error     string  `json:"error,omitempty"`
+		}{
+			Time:      p.TimeStamp.UTC().Format(time.RFC3339Nano),
+			Method:    p.Method,
+			Path:      p.Path,
+			Status:    p.StatusCode,
+			LatencyMS: float64(p.Latency.Microseconds()) / 1000,
+			ClientIP:  p.ClientIP,
+			BodySize:  p.BodySize,
+			Error:     p.ErrorMessage,
+		})
+		return string(line) + "\n"
+	})
+}
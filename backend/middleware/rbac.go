@@ -0,0 +1,98 @@
+// backend/middleware/rbac.go
+package middleware
+
+import (
+	"ProtocolManager/backend/models"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Permission string
+
+const (
+	PermProtocolsRead   Permission = "protocols:read"
+	PermProtocolsWrite  Permission = "protocols:write"
+	PermProtocolsDelete Permission = "protocols:delete"
+	PermCustomersRead   Permission = "customers:read"
+	PermCustomersWrite  Permission = "customers:write"
+	PermCustomersDelete Permission = "customers:delete"
+	PermBranchesRead    Permission = "branches:read"
+	PermBranchesWrite   Permission = "branches:write"
+	PermBranchesDelete  Permission = "branches:delete"
+	PermPersonnelRead   Permission = "personnel:read"
+	PermPersonnelWrite  Permission = "personnel:write"
+	PermPersonnelDelete Permission = "personnel:delete"
+	PermStatusesRead    Permission = "statuses:read"
+	PermStatusesWrite   Permission = "statuses:write"
+	PermStatusesDelete  Permission = "statuses:delete"
+	PermUsersManage     Permission = "users:manage"
+	PermWebhooksManage  Permission = "webhooks:manage"
+	PermSystemMonitor   Permission = "system:monitor"
+)
+
+var readPermissions = []Permission{
+	PermProtocolsRead,
+	PermCustomersRead,
+	PermBranchesRead,
+	PermPersonnelRead,
+	PermStatusesRead,
+}
+
+// Matriz de permissões por papel. Papéis fora de models.IsValidRole não
+// recebem nenhuma permissão.
+var rolePermissions = map[string][]Permission{
+	models.RoleAdmin: {
+		PermProtocolsRead, PermProtocolsWrite, PermProtocolsDelete,
+		PermCustomersRead, PermCustomersWrite, PermCustomersDelete,
+		PermBranchesRead, PermBranchesWrite, PermBranchesDelete,
+		PermPersonnelRead, PermPersonnelWrite, PermPersonnelDelete,
+		PermStatusesRead, PermStatusesWrite, PermStatusesDelete,
+		PermUsersManage, PermWebhooksManage, PermSystemMonitor,
+	},
+	models.RoleBranchManager: append(
+		[]Permission{
+			PermProtocolsWrite, PermProtocolsDelete,
+			PermCustomersWrite, PermCustomersDelete,
+			PermPersonnelWrite,
+		}, readPermissions...,
+	),
+	models.RoleAgent: append(
+		[]Permission{PermProtocolsWrite, PermCustomersWrite},
+		readPermissions...,
+	),
+	models.RoleReadOnly: readPermissions,
+}
+
+// HasPermission informa se o papel concede a permissão
+func HasPermission(role string, perm Permission) bool {
+	for _, p := range rolePermissions[role] {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
+
+// RequirePermission bloqueia a rota para usuários cujo papel não concede
+// a permissão. Deve ser usada depois de RequireAuth.
+func RequirePermission(perm Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, ok := CurrentUser(c)
+		if !ok {
+			c.AbortWithStatusJSON(
+				http.StatusUnauthorized, gin.H{"error": "Não autenticado"},
+			)
+			return
+		}
+
+		if !HasPermission(user.Role, perm) {
+			c.AbortWithStatusJSON(
+				http.StatusForbidden, gin.H{"error": "Permissão negada"},
+			)
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,105 @@
+// backend/migrate_cmd.go
+package main
+
+import (
+	"ProtocolManager/backend/migrations"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"text/tabwriter"
+)
+
+// migrateSchema aplica as migrações pendentes na inicialização. Com
+// DB_MIGRATE_ON_START=false o schema fica a cargo de "migrate up" e o
+// servidor não sobe com migrações pendentes.
+func migrateSchema(db *sql.DB, onStart bool) {
+	migrator, err := migrations.NewMigrator(db)
+	if err != nil {
+		log.Fatal("Invalid migrations: ", err)
+	}
+	if onStart {
+		if _, err := migrator.Up(context.Background()); err != nil {
+			log.Fatal("Migration failed: ", err)
+		}
+		return
+	}
+	pending, err := migrator.Pending(context.Background())
+	if err != nil {
+		log.Fatal("Error checking migrations: ", err)
+	}
+	if len(pending) > 0 {
+		log.Fatalf(
+			"%d pending migration(s), starting with %d_%s; run \"migrate up\"",
+			len(pending), pending[0].Version, pending[0].Name,
+		)
+	}
+}
+
+const migrateUsage = "usage: migrate up | down [N] | status"
+
+// runMigrate executa o subcomando "migrate": up aplica as pendentes, down
+// desfaz as N últimas (1 por padrão) e status lista a situação de cada
+// versão
+func runMigrate(db *sql.DB, args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+	migrator, err := migrations.NewMigrator(db)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			fmt.Println("No pending migrations")
+		}
+		return nil
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				return fmt.Errorf("invalid number of steps %q", args[1])
+			}
+		}
+		reverted, err := migrator.Down(ctx, steps)
+		if err != nil {
+			return err
+		}
+		if len(reverted) == 0 {
+			fmt.Println("No applied migrations")
+		}
+		return nil
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tSTATUS\tAPPLIED AT")
+		for _, s := range statuses {
+			state, appliedAt := "pending", ""
+			if s.Applied {
+				state = "applied"
+				appliedAt = s.AppliedAt.Local().Format("2006-01-02 15:04:05")
+			}
+			if !s.Known {
+				state = "applied (unknown)"
+			}
+			fmt.Fprintf(w, "%04d\t%s\t%s\t%s\n", s.Version, s.Name, state, appliedAt)
+		}
+		return w.Flush()
+	default:
+		return errors.New(migrateUsage)
+	}
+}
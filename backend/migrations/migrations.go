@@ -0,0 +1,342 @@
+// backend/migrations/migrations.go
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Os arquivos de sql/ seguem o padrão <versão>_<nome>.<up|down>.sql; cada
+// versão precisa dos dois sentidos
+//
+//go:embed sql/*.sql
+var files embed.FS
+
+var fileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// Chave do advisory lock que impede duas instâncias de migrarem o mesmo
+// banco ao mesmo tempo
+const migrationLock = 0x6d696772617465
+
+const createTable = `
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    BIGINT PRIMARY KEY,
+		name       TEXT NOT NULL,
+		applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	)
+`
+
+// ErrUnknownVersion indica uma versão aplicada no banco que este binário
+// não conhece, normalmente gravada por uma versão mais nova do servidor
+var ErrUnknownVersion = errors.New("versão de migração desconhecida")
+
+// Migration é um par de scripts numerado
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus é a situação de uma migração no banco
+type MigrationStatus struct {
+	Version   int64
+	Name      string
+	Applied   bool
+	AppliedAt time.Time
+	// Known é falso para versões aplicadas que não existem em sql/
+	Known bool
+}
+
+// Migrator aplica e desfaz as migrações embutidas, registrando cada versão
+// em schema_migrations. Cada migração roda na própria transação.
+type Migrator struct {
+	DB         *sql.DB
+	Migrations []Migration
+}
+
+func NewMigrator(db *sql.DB) (*Migrator, error) {
+	migrations, err := load(files)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{DB: db, Migrations: migrations}, nil
+}
+
+// load lê os scripts e os ordena por versão
+func load(fsys fs.FS) ([]Migration, error) {
+	names, err := fs.Glob(fsys, "sql/*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int64]*Migration{}
+	for _, name := range names {
+		match := fileName.FindStringSubmatch(name[len("sql/"):])
+		if match == nil {
+			return nil, fmt.Errorf("invalid migration file name %s", name)
+		}
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version %s: %w", name, err)
+		}
+		content, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		} else if m.Name != match[2] {
+			return nil, fmt.Errorf(
+				"migration %d has two names: %s and %s", version, m.Name, match[2],
+			)
+		}
+		if match[3] == "up" {
+			m.Up = string(content)
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf(
+				"migration %d_%s needs both up and down files", m.Version, m.Name,
+			)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// Up aplica, em ordem, todas as migrações pendentes e devolve as aplicadas
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var done []Migration
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, migration := range m.Migrations {
+			if _, ok := applied[migration.Version]; ok {
+				continue
+			}
+			if err := apply(ctx, conn, migration, true); err != nil {
+				return err
+			}
+			done = append(done, migration)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Down desfaz as últimas steps migrações aplicadas, da mais nova para a
+// mais antiga, e devolve as desfeitas
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	var done []Migration
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+		versions := make([]int64, 0, len(applied))
+		for version := range applied {
+			versions = append(versions, version)
+		}
+		sort.Slice(versions, func(i, j int) bool {
+			return versions[i] > versions[j]
+		})
+
+		known := m.byVersion()
+		for _, version := range versions[:min(steps, len(versions))] {
+			migration, ok := known[version]
+			if !ok {
+				return fmt.Errorf("%w: %d", ErrUnknownVersion, version)
+			}
+			if err := apply(ctx, conn, migration, false); err != nil {
+				return err
+			}
+			done = append(done, migration)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Status lista as migrações conhecidas e as aplicadas no banco, por versão
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	conn, err := m.DB.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, createTable); err != nil {
+		return nil, err
+	}
+	applied, err := appliedVersions(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, 0, len(m.Migrations))
+	for _, migration := range m.Migrations {
+		status := MigrationStatus{
+			Version: migration.Version,
+			Name:    migration.Name,
+			Known:   true,
+		}
+		if row, ok := applied[migration.Version]; ok {
+			status.Applied = true
+			status.AppliedAt = row.AppliedAt
+			delete(applied, migration.Version)
+		}
+		statuses = append(statuses, status)
+	}
+	for _, row := range applied {
+		statuses = append(statuses, row)
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Version < statuses[j].Version
+	})
+	return statuses, nil
+}
+
+// Pending devolve as migrações conhecidas que ainda não foram aplicadas
+func (m *Migrator) Pending(ctx context.Context) ([]Migration, error) {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+	known := m.byVersion()
+	var pending []Migration
+	for _, status := range statuses {
+		if !status.Applied {
+			pending = append(pending, known[status.Version])
+		}
+	}
+	return pending, nil
+}
+
+func (m *Migrator) byVersion() map[int64]Migration {
+	known := make(map[int64]Migration, len(m.Migrations))
+	for _, migration := range m.Migrations {
+		known[migration.Version] = migration
+	}
+	return known
+}
+
+// withLock executa fn numa conexão dedicada que segura o advisory lock.
+// pg_advisory_lock pertence à sessão, por isso a conexão não pode voltar ao
+// pool antes do unlock.
+func (m *Migrator) withLock(
+	ctx context.Context, fn func(conn *sql.Conn) error,
+) error {
+	conn, err := m.DB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(
+		ctx, "SELECT pg_advisory_lock($1)", migrationLock,
+	); err != nil {
+		return fmt.Errorf("acquire migration lock: %w", err)
+	}
+	defer func() {
+		// Com o contexto cancelado o unlock ainda precisa rodar
+		if _, err := conn.ExecContext(
+			context.Background(), "SELECT pg_advisory_unlock($1)", migrationLock,
+		); err != nil {
+			log.Printf("Failed to release migration lock: %v", err)
+		}
+	}()
+
+	if _, err := conn.ExecContext(ctx, createTable); err != nil {
+		return err
+	}
+	return fn(conn)
+}
+
+// appliedVersions lê schema_migrations; o nome gravado é usado para
+// versões que não existem neste binário
+func appliedVersions(
+	ctx context.Context, conn *sql.Conn,
+) (map[int64]MigrationStatus, error) {
+	rows, err := conn.QueryContext(
+		ctx, "SELECT version, name, applied_at FROM schema_migrations",
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := map[int64]MigrationStatus{}
+	for rows.Next() {
+		status := MigrationStatus{Applied: true}
+		if err := rows.Scan(
+			&status.Version, &status.Name, &status.AppliedAt,
+		); err != nil {
+			return nil, err
+		}
+		applied[status.Version] = status
+	}
+	return applied, rows.Err()
+}
+
+// apply executa um sentido da migração e atualiza schema_migrations na
+// mesma transação
+func apply(
+	ctx context.Context, conn *sql.Conn, migration Migration, up bool,
+) error {
+	direction, script := "down", migration.Down
+	record := "DELETE FROM schema_migrations WHERE version = $1"
+	args := []any{migration.Version}
+	if up {
+		direction, script = "up", migration.Up
+		record = "INSERT INTO schema_migrations (version, name) VALUES ($1, $2)"
+		args = append(args, migration.Name)
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Sem argumentos o script vai pelo protocolo simples, que aceita vários
+	// comandos e blocos DO
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		return fmt.Errorf(
+			"migration %d_%s (%s): %w",
+			migration.Version, migration.Name, direction, err,
+		)
+	}
+	if _, err := tx.ExecContext(ctx, record, args...); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	log.Printf(
+		"Migration %d_%s applied (%s)", migration.Version, migration.Name, direction,
+	)
+	return nil
+}
@@ -0,0 +1,19 @@
+-- Remove todo o esquema: só faz sentido em bancos de desenvolvimento
+DROP TABLE IF EXISTS outbox_cursors;
+DROP TABLE IF EXISTS outbox_events;
+DROP TABLE IF EXISTS webhook_deliveries;
+DROP TABLE IF EXISTS webhook_subscriptions;
+DROP TABLE IF EXISTS holidays;
+DROP TABLE IF EXISTS protocol_status_transitions;
+DROP TABLE IF EXISTS protocol_reminders;
+DROP TABLE IF EXISTS protocol_attachment_versions;
+DROP TABLE IF EXISTS protocol_attachments;
+DROP TABLE IF EXISTS protocol_history;
+DROP TABLE IF EXISTS protocol_sequences;
+DROP TABLE IF EXISTS protocols;
+DROP TABLE IF EXISTS protocol_statuses;
+DROP TABLE IF EXISTS protocol_types;
+DROP TABLE IF EXISTS users;
+DROP TABLE IF EXISTS customers;
+DROP TABLE IF EXISTS sales_personnel;
+DROP TABLE IF EXISTS insurance_branches;
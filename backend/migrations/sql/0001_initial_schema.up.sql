@@ -0,0 +1,327 @@
+-- Esquema base, equivalente ao que o AutoMigrate criava. Tudo usa IF NOT
+-- EXISTS para que bancos criados antes das migrações sejam adotados sem
+-- alteração; chaves estrangeiras ficam na 0003.
+
+CREATE TABLE IF NOT EXISTS insurance_branches (
+    branch_id   BIGSERIAL PRIMARY KEY,
+    branch_name TEXT NOT NULL,
+    branch_code TEXT NOT NULL,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_insurance_branches_branch_code
+    ON insurance_branches (branch_code);
+
+CREATE TABLE IF NOT EXISTS sales_personnel (
+    personnel_id BIGSERIAL PRIMARY KEY,
+    first_name   TEXT NOT NULL,
+    last_name    TEXT NOT NULL,
+    email        TEXT NOT NULL,
+    phone        TEXT,
+    branch_id    BIGINT,
+    active       BOOLEAN DEFAULT true,
+    created_at   TIMESTAMPTZ,
+    updated_at   TIMESTAMPTZ
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_sales_personnel_email
+    ON sales_personnel (email);
+
+CREATE TABLE IF NOT EXISTS customers (
+    customer_id BIGSERIAL PRIMARY KEY,
+    first_name  TEXT NOT NULL,
+    last_name   TEXT NOT NULL,
+    email       TEXT NOT NULL,
+    phone       TEXT,
+    address     TEXT,
+    city        TEXT,
+    state       TEXT,
+    postal_code TEXT,
+    branch_id   BIGINT,
+    active      BOOLEAN DEFAULT true,
+    created_at  TIMESTAMPTZ,
+    updated_at  TIMESTAMPTZ
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_customers_email ON customers (email);
+
+CREATE TABLE IF NOT EXISTS users (
+    user_id       BIGSERIAL PRIMARY KEY,
+    email         TEXT NOT NULL,
+    password_hash TEXT NOT NULL,
+    role          TEXT NOT NULL,
+    active        BOOLEAN NOT NULL DEFAULT true,
+    created_at    TIMESTAMPTZ,
+    updated_at    TIMESTAMPTZ,
+    personnel_id  BIGINT
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email ON users (email);
+
+CREATE TABLE IF NOT EXISTS protocol_types (
+    type_id               BIGSERIAL PRIMARY KEY,
+    type_name             TEXT NOT NULL,
+    description           TEXT,
+    default_deadline_days BIGINT,
+    allowed_mime_types    TEXT,
+    denied_mime_types     TEXT,
+    created_at            TIMESTAMPTZ,
+    updated_at            TIMESTAMPTZ,
+    CONSTRAINT uni_protocol_types_type_name UNIQUE (type_name)
+);
+
+CREATE TABLE IF NOT EXISTS protocol_statuses (
+    status_id   BIGSERIAL PRIMARY KEY,
+    status_name TEXT,
+    color       TEXT,
+    is_terminal BOOLEAN
+);
+
+CREATE TABLE IF NOT EXISTS protocols (
+    protocol_id         BIGSERIAL PRIMARY KEY,
+    protocol_number     TEXT,
+    title               TEXT NOT NULL,
+    description         TEXT,
+    type_id             BIGINT NOT NULL,
+    status_id           BIGINT NOT NULL,
+    requestor_id        BIGINT,
+    customer_id         BIGINT,
+    branch_id           BIGINT,
+    assigned_to         BIGINT,
+    created_by          BIGINT NOT NULL,
+    priority            TEXT,
+    deadline            TIMESTAMPTZ,
+    date_required       TIMESTAMPTZ,
+    expected_completion TIMESTAMPTZ,
+    created_at          TIMESTAMPTZ,
+    updated_at          TIMESTAMPTZ,
+    closed_at           TIMESTAMPTZ,
+    sla_breached_at     TIMESTAMPTZ
+);
+
+-- Antes da sequência por ano, números podiam se repetir ou ficar vazios, e
+-- o índice único abaixo não seria criado. Vazios recebem LEGACY-<id>; das
+-- repetições, o protocolo mais antigo mantém o número e os demais ganham o
+-- sufixo -DUP<n>. Cada alteração é informada no log da migração.
+DO $$
+DECLARE
+    fixed RECORD;
+BEGIN
+    FOR fixed IN
+        UPDATE protocols
+        SET protocol_number = 'LEGACY-' || protocol_id
+        WHERE protocol_number IS NULL OR btrim(protocol_number) = ''
+        RETURNING protocol_id, protocol_number
+    LOOP
+        RAISE WARNING 'protocol % had no number, renumbered to %',
+            fixed.protocol_id, fixed.protocol_number;
+    END LOOP;
+
+    FOR fixed IN
+        WITH duplicated AS (
+            SELECT protocol_id, protocol_number,
+                row_number() OVER (
+                    PARTITION BY protocol_number ORDER BY protocol_id
+                ) AS n
+            FROM protocols
+        )
+        UPDATE protocols p
+        SET protocol_number = d.protocol_number || '-DUP' || (d.n - 1)
+        FROM duplicated d
+        WHERE p.protocol_id = d.protocol_id AND d.n > 1
+        RETURNING p.protocol_id, d.protocol_number AS old_number,
+            p.protocol_number
+    LOOP
+        RAISE WARNING 'protocol % duplicated number %, renumbered to %',
+            fixed.protocol_id, fixed.old_number, fixed.protocol_number;
+    END LOOP;
+END $$;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_protocols_protocol_number
+    ON protocols (protocol_number);
+
+CREATE TABLE IF NOT EXISTS protocol_sequences (
+    scope_key  TEXT NOT NULL,
+    year       BIGINT NOT NULL,
+    last_value BIGINT NOT NULL,
+    PRIMARY KEY (scope_key, year)
+);
+
+CREATE TABLE IF NOT EXISTS protocol_history (
+    protocol_history_id BIGSERIAL PRIMARY KEY,
+    protocol_id         BIGINT,
+    old_status_id       BIGINT,
+    new_status_id       BIGINT,
+    notes               TEXT,
+    created_by          BIGINT,
+    created_at          TIMESTAMPTZ
+);
+
+CREATE TABLE IF NOT EXISTS protocol_attachments (
+    attachment_id    BIGSERIAL PRIMARY KEY,
+    protocol_id      BIGINT NOT NULL,
+    file_name        TEXT NOT NULL,
+    file_path        TEXT NOT NULL,
+    file_size        BIGINT,
+    content_type     TEXT,
+    description      TEXT,
+    uploaded_by      BIGINT NOT NULL,
+    uploaded_at      TIMESTAMPTZ,
+    checksum         TEXT,
+    version          BIGINT NOT NULL DEFAULT 1,
+    scan_status      TEXT NOT NULL DEFAULT 'pending',
+    scan_result      TEXT,
+    scanned_at       TIMESTAMPTZ,
+    thumbnail_status TEXT NOT NULL DEFAULT 'pending',
+    thumbnail_path   TEXT
+);
+
+CREATE TABLE IF NOT EXISTS protocol_attachment_versions (
+    version_id       BIGSERIAL PRIMARY KEY,
+    attachment_id    BIGINT NOT NULL,
+    version          BIGINT NOT NULL,
+    file_name        TEXT NOT NULL,
+    file_path        TEXT NOT NULL,
+    file_size        BIGINT,
+    content_type     TEXT,
+    uploaded_by      BIGINT NOT NULL,
+    uploaded_at      TIMESTAMPTZ,
+    checksum         TEXT,
+    scan_status      TEXT NOT NULL DEFAULT 'pending',
+    scan_result      TEXT,
+    scan_attempts    BIGINT DEFAULT 0,
+    next_scan_at     TIMESTAMPTZ,
+    scanned_at       TIMESTAMPTZ,
+    thumbnail_status TEXT NOT NULL DEFAULT 'pending',
+    thumbnail_path   TEXT
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_attachment_version
+    ON protocol_attachment_versions (attachment_id, version);
+CREATE INDEX IF NOT EXISTS idx_protocol_attachment_versions_scan_status
+    ON protocol_attachment_versions (scan_status);
+
+CREATE TABLE IF NOT EXISTS protocol_reminders (
+    reminder_id      BIGSERIAL PRIMARY KEY,
+    protocol_id      BIGINT NOT NULL,
+    reminder_text    TEXT NOT NULL,
+    reminder_message TEXT,
+    reminder_date    TIMESTAMPTZ NOT NULL,
+    is_completed     BOOLEAN DEFAULT false,
+    is_sent          BOOLEAN DEFAULT false,
+    created_by       BIGINT NOT NULL,
+    created_at       TIMESTAMPTZ,
+    sent_at          TIMESTAMPTZ,
+    attempts         BIGINT DEFAULT 0,
+    next_attempt_at  TIMESTAMPTZ,
+    last_error       TEXT,
+    failed_at        TIMESTAMPTZ
+);
+
+CREATE TABLE IF NOT EXISTS protocol_status_transitions (
+    transition_id  BIGSERIAL PRIMARY KEY,
+    type_id        BIGINT NOT NULL,
+    from_status_id BIGINT NOT NULL,
+    to_status_id   BIGINT NOT NULL,
+    allowed_roles  TEXT,
+    requires_note  BOOLEAN DEFAULT false,
+    created_at     TIMESTAMPTZ,
+    updated_at     TIMESTAMPTZ
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_status_transition
+    ON protocol_status_transitions (type_id, from_status_id, to_status_id);
+
+CREATE TABLE IF NOT EXISTS holidays (
+    holiday_id BIGSERIAL PRIMARY KEY,
+    date       DATE NOT NULL,
+    name       TEXT NOT NULL,
+    branch_id  BIGINT,
+    created_at TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS idx_holidays_date ON holidays (date);
+
+CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+    subscription_id BIGSERIAL PRIMARY KEY,
+    url             TEXT NOT NULL,
+    secret          TEXT NOT NULL,
+    events          TEXT,
+    active          BOOLEAN DEFAULT true,
+    created_at      TIMESTAMPTZ,
+    updated_at      TIMESTAMPTZ
+);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    delivery_id     BIGSERIAL PRIMARY KEY,
+    subscription_id BIGINT NOT NULL,
+    event_id        TEXT NOT NULL,
+    event           TEXT NOT NULL,
+    payload         TEXT NOT NULL,
+    status          TEXT NOT NULL DEFAULT 'pending',
+    attempts        BIGINT DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ,
+    last_error      TEXT,
+    response_status BIGINT,
+    created_at      TIMESTAMPTZ,
+    delivered_at    TIMESTAMPTZ
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_webhook_delivery_event
+    ON webhook_deliveries (subscription_id, event_id);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_status
+    ON webhook_deliveries (status);
+
+CREATE TABLE IF NOT EXISTS outbox_events (
+    event_id        BIGSERIAL PRIMARY KEY,
+    idempotency_key TEXT NOT NULL,
+    event_type      TEXT NOT NULL,
+    protocol_id     BIGINT NOT NULL,
+    branch_id       BIGINT,
+    payload         TEXT NOT NULL,
+    occurred_at     TIMESTAMPTZ NOT NULL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_outbox_events_idempotency_key
+    ON outbox_events (idempotency_key);
+CREATE INDEX IF NOT EXISTS idx_outbox_events_protocol_id
+    ON outbox_events (protocol_id);
+CREATE INDEX IF NOT EXISTS idx_outbox_events_occurred_at
+    ON outbox_events (occurred_at);
+
+CREATE TABLE IF NOT EXISTS outbox_cursors (
+    consumer      TEXT PRIMARY KEY,
+    last_event_id BIGINT NOT NULL DEFAULT 0,
+    last_error    TEXT,
+    last_error_at TIMESTAMPTZ,
+    updated_at    TIMESTAMPTZ
+);
+
+-- Colunas acrescentadas ao longo do tempo pelo AutoMigrate: bancos antigos
+-- podem não ter todas. A lista cobre toda a diferença entre os modelos
+-- originais (protocols, protocol_history, protocol_attachments,
+-- protocol_reminders, protocol_statuses e protocol_types) e os atuais;
+-- order_sequence fica na 0002. Toda coluna nova num modelo precisa de uma
+-- migração com ADD COLUMN IF NOT EXISTS.
+ALTER TABLE protocol_types ADD COLUMN IF NOT EXISTS allowed_mime_types TEXT;
+ALTER TABLE protocol_types ADD COLUMN IF NOT EXISTS denied_mime_types TEXT;
+ALTER TABLE protocols ADD COLUMN IF NOT EXISTS sla_breached_at TIMESTAMPTZ;
+ALTER TABLE protocol_attachments ADD COLUMN IF NOT EXISTS checksum TEXT;
+ALTER TABLE protocol_attachments
+    ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT 1;
+ALTER TABLE protocol_attachments
+    ADD COLUMN IF NOT EXISTS scan_status TEXT NOT NULL DEFAULT 'pending';
+ALTER TABLE protocol_attachments ADD COLUMN IF NOT EXISTS scan_result TEXT;
+ALTER TABLE protocol_attachments ADD COLUMN IF NOT EXISTS scanned_at TIMESTAMPTZ;
+ALTER TABLE protocol_attachments
+    ADD COLUMN IF NOT EXISTS thumbnail_status TEXT NOT NULL DEFAULT 'pending';
+ALTER TABLE protocol_attachments ADD COLUMN IF NOT EXISTS thumbnail_path TEXT;
+ALTER TABLE protocol_attachment_versions ADD COLUMN IF NOT EXISTS checksum TEXT;
+ALTER TABLE protocol_attachment_versions
+    ADD COLUMN IF NOT EXISTS thumbnail_status TEXT NOT NULL DEFAULT 'pending';
+ALTER TABLE protocol_attachment_versions
+    ADD COLUMN IF NOT EXISTS thumbnail_path TEXT;
+-- Entrega dos lembretes pelo despachante
+ALTER TABLE protocol_reminders ADD COLUMN IF NOT EXISTS sent_at TIMESTAMPTZ;
+ALTER TABLE protocol_reminders ADD COLUMN IF NOT EXISTS attempts BIGINT DEFAULT 0;
+ALTER TABLE protocol_reminders
+    ADD COLUMN IF NOT EXISTS next_attempt_at TIMESTAMPTZ;
+ALTER TABLE protocol_reminders ADD COLUMN IF NOT EXISTS last_error TEXT;
+ALTER TABLE protocol_reminders ADD COLUMN IF NOT EXISTS failed_at TIMESTAMPTZ;
+
+-- Índices das colunas acima, criados só depois delas existirem
+CREATE INDEX IF NOT EXISTS idx_protocol_attachments_scan_status
+    ON protocol_attachments (scan_status);
+CREATE INDEX IF NOT EXISTS idx_protocol_attachment_versions_thumbnail_status
+    ON protocol_attachment_versions (thumbnail_status);
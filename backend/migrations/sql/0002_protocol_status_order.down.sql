@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_protocol_statuses_order_sequence;
+ALTER TABLE protocol_statuses DROP COLUMN IF EXISTS order_sequence;
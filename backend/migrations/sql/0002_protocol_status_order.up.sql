@@ -0,0 +1,7 @@
+-- Ordem de exibição dos status, usada por ProtocolStatusRepository.GetAll.
+-- Os status existentes mantêm a ordem de criação.
+ALTER TABLE protocol_statuses
+    ADD COLUMN IF NOT EXISTS order_sequence INTEGER NOT NULL DEFAULT 0;
+UPDATE protocol_statuses SET order_sequence = status_id WHERE order_sequence = 0;
+CREATE INDEX IF NOT EXISTS idx_protocol_statuses_order_sequence
+    ON protocol_statuses (order_sequence);
@@ -0,0 +1,36 @@
+-- Remove as chaves e índices criados na 0003
+DROP INDEX IF EXISTS idx_sales_personnel_branch_id;
+DROP INDEX IF EXISTS idx_customers_branch_id;
+DROP INDEX IF EXISTS idx_protocol_reminders_protocol_id;
+DROP INDEX IF EXISTS idx_protocol_attachments_protocol_id;
+DROP INDEX IF EXISTS idx_protocol_history_protocol_id;
+DROP INDEX IF EXISTS idx_protocols_assigned_to;
+DROP INDEX IF EXISTS idx_protocols_customer_id;
+DROP INDEX IF EXISTS idx_protocols_branch_id;
+DROP INDEX IF EXISTS idx_protocols_type_id;
+DROP INDEX IF EXISTS idx_protocols_status_id;
+ALTER TABLE webhook_deliveries DROP CONSTRAINT IF EXISTS fk_webhook_deliveries_subscription;
+ALTER TABLE protocol_status_transitions DROP CONSTRAINT IF EXISTS fk_protocol_status_transitions_to_status;
+ALTER TABLE protocol_status_transitions DROP CONSTRAINT IF EXISTS fk_protocol_status_transitions_from_status;
+ALTER TABLE protocol_status_transitions DROP CONSTRAINT IF EXISTS fk_protocol_status_transitions_type;
+ALTER TABLE protocol_reminders DROP CONSTRAINT IF EXISTS fk_protocol_reminders_created_by_agent;
+ALTER TABLE protocol_reminders DROP CONSTRAINT IF EXISTS fk_protocol_reminders_protocol;
+ALTER TABLE protocol_attachment_versions DROP CONSTRAINT IF EXISTS fk_protocol_attachment_versions_uploaded_by_agent;
+ALTER TABLE protocol_attachment_versions DROP CONSTRAINT IF EXISTS fk_protocol_attachment_versions_attachment;
+ALTER TABLE protocol_attachments DROP CONSTRAINT IF EXISTS fk_protocol_attachments_uploaded_by_agent;
+ALTER TABLE protocol_attachments DROP CONSTRAINT IF EXISTS fk_protocol_attachments_protocol;
+ALTER TABLE protocol_history DROP CONSTRAINT IF EXISTS fk_protocol_history_created_by_agent;
+ALTER TABLE protocol_history DROP CONSTRAINT IF EXISTS fk_protocol_history_new_status;
+ALTER TABLE protocol_history DROP CONSTRAINT IF EXISTS fk_protocol_history_previous_status;
+ALTER TABLE protocol_history DROP CONSTRAINT IF EXISTS fk_protocol_history_protocol;
+ALTER TABLE protocols DROP CONSTRAINT IF EXISTS fk_protocols_created_by_agent;
+ALTER TABLE protocols DROP CONSTRAINT IF EXISTS fk_protocols_assigned_agent;
+ALTER TABLE protocols DROP CONSTRAINT IF EXISTS fk_protocols_requestor;
+ALTER TABLE protocols DROP CONSTRAINT IF EXISTS fk_protocols_branch;
+ALTER TABLE protocols DROP CONSTRAINT IF EXISTS fk_protocols_customer;
+ALTER TABLE protocols DROP CONSTRAINT IF EXISTS fk_protocols_status;
+ALTER TABLE protocols DROP CONSTRAINT IF EXISTS fk_protocols_type;
+ALTER TABLE holidays DROP CONSTRAINT IF EXISTS fk_holidays_branch;
+ALTER TABLE users DROP CONSTRAINT IF EXISTS fk_users_personnel;
+ALTER TABLE customers DROP CONSTRAINT IF EXISTS fk_customers_branch;
+ALTER TABLE sales_personnel DROP CONSTRAINT IF EXISTS fk_sales_personnel_branch;
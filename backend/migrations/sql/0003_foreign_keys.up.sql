@@ -0,0 +1,88 @@
+-- Chaves estrangeiras declaradas explicitamente. Os nomes seguem os que o
+-- AutoMigrate do GORM usava, para que bancos antigos não fiquem com
+-- restrições duplicadas.
+--
+-- As restrições entram como NOT VALID e são validadas em seguida: se um
+-- banco antigo tiver registros órfãos, a restrição continua valendo para
+-- novas gravações e a validação é adiada com um aviso, em vez de impedir a
+-- migração. Depois de corrigir os dados, basta
+-- ALTER TABLE <tabela> VALIDATE CONSTRAINT <nome>.
+--
+-- outbox_events não tem chave para protocols: o evento protocol.deleted
+-- sobrevive ao protocolo removido.
+DO $$
+DECLARE
+    fk RECORD;
+BEGIN
+    FOR fk IN SELECT * FROM (VALUES
+        ('sales_personnel', 'fk_sales_personnel_branch', 'branch_id', 'insurance_branches (branch_id)'),
+        ('customers', 'fk_customers_branch', 'branch_id', 'insurance_branches (branch_id)'),
+        ('users', 'fk_users_personnel', 'personnel_id', 'sales_personnel (personnel_id)'),
+        ('holidays', 'fk_holidays_branch', 'branch_id', 'insurance_branches (branch_id)'),
+
+        ('protocols', 'fk_protocols_type', 'type_id', 'protocol_types (type_id)'),
+        ('protocols', 'fk_protocols_status', 'status_id', 'protocol_statuses (status_id)'),
+        ('protocols', 'fk_protocols_customer', 'customer_id', 'customers (customer_id)'),
+        ('protocols', 'fk_protocols_branch', 'branch_id', 'insurance_branches (branch_id)'),
+        ('protocols', 'fk_protocols_requestor', 'requestor_id', 'sales_personnel (personnel_id)'),
+        ('protocols', 'fk_protocols_assigned_agent', 'assigned_to', 'sales_personnel (personnel_id)'),
+        ('protocols', 'fk_protocols_created_by_agent', 'created_by', 'sales_personnel (personnel_id)'),
+
+        ('protocol_history', 'fk_protocol_history_protocol', 'protocol_id', 'protocols (protocol_id)'),
+        ('protocol_history', 'fk_protocol_history_previous_status', 'old_status_id', 'protocol_statuses (status_id)'),
+        ('protocol_history', 'fk_protocol_history_new_status', 'new_status_id', 'protocol_statuses (status_id)'),
+        ('protocol_history', 'fk_protocol_history_created_by_agent', 'created_by', 'sales_personnel (personnel_id)'),
+
+        ('protocol_attachments', 'fk_protocol_attachments_protocol', 'protocol_id', 'protocols (protocol_id)'),
+        ('protocol_attachments', 'fk_protocol_attachments_uploaded_by_agent', 'uploaded_by', 'sales_personnel (personnel_id)'),
+        ('protocol_attachment_versions', 'fk_protocol_attachment_versions_attachment', 'attachment_id', 'protocol_attachments (attachment_id)'),
+        ('protocol_attachment_versions', 'fk_protocol_attachment_versions_uploaded_by_agent', 'uploaded_by', 'sales_personnel (personnel_id)'),
+
+        ('protocol_reminders', 'fk_protocol_reminders_protocol', 'protocol_id', 'protocols (protocol_id)'),
+        ('protocol_reminders', 'fk_protocol_reminders_created_by_agent', 'created_by', 'sales_personnel (personnel_id)'),
+
+        ('protocol_status_transitions', 'fk_protocol_status_transitions_type', 'type_id', 'protocol_types (type_id)'),
+        ('protocol_status_transitions', 'fk_protocol_status_transitions_from_status', 'from_status_id', 'protocol_statuses (status_id)'),
+        ('protocol_status_transitions', 'fk_protocol_status_transitions_to_status', 'to_status_id', 'protocol_statuses (status_id)'),
+
+        ('webhook_deliveries', 'fk_webhook_deliveries_subscription', 'subscription_id', 'webhook_subscriptions (subscription_id)')
+    ) AS t (table_name, constraint_name, column_name, target)
+    LOOP
+        IF NOT EXISTS (
+            SELECT 1 FROM pg_constraint
+            WHERE conname = fk.constraint_name
+                AND conrelid = format('%I', fk.table_name)::regclass
+        ) THEN
+            EXECUTE format(
+                'ALTER TABLE %I ADD CONSTRAINT %I FOREIGN KEY (%I) REFERENCES %s NOT VALID',
+                fk.table_name, fk.constraint_name, fk.column_name, fk.target
+            );
+        END IF;
+
+        BEGIN
+            EXECUTE format(
+                'ALTER TABLE %I VALIDATE CONSTRAINT %I',
+                fk.table_name, fk.constraint_name
+            );
+        EXCEPTION WHEN foreign_key_violation THEN
+            RAISE WARNING '% not validated: % has rows referencing missing records',
+                fk.constraint_name, fk.table_name;
+        END;
+    END LOOP;
+END $$;
+
+-- Índices das chaves mais consultadas
+CREATE INDEX IF NOT EXISTS idx_protocols_status_id ON protocols (status_id);
+CREATE INDEX IF NOT EXISTS idx_protocols_type_id ON protocols (type_id);
+CREATE INDEX IF NOT EXISTS idx_protocols_branch_id ON protocols (branch_id);
+CREATE INDEX IF NOT EXISTS idx_protocols_customer_id ON protocols (customer_id);
+CREATE INDEX IF NOT EXISTS idx_protocols_assigned_to ON protocols (assigned_to);
+CREATE INDEX IF NOT EXISTS idx_protocol_history_protocol_id
+    ON protocol_history (protocol_id);
+CREATE INDEX IF NOT EXISTS idx_protocol_attachments_protocol_id
+    ON protocol_attachments (protocol_id);
+CREATE INDEX IF NOT EXISTS idx_protocol_reminders_protocol_id
+    ON protocol_reminders (protocol_id);
+CREATE INDEX IF NOT EXISTS idx_customers_branch_id ON customers (branch_id);
+CREATE INDEX IF NOT EXISTS idx_sales_personnel_branch_id
+    ON sales_personnel (branch_id);
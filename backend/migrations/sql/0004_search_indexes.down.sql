@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_protocol_history_search;
+DROP INDEX IF EXISTS idx_customers_search;
+DROP INDEX IF EXISTS idx_protocols_search;
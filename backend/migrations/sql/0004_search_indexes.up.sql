@@ -0,0 +1,8 @@
+-- Índices GIN da busca textual. As expressões precisam ser idênticas às de
+-- repository/search_repository.go para que o PostgreSQL use os índices.
+CREATE INDEX IF NOT EXISTS idx_protocols_search ON protocols
+    USING GIN (to_tsvector('portuguese', coalesce(title, '') || ' ' || coalesce(description, '')));
+CREATE INDEX IF NOT EXISTS idx_customers_search ON customers
+    USING GIN (to_tsvector('simple', coalesce(first_name, '') || ' ' || coalesce(last_name, '') || ' ' || coalesce(email, '')));
+CREATE INDEX IF NOT EXISTS idx_protocol_history_search ON protocol_history
+    USING GIN (to_tsvector('portuguese', coalesce(notes, '')));
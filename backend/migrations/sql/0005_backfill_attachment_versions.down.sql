@@ -0,0 +1,2 @@
+-- As versões criadas pelo backfill não se distinguem das demais; nada é
+-- desfeito
@@ -0,0 +1,15 @@
+-- Anexos gravados antes do versionamento passam a ter a versão 1
+INSERT INTO protocol_attachment_versions (
+    attachment_id, version, file_name, file_path, file_size,
+    content_type, uploaded_by, uploaded_at, checksum, scan_status,
+    scan_result, scanned_at, thumbnail_status, thumbnail_path
+)
+SELECT a.attachment_id, a.version, a.file_name, a.file_path,
+    a.file_size, a.content_type, a.uploaded_by, a.uploaded_at,
+    a.checksum, a.scan_status, a.scan_result, a.scanned_at,
+    a.thumbnail_status, a.thumbnail_path
+FROM protocol_attachments a
+WHERE NOT EXISTS (
+    SELECT 1 FROM protocol_attachment_versions v
+    WHERE v.attachment_id = a.attachment_id
+);
@@ -0,0 +1,2 @@
+-- Os papéis antigos não são restaurados; só a restrição é removida
+ALTER TABLE users DROP CONSTRAINT IF EXISTS chk_users_role;
@@ -0,0 +1,48 @@
+-- Antes do controle de acesso o papel vinha do cliente no cadastro e não
+-- era validado. Os valores antigos são convertidos para os papéis de
+-- models/role.go: variações de administrador e gerente mantêm o nível de
+-- acesso, "user" e similares viram agent (antes podiam tudo, exceto
+-- administrar) e qualquer outro valor, inclusive vazio, vira read_only.
+-- Cada usuário convertido é informado com RAISE WARNING.
+DO $$
+DECLARE
+    u RECORD;
+    mapped TEXT;
+BEGIN
+    FOR u IN
+        SELECT user_id, email, role FROM users
+        WHERE role IS NULL
+            OR role NOT IN ('admin', 'branch_manager', 'agent', 'read_only')
+        ORDER BY user_id
+    LOOP
+        mapped := CASE lower(btrim(coalesce(u.role, '')))
+            WHEN 'admin' THEN 'admin'
+            WHEN 'administrator' THEN 'admin'
+            WHEN 'administrador' THEN 'admin'
+            WHEN 'superuser' THEN 'admin'
+            WHEN 'manager' THEN 'branch_manager'
+            WHEN 'branch_manager' THEN 'branch_manager'
+            WHEN 'branch-manager' THEN 'branch_manager'
+            WHEN 'gerente' THEN 'branch_manager'
+            WHEN 'agent' THEN 'agent'
+            WHEN 'agente' THEN 'agent'
+            WHEN 'user' THEN 'agent'
+            WHEN 'usuario' THEN 'agent'
+            WHEN 'usuário' THEN 'agent'
+            WHEN 'operator' THEN 'agent'
+            WHEN 'operador' THEN 'agent'
+            WHEN 'atendente' THEN 'agent'
+            WHEN 'read_only' THEN 'read_only'
+            WHEN 'readonly' THEN 'read_only'
+            WHEN 'viewer' THEN 'read_only'
+            ELSE 'read_only'
+        END;
+        UPDATE users SET role = mapped WHERE user_id = u.user_id;
+        RAISE WARNING 'user % (%): role % mapped to %',
+            u.user_id, u.email, coalesce(quote_literal(u.role), 'NULL'), mapped;
+    END LOOP;
+END $$;
+
+ALTER TABLE users DROP CONSTRAINT IF EXISTS chk_users_role;
+ALTER TABLE users ADD CONSTRAINT chk_users_role
+    CHECK (role IN ('admin', 'branch_manager', 'agent', 'read_only'));
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS outbox_dead_letters;
+ALTER TABLE outbox_cursors
+    DROP COLUMN IF EXISTS attempts,
+    DROP COLUMN IF EXISTS failed_event_id;
@@ -0,0 +1,22 @@
+-- Tentativas do evento em que cada consumidor está parado. Ao atingir
+-- OUTBOX_MAX_ATTEMPTS o evento vai para outbox_dead_letters e o cursor
+-- segue adiante.
+ALTER TABLE outbox_cursors
+    ADD COLUMN IF NOT EXISTS failed_event_id BIGINT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS attempts INTEGER NOT NULL DEFAULT 0;
+
+-- Cópia do evento descartado: o original pode ser removido pela retenção
+CREATE TABLE IF NOT EXISTS outbox_dead_letters (
+    dead_letter_id  BIGSERIAL PRIMARY KEY,
+    consumer        TEXT NOT NULL,
+    event_id        BIGINT NOT NULL,
+    idempotency_key TEXT NOT NULL,
+    event_type      TEXT NOT NULL,
+    protocol_id     BIGINT NOT NULL,
+    payload         TEXT NOT NULL,
+    attempts        INTEGER NOT NULL,
+    last_error      TEXT NOT NULL,
+    parked_at       TIMESTAMPTZ NOT NULL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_outbox_dead_letters_consumer_event
+    ON outbox_dead_letters (consumer, event_id);
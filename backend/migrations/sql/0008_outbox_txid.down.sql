@@ -0,0 +1,3 @@
+ALTER TABLE outbox_cursors DROP COLUMN IF EXISTS last_txid;
+DROP INDEX IF EXISTS idx_outbox_events_position;
+ALTER TABLE outbox_events DROP COLUMN IF EXISTS txid;
@@ -0,0 +1,15 @@
+-- Sem trava global na gravação, event_ids podem ser confirmados fora de
+-- ordem. Os leitores passam a seguir a ordem (txid, event_id) e só leem
+-- eventos de transações anteriores ao xmin do snapshot atual, que já
+-- terminaram; assim nenhum evento confirmado depois fica para trás do
+-- cursor. Requer PostgreSQL 13 (xid8).
+ALTER TABLE outbox_events
+    ADD COLUMN IF NOT EXISTS txid xid8 NOT NULL DEFAULT pg_current_xact_id();
+CREATE INDEX IF NOT EXISTS idx_outbox_events_position
+    ON outbox_events (txid, event_id);
+
+-- Os eventos existentes recebem o txid desta migração; os cursores também,
+-- para continuarem do mesmo event_id
+ALTER TABLE outbox_cursors
+    ADD COLUMN IF NOT EXISTS last_txid xid8 NOT NULL DEFAULT '0';
+UPDATE outbox_cursors SET last_txid = pg_current_xact_id();
@@ -0,0 +1 @@
+ALTER TABLE protocol_reminders DROP COLUMN IF EXISTS lease_until;
@@ -0,0 +1,3 @@
+-- Reserva do lembrete pelo despachante: o envio acontece fora da transação
+-- e, enquanto lease_until não passar, outra réplica não pega o lembrete
+ALTER TABLE protocol_reminders ADD COLUMN IF NOT EXISTS lease_until TIMESTAMPTZ;
@@ -0,0 +1,4 @@
+-- Reserva da entrega pelo despachante: a requisição HTTP acontece fora da
+-- transação e, enquanto lease_until não passar, outra réplica não pega a
+-- entrega
+ALTER TABLE webhook_deliveries ADD COLUMN IF NOT EXISTS lease_until TIMESTAMPTZ;
@@ -0,0 +1,4 @@
+-- Reserva da versão pelo antivírus: a varredura no clamd acontece fora da
+-- transação e, enquanto scan_lease_until não passar, outra réplica não pega
+-- a versão
+ALTER TABLE protocol_attachment_versions ADD COLUMN IF NOT EXISTS scan_lease_until TIMESTAMPTZ;
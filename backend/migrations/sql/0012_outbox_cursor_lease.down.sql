@@ -0,0 +1 @@
+ALTER TABLE outbox_cursors DROP COLUMN IF EXISTS lease_until;
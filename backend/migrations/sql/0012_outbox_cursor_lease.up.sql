@@ -0,0 +1,5 @@
+-- Reserva do cursor pelo relay: os consumidores rodam fora de qualquer
+-- transação e, enquanto lease_until não passar, outra réplica não processa
+-- o mesmo consumidor. O valor também identifica a reserva ao avançar o
+-- cursor, para que uma réplica com a reserva vencida não o mova.
+ALTER TABLE outbox_cursors ADD COLUMN IF NOT EXISTS lease_until TIMESTAMPTZ;
@@ -0,0 +1,22 @@
+-- Fila de notificações por e-mail. O relay do outbox só grava aqui; o
+-- envio SMTP acontece no despachante de notificações, fora do relay, com
+-- reserva (lease_until), novas tentativas e espera exponencial. A chave
+-- do evento de origem descarta repetições do relay.
+CREATE TABLE IF NOT EXISTS notification_queue (
+    notification_id BIGSERIAL PRIMARY KEY,
+    idempotency_key TEXT NOT NULL,
+    event_type      TEXT NOT NULL,
+    protocol_id     BIGINT NOT NULL,
+    payload         TEXT NOT NULL,
+    status          TEXT NOT NULL DEFAULT 'pending',
+    attempts        INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ,
+    lease_until     TIMESTAMPTZ,
+    last_error      TEXT NOT NULL DEFAULT '',
+    created_at      TIMESTAMPTZ NOT NULL,
+    sent_at         TIMESTAMPTZ
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_notification_queue_idempotency_key
+    ON notification_queue (idempotency_key);
+CREATE INDEX IF NOT EXISTS idx_notification_queue_status
+    ON notification_queue (status);
@@ -0,0 +1 @@
+ALTER TABLE protocol_attachment_versions DROP COLUMN IF EXISTS thumbnail_lease_until;
@@ -0,0 +1,4 @@
+-- Reserva da versão pelo gerador de miniaturas: leitura do original,
+-- renderização e gravação acontecem fora da transação e, enquanto
+-- thumbnail_lease_until não passar, outra réplica não pega a versão
+ALTER TABLE protocol_attachment_versions ADD COLUMN IF NOT EXISTS thumbnail_lease_until TIMESTAMPTZ;
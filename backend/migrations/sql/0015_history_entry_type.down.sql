@@ -0,0 +1 @@
+ALTER TABLE protocol_history DROP COLUMN IF EXISTS entry_type;
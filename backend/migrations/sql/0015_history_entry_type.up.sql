@@ -0,0 +1,9 @@
+-- Tipo da entrada do histórico. Entradas automáticas (sla_breach) não têm
+-- autor: created_by fica nulo em vez de apontar para o responsável.
+ALTER TABLE protocol_history
+    ADD COLUMN IF NOT EXISTS entry_type TEXT NOT NULL DEFAULT 'status_change';
+
+-- Violações registradas antes desta migração eram atribuídas ao responsável
+UPDATE protocol_history SET entry_type = 'sla_breach', created_by = NULL
+WHERE notes LIKE 'SLA violado: prazo era %'
+    AND old_status_id = new_status_id;
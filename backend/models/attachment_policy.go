@@ -0,0 +1,76 @@
+// backend/models/attachment_policy.go
+package models
+
+import "strings"
+
+// AttachmentPolicy limita os anexos enviados para um protocolo
+type AttachmentPolicy struct {
+	// Tamanho máximo de cada arquivo em bytes; 0 não limita
+	MaxFileSize int64 `json:"max_file_size"`
+	// Soma máxima dos anexos de um protocolo em bytes; 0 não limita
+	ProtocolQuota int64 `json:"protocol_quota"`
+	// Tipos aceitos; vazio aceita qualquer tipo que não esteja em Denied
+	Allowed []string `json:"allowed"`
+	Denied  []string `json:"denied"`
+}
+
+// ParseMimeList lê uma lista de tipos separados por vírgula
+func ParseMimeList(list string) []string {
+	var types []string
+	for _, t := range strings.Split(list, ",") {
+		if t = strings.ToLower(strings.TrimSpace(t)); t != "" {
+			types = append(types, t)
+		}
+	}
+	return types
+}
+
+// IsValidMimePattern aceita "tipo/subtipo", "tipo/*" e "*/*"
+func IsValidMimePattern(pattern string) bool {
+	kind, subtype, ok := strings.Cut(pattern, "/")
+	if !ok || kind == "" || subtype == "" || strings.ContainsAny(pattern, " ;,") {
+		return false
+	}
+	return kind != "*" || subtype == "*"
+}
+
+// ForType aplica as listas do tipo de protocolo. Cada lista preenchida no
+// tipo substitui a global correspondente.
+func (p AttachmentPolicy) ForType(protocolType ProtocolType) AttachmentPolicy {
+	if allowed := ParseMimeList(protocolType.AllowedMimeTypes); allowed != nil {
+		p.Allowed = allowed
+	}
+	if denied := ParseMimeList(protocolType.DeniedMimeTypes); denied != nil {
+		p.Denied = denied
+	}
+	return p
+}
+
+// AllowsType informa se o tipo MIME pode ser anexado. A lista de negados
+// prevalece sobre a de aceitos.
+func (p AttachmentPolicy) AllowsType(contentType string) bool {
+	contentType = strings.ToLower(contentType)
+	for _, pattern := range p.Denied {
+		if matchMimeType(pattern, contentType) {
+			return false
+		}
+	}
+	if len(p.Allowed) == 0 {
+		return true
+	}
+	for _, pattern := range p.Allowed {
+		if matchMimeType(pattern, contentType) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchMimeType aceita o tipo exato, "tipo/*" e "*/*"
+func matchMimeType(pattern, contentType string) bool {
+	if pattern == "*/*" || pattern == contentType {
+		return true
+	}
+	prefix, ok := strings.CutSuffix(pattern, "/*")
+	return ok && strings.HasPrefix(contentType, prefix+"/")
+}
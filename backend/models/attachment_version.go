@@ -0,0 +1,62 @@
+// backend/models/attachment_version.go
+package models
+
+import "time"
+
+// AttachmentVersion é uma versão do conteúdo de um anexo. Enviar um
+// documento corrigido cria uma nova versão em vez de apagar o anexo; a
+// linha de ProtocolAttachment espelha sempre a versão atual.
+type AttachmentVersion struct {
+	VersionID    int    `json:"version_id" gorm:"primaryKey;column:version_id"`
+	AttachmentID int    `json:"attachment_id" gorm:"column:attachment_id;not null;uniqueIndex:idx_attachment_version"`
+	Version      int    `json:"version" gorm:"column:version;not null;uniqueIndex:idx_attachment_version"`
+	FileName     string `json:"file_name" gorm:"column:file_name;not null"`
+	// Chave no armazenamento; o download é feito pela API
+	FilePath     string     `json:"-" gorm:"column:file_path;not null"`
+	FileSize     int64      `json:"file_size" gorm:"column:file_size"`
+	ContentType  string     `json:"content_type" gorm:"column:content_type"`
+	UploadedBy   int        `json:"uploaded_by" gorm:"column:uploaded_by;not null"`
+	UploadedAt   time.Time  `json:"uploaded_at" gorm:"column:uploaded_at"`
+	Checksum     string     `json:"checksum" gorm:"column:checksum"`
+	ScanStatus   string     `json:"scan_status" gorm:"column:scan_status;not null;default:pending;index"`
+	ScanResult   string     `json:"scan_result" gorm:"column:scan_result"`
+	ScanAttempts int        `json:"scan_attempts" gorm:"column:scan_attempts;default:0"`
+	NextScanAt   *time.Time `json:"-" gorm:"column:next_scan_at"`
+	ScannedAt    *time.Time `json:"scanned_at" gorm:"column:scanned_at"`
+	// Reservada para varredura por um processo até este instante
+	ScanLeaseUntil *time.Time `json:"-" gorm:"column:scan_lease_until"`
+	// Miniaturas só são geradas para versões já verificadas
+	ThumbnailStatus string `json:"thumbnail_status" gorm:"column:thumbnail_status;not null;default:pending;index"`
+	ThumbnailPath   string `json:"-" gorm:"column:thumbnail_path"`
+	// Reservada pelo gerador de miniaturas até este instante
+	ThumbnailLeaseUntil *time.Time `json:"-" gorm:"column:thumbnail_lease_until"`
+	ThumbnailURL        string     `json:"thumbnail_url,omitempty" gorm:"-"`
+	PreviewURL          string     `json:"preview_url,omitempty" gorm:"-"`
+
+	UploadedByAgent SalesPersonnel `json:"uploaded_by_agent" gorm:"foreignKey:UploadedBy;references:PersonnelID"`
+}
+
+func (AttachmentVersion) TableName() string {
+	return "protocol_attachment_versions"
+}
+
+// NewAttachmentVersion copia o conteúdo atual do anexo para uma versão
+func NewAttachmentVersion(a ProtocolAttachment) AttachmentVersion {
+	return AttachmentVersion{
+		AttachmentID: a.AttachmentID,
+		Version:      a.Version,
+		FileName:     a.FileName,
+		FilePath:     a.FilePath,
+		FileSize:     a.FileSize,
+		ContentType:  a.ContentType,
+		UploadedBy:   a.UploadedBy,
+		UploadedAt:   a.UploadedAt,
+		Checksum:     a.Checksum,
+		ScanStatus:   a.ScanStatus,
+		ScanResult:   a.ScanResult,
+		ScannedAt:    a.ScannedAt,
+
+		ThumbnailStatus: a.ThumbnailStatus,
+		ThumbnailPath:   a.ThumbnailPath,
+	}
+}
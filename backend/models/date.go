@@ -0,0 +1,96 @@
+// backend/models/date.go
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// DateLayout é o formato de Date no JSON e no banco
+const DateLayout = "2006-01-02"
+
+// Date é um dia sem horário, para colunas do tipo date. No JSON é
+// "YYYY-MM-DD"; timestamps RFC 3339 também são aceitos e só o dia é usado.
+type Date struct {
+	time.Time
+}
+
+// NewDate devolve o dia de t, à meia-noite em UTC
+func NewDate(t time.Time) Date {
+	return Date{time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)}
+}
+
+// ParseDate lê "YYYY-MM-DD" ou um timestamp RFC 3339
+func ParseDate(s string) (Date, error) {
+	if t, err := time.Parse(DateLayout, s); err == nil {
+		return Date{t}, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return Date{}, fmt.Errorf("invalid date %q, use YYYY-MM-DD", s)
+	}
+	return NewDate(t), nil
+}
+
+func (d Date) String() string {
+	return d.Format(DateLayout)
+}
+
+func (d Date) MarshalJSON() ([]byte, error) {
+	if d.IsZero() {
+		return []byte("null"), nil
+	}
+	return json.Marshal(d.String())
+}
+
+func (d *Date) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*d = Date{}
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("invalid date %s, use YYYY-MM-DD", data)
+	}
+	parsed, err := ParseDate(s)
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+// Value grava só o dia, sem fuso que pudesse mudar a data no banco
+func (d Date) Value() (driver.Value, error) {
+	if d.IsZero() {
+		return nil, nil
+	}
+	return d.String(), nil
+}
+
+func (d *Date) Scan(value any) error {
+	switch v := value.(type) {
+	case nil:
+		*d = Date{}
+	case time.Time:
+		*d = NewDate(v)
+	case string:
+		return d.scanString(v)
+	case []byte:
+		return d.scanString(string(v))
+	default:
+		return fmt.Errorf("cannot scan %T into Date", value)
+	}
+	return nil
+}
+
+func (d *Date) scanString(s string) error {
+	parsed, err := ParseDate(s)
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
@@ -0,0 +1,55 @@
+// backend/models/date_test.go
+package models
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestHolidayDateJSON(t *testing.T) {
+	for _, body := range []string{
+		`{"name":"Natal","date":"2026-12-25"}`,
+		`{"name":"Natal","date":"2026-12-25T00:00:00Z"}`,
+		`{"name":"Natal","date":"2026-12-25T22:00:00-03:00"}`,
+	} {
+		var holiday Holiday
+		if err := json.Unmarshal([]byte(body), &holiday); err != nil {
+			t.Fatalf("%s: %v", body, err)
+		}
+		if got := holiday.Date.String(); got != "2026-12-25" {
+			t.Errorf("%s: got %s, want 2026-12-25", body, got)
+		}
+
+		out, err := json.Marshal(holiday)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var back struct {
+			Date string `json:"date"`
+		}
+		json.Unmarshal(out, &back)
+		if back.Date != "2026-12-25" {
+			t.Errorf("%s: marshalled date %q", body, back.Date)
+		}
+	}
+
+	var holiday Holiday
+	if err := json.Unmarshal([]byte(`{"date":"25/12/2026"}`), &holiday); err == nil {
+		t.Error("expected an error for a non-ISO date")
+	}
+}
+
+func TestDateScan(t *testing.T) {
+	var d Date
+	if err := d.Scan(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)); err != nil || d.String() != "2026-01-01" {
+		t.Errorf("time.Time: %v %s", err, d)
+	}
+	if err := d.Scan([]byte("2026-02-03")); err != nil || d.String() != "2026-02-03" {
+		t.Errorf("[]byte: %v %s", err, d)
+	}
+	value, err := d.Value()
+	if err != nil || value != "2026-02-03" {
+		t.Errorf("Value: %v %v", value, err)
+	}
+}
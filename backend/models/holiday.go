@@ -0,0 +1,18 @@
+// backend/models/holiday.go
+package models
+
+import "time"
+
+// Holiday é um dia sem expediente, ignorado na contagem de prazos em dias
+// úteis. Sem BranchID o feriado vale para todas as filiais.
+type Holiday struct {
+	HolidayID int       `json:"holiday_id" gorm:"primaryKey;column:holiday_id"`
+	Date      Date      `json:"date" gorm:"column:date;type:date;not null;index"`
+	Name      string    `json:"name" gorm:"column:name;not null"`
+	BranchID  *int      `json:"branch_id" gorm:"column:branch_id"`
+	CreatedAt time.Time `json:"created_at" gorm:"column:created_at;autoCreateTime"`
+}
+
+func (Holiday) TableName() string {
+	return "holidays"
+}
@@ -0,0 +1,37 @@
+// backend/models/notification.go
+package models
+
+import "time"
+
+// Situação de uma notificação na fila
+const (
+	NotificationPending = "pending"
+	NotificationSent    = "sent"
+	// Esgotou as tentativas
+	NotificationDead = "dead"
+)
+
+// Notification é uma notificação por e-mail derivada de um evento do
+// outbox. O relay só a grava; o envio acontece no despachante de
+// notificações.
+type Notification struct {
+	NotificationID int64 `json:"notification_id" gorm:"primaryKey;column:notification_id;autoIncrement"`
+	// Chave do evento de origem; descarta repetições e identifica a
+	// mensagem enviada
+	IdempotencyKey string     `json:"idempotency_key" gorm:"column:idempotency_key;not null;uniqueIndex:idx_notification_queue_idempotency_key"`
+	EventType      string     `json:"event_type" gorm:"column:event_type;not null"`
+	ProtocolID     int        `json:"protocol_id" gorm:"column:protocol_id;not null"`
+	Payload        string     `json:"payload" gorm:"column:payload;type:text;not null"`
+	Status         string     `json:"status" gorm:"column:status;not null;default:pending;index:idx_notification_queue_status"`
+	Attempts       int        `json:"attempts" gorm:"column:attempts;not null;default:0"`
+	NextAttemptAt  *time.Time `json:"next_attempt_at" gorm:"column:next_attempt_at"`
+	LastError      string     `json:"last_error" gorm:"column:last_error;not null;default:''"`
+	CreatedAt      time.Time  `json:"created_at" gorm:"column:created_at;not null"`
+	SentAt         *time.Time `json:"sent_at" gorm:"column:sent_at"`
+	// Reservada por um despachante até este instante
+	LeaseUntil *time.Time `json:"-" gorm:"column:lease_until"`
+}
+
+func (Notification) TableName() string {
+	return "notification_queue"
+}
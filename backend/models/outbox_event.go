@@ -0,0 +1,263 @@
+// backend/models/outbox_event.go
+package models
+
+import "time"
+
+// Eventos de domínio gravados no outbox e publicados para os webhooks
+const (
+	EventProtocolCreated          = "protocol.created"
+	EventProtocolUpdated          = "protocol.updated"
+	EventProtocolStatusChanged    = "protocol.status_changed"
+	EventProtocolDeleted          = "protocol.deleted"
+	EventProtocolSLABreached      = "protocol.sla_breached"
+	EventHistoryCreated           = "history.created"
+	EventAttachmentAdded          = "attachment.added"
+	EventAttachmentDeleted        = "attachment.deleted"
+	EventAttachmentScanned        = "attachment.scanned"
+	EventAttachmentVersionAdded   = "attachment.version_added"
+	EventAttachmentThumbnailReady = "attachment.thumbnail_ready"
+	EventReminderCreated          = "reminder.created"
+	EventReminderUpdated          = "reminder.updated"
+	EventReminderDeleted          = "reminder.deleted"
+)
+
+// IsValidEvent informa se o nome corresponde a um evento publicado
+func IsValidEvent(event string) bool {
+	switch event {
+	case EventProtocolCreated, EventProtocolUpdated,
+		EventProtocolStatusChanged, EventProtocolDeleted,
+		EventProtocolSLABreached, EventHistoryCreated,
+		EventAttachmentAdded, EventAttachmentDeleted, EventAttachmentScanned,
+		EventAttachmentVersionAdded, EventAttachmentThumbnailReady,
+		EventReminderCreated, EventReminderUpdated, EventReminderDeleted:
+		return true
+	}
+	return false
+}
+
+// OutboxEvent é um evento de domínio gravado na mesma transação da
+// alteração que o originou. O relay entrega os eventos aos consumidores na
+// ordem de Position.
+type OutboxEvent struct {
+	EventID int64 `json:"event_id" gorm:"primaryKey;column:event_id;autoIncrement"`
+	// Transação que gravou o evento, preenchida pelo banco
+	TxID int64 `json:"-" gorm:"column:txid;->"`
+	// Chave única do evento; consumidores a usam para descartar repetições
+	IdempotencyKey string `json:"idempotency_key" gorm:"column:idempotency_key;not null;uniqueIndex"`
+	EventType      string `json:"event_type" gorm:"column:event_type;not null"`
+	// Todos os eventos atuais pertencem a um protocolo
+	ProtocolID int `json:"protocol_id" gorm:"column:protocol_id;not null;index"`
+	// Filial do protocolo, para filtrar eventos pelo escopo do usuário
+	BranchID   *int      `json:"branch_id" gorm:"column:branch_id"`
+	Payload    string    `json:"payload" gorm:"column:payload;type:text;not null"`
+	OccurredAt time.Time `json:"occurred_at" gorm:"column:occurred_at;not null;index"`
+}
+
+func (OutboxEvent) TableName() string {
+	return "outbox_events"
+}
+
+// Position devolve a posição do evento na ordem de leitura do outbox
+func (e OutboxEvent) Position() OutboxPosition {
+	return OutboxPosition{TxID: e.TxID, EventID: e.EventID}
+}
+
+// OutboxPosition ordena os eventos pela transação que os gravou e, dentro
+// dela, pelo event_id. Ao contrário do event_id sozinho, essa ordem não
+// muda depois que as transações terminam, mesmo confirmadas fora de ordem.
+type OutboxPosition struct {
+	TxID    int64
+	EventID int64
+}
+
+// Before informa se p vem antes de other
+func (p OutboxPosition) Before(other OutboxPosition) bool {
+	if p.TxID != other.TxID {
+		return p.TxID < other.TxID
+	}
+	return p.EventID < other.EventID
+}
+
+// OutboxCursor guarda até onde cada consumidor já processou o outbox
+type OutboxCursor struct {
+	Consumer    string     `json:"consumer" gorm:"primaryKey;column:consumer"`
+	LastEventID int64      `json:"last_event_id" gorm:"column:last_event_id;not null;default:0"`
+	LastTxID    int64      `json:"-" gorm:"column:last_txid;not null;default:0"`
+	LastError   string     `json:"last_error" gorm:"column:last_error"`
+	LastErrorAt *time.Time `json:"last_error_at" gorm:"column:last_error_at"`
+	// Evento em que o consumidor está parado e quantas vezes já falhou
+	FailedEventID int64 `json:"failed_event_id" gorm:"column:failed_event_id;not null;default:0"`
+	Attempts      int   `json:"attempts" gorm:"column:attempts;not null;default:0"`
+	// Reserva do relay que está processando o consumidor
+	LeaseUntil *time.Time `json:"-" gorm:"column:lease_until"`
+	UpdatedAt  time.Time  `json:"updated_at" gorm:"column:updated_at;autoUpdateTime"`
+}
+
+func (OutboxCursor) TableName() string {
+	return "outbox_cursors"
+}
+
+// Position devolve a posição do último evento processado
+func (c OutboxCursor) Position() OutboxPosition {
+	return OutboxPosition{TxID: c.LastTxID, EventID: c.LastEventID}
+}
+
+// OutboxDeadLetter guarda um evento que um consumidor desistiu de processar.
+// O evento é copiado porque o original sai do outbox com a retenção.
+type OutboxDeadLetter struct {
+	DeadLetterID   int64     `json:"dead_letter_id" gorm:"primaryKey;column:dead_letter_id;autoIncrement"`
+	Consumer       string    `json:"consumer" gorm:"column:consumer;not null;uniqueIndex:idx_outbox_dead_letters_consumer_event"`
+	EventID        int64     `json:"event_id" gorm:"column:event_id;not null;uniqueIndex:idx_outbox_dead_letters_consumer_event"`
+	IdempotencyKey string    `json:"idempotency_key" gorm:"column:idempotency_key;not null"`
+	EventType      string    `json:"event_type" gorm:"column:event_type;not null"`
+	ProtocolID     int       `json:"protocol_id" gorm:"column:protocol_id;not null"`
+	Payload        string    `json:"payload" gorm:"column:payload;type:text;not null"`
+	Attempts       int       `json:"attempts" gorm:"column:attempts;not null"`
+	LastError      string    `json:"last_error" gorm:"column:last_error;not null"`
+	ParkedAt       time.Time `json:"parked_at" gorm:"column:parked_at;not null"`
+}
+
+func (OutboxDeadLetter) TableName() string {
+	return "outbox_dead_letters"
+}
+
+// ProtocolEventData são os campos do protocolo publicados nos eventos
+// protocol.*, sem as associações
+type ProtocolEventData struct {
+	ProtocolID     int        `json:"protocol_id"`
+	ProtocolNumber string     `json:"protocol_number"`
+	Title          string     `json:"title"`
+	TypeID         int        `json:"type_id"`
+	StatusID       int        `json:"status_id"`
+	CustomerID     int        `json:"customer_id"`
+	BranchID       *int       `json:"branch_id"`
+	AssignedTo     int        `json:"assigned_to"`
+	Priority       string     `json:"priority"`
+	Deadline       *time.Time `json:"deadline"`
+	ClosedAt       *time.Time `json:"closed_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+
+	// Preenchidos em protocol.updated e protocol.status_changed
+	ChangedFields      []string `json:"changed_fields,omitempty"`
+	PreviousStatusID   *int     `json:"previous_status_id,omitempty"`
+	PreviousAssignedTo *int     `json:"previous_assigned_to,omitempty"`
+	Notes              string   `json:"notes,omitempty"`
+}
+
+func NewProtocolEventData(p Protocol) ProtocolEventData {
+	return ProtocolEventData{
+		ProtocolID:     p.ProtocolID,
+		ProtocolNumber: p.ProtocolNumber,
+		Title:          p.Title,
+		TypeID:         p.TypeID,
+		StatusID:       p.StatusID,
+		CustomerID:     p.CustomerID,
+		BranchID:       p.BranchID,
+		AssignedTo:     p.AssignedTo,
+		Priority:       p.Priority,
+		Deadline:       p.Deadline,
+		ClosedAt:       p.ClosedAt,
+		UpdatedAt:      p.UpdatedAt,
+	}
+}
+
+// AttachmentEventData omite o caminho interno do arquivo
+type AttachmentEventData struct {
+	AttachmentID int       `json:"attachment_id"`
+	ProtocolID   int       `json:"protocol_id"`
+	FileName     string    `json:"file_name"`
+	FileSize     int64     `json:"file_size"`
+	ContentType  string    `json:"content_type"`
+	Description  string    `json:"description"`
+	UploadedBy   int       `json:"uploaded_by"`
+	UploadedAt   time.Time `json:"uploaded_at"`
+	Checksum     string    `json:"checksum"`
+	Version      int       `json:"version"`
+	ScanStatus   string    `json:"scan_status"`
+	ScanResult   string    `json:"scan_result"`
+}
+
+func NewAttachmentEventData(a ProtocolAttachment) AttachmentEventData {
+	return AttachmentEventData{
+		AttachmentID: a.AttachmentID,
+		ProtocolID:   a.ProtocolID,
+		FileName:     a.FileName,
+		FileSize:     a.FileSize,
+		ContentType:  a.ContentType,
+		Description:  a.Description,
+		UploadedBy:   a.UploadedBy,
+		UploadedAt:   a.UploadedAt,
+		Checksum:     a.Checksum,
+		Version:      a.Version,
+		ScanStatus:   a.ScanStatus,
+		ScanResult:   a.ScanResult,
+	}
+}
+
+// NewAttachmentVersionEventData descreve uma versão específica do anexo,
+// que pode não ser a atual
+func NewAttachmentVersionEventData(
+	a ProtocolAttachment, v AttachmentVersion,
+) AttachmentEventData {
+	data := NewAttachmentEventData(a)
+	data.FileName = v.FileName
+	data.FileSize = v.FileSize
+	data.ContentType = v.ContentType
+	data.UploadedBy = v.UploadedBy
+	data.UploadedAt = v.UploadedAt
+	data.Checksum = v.Checksum
+	data.Version = v.Version
+	data.ScanStatus = v.ScanStatus
+	data.ScanResult = v.ScanResult
+	return data
+}
+
+// HistoryEventData é publicado em history.created
+type HistoryEventData struct {
+	ProtocolHistoryID int       `json:"protocol_history_id"`
+	ProtocolID        int       `json:"protocol_id"`
+	OldStatusID       *int      `json:"previous_status_id"`
+	NewStatusID       int       `json:"new_status_id"`
+	Notes             string    `json:"notes"`
+	EntryType         string    `json:"entry_type"`
+	CreatedBy         *int      `json:"created_by"`
+	CreatedAt         time.Time `json:"created_at"`
+}
+
+func NewHistoryEventData(h ProtocolHistory) HistoryEventData {
+	return HistoryEventData{
+		ProtocolHistoryID: h.ProtocolHistoryID,
+		ProtocolID:        h.ProtocolID,
+		OldStatusID:       h.OldStatusID,
+		NewStatusID:       h.NewStatusID,
+		Notes:             h.Notes,
+		EntryType:         h.EntryType,
+		CreatedBy:         h.CreatedBy,
+		CreatedAt:         h.CreatedAt,
+	}
+}
+
+// ReminderEventData é publicado nos eventos reminder.*
+type ReminderEventData struct {
+	ReminderID   int        `json:"reminder_id"`
+	ProtocolID   int        `json:"protocol_id"`
+	ReminderText string     `json:"reminder_text"`
+	ReminderDate time.Time  `json:"reminder_date"`
+	IsCompleted  bool       `json:"is_completed"`
+	IsSent       bool       `json:"is_sent"`
+	SentAt       *time.Time `json:"sent_at"`
+	CreatedBy    int        `json:"created_by"`
+}
+
+func NewReminderEventData(r ProtocolReminder) ReminderEventData {
+	return ReminderEventData{
+		ReminderID:   r.ReminderID,
+		ProtocolID:   r.ProtocolID,
+		ReminderText: r.ReminderText,
+		ReminderDate: r.ReminderDate,
+		IsCompleted:  r.IsCompleted,
+		IsSent:       r.IsSent,
+		SentAt:       r.SentAt,
+		CreatedBy:    r.CreatedBy,
+	}
+}
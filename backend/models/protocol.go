@@ -5,7 +5,7 @@ import "time"
 
 type Protocol struct {
 	ProtocolID         int        `json:"protocol_id" gorm:"primaryKey;column:protocol_id"`
-	ProtocolNumber     string     `json:"protocol_number" gorm:"column:protocol_number"`
+	ProtocolNumber     string     `json:"protocol_number" gorm:"column:protocol_number;uniqueIndex"`
 	Title              string     `json:"title" gorm:"column:title;not null"`
 	Description        string     `json:"description" gorm:"column:description"`
 	TypeID             int        `json:"type_id" gorm:"column:type_id;not null"`
@@ -22,6 +22,8 @@ type Protocol struct {
 	CreatedAt          time.Time  `json:"created_at" gorm:"column:created_at"`
 	UpdatedAt          time.Time  `json:"updated_at" gorm:"column:updated_at"`
 	ClosedAt           *time.Time `json:"closed_at" gorm:"column:closed_at"`
+	SLABreachedAt      *time.Time `json:"sla_breached_at" gorm:"column:sla_breached_at"`
+	SLAState           string     `json:"sla_state" gorm:"-"`
 	// Define relationships for proper preloading
 	// In Protocol model
 	Type           ProtocolType   `json:"type" gorm:"foreignKey:TypeID;references:TypeID"`
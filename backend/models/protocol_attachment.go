@@ -5,6 +5,21 @@ import (
 	"time"
 )
 
+// Situação da verificação antivírus de um anexo
+const (
+	ScanPending  = "pending"
+	ScanClean    = "clean"
+	ScanInfected = "infected"
+)
+
+// Situação da miniatura de um anexo. Unavailable cobre tanto os tipos sem
+// prévia quanto os arquivos que não puderam ser renderizados.
+const (
+	ThumbnailPending     = "pending"
+	ThumbnailReady       = "ready"
+	ThumbnailUnavailable = "unavailable"
+)
+
 type ProtocolAttachment struct {
 	AttachmentID int       `json:"attachment_id" gorm:"primaryKey;column:attachment_id"`
 	ProtocolID   int       `json:"protocol_id" gorm:"column:protocol_id;not null"`
@@ -15,6 +30,24 @@ type ProtocolAttachment struct {
 	Description  string    `json:"description" gorm:"column:description"`
 	UploadedBy   int       `json:"uploaded_by" gorm:"column:uploaded_by;not null"`
 	UploadedAt   time.Time `json:"uploaded_at" gorm:"column:uploaded_at"`
+	// SHA-256 em hexadecimal, calculado no upload e conferido no download;
+	// vazio para anexos enviados antes da verificação de integridade
+	Checksum string `json:"checksum" gorm:"column:checksum"`
+	// Número da versão atual; as anteriores ficam em AttachmentVersion
+	Version int `json:"version" gorm:"column:version;not null;default:1"`
+	// Só anexos clean podem ser baixados. ScanResult traz a assinatura
+	// encontrada ou o motivo de uma liberação sem varredura. A varredura é
+	// feita por versão; estes campos espelham a atual.
+	ScanStatus string     `json:"scan_status" gorm:"column:scan_status;not null;default:pending;index"`
+	ScanResult string     `json:"scan_result" gorm:"column:scan_result"`
+	ScannedAt  *time.Time `json:"scanned_at" gorm:"column:scanned_at"`
+	// Miniatura da versão atual, gerada depois da varredura
+	ThumbnailStatus string `json:"thumbnail_status" gorm:"column:thumbnail_status;not null;default:pending"`
+	ThumbnailPath   string `json:"-" gorm:"column:thumbnail_path"`
+	// Preenchidos pela API: a miniatura quando pronta e a visualização
+	// no navegador quando o tipo permite
+	ThumbnailURL string `json:"thumbnail_url,omitempty" gorm:"-"`
+	PreviewURL   string `json:"preview_url,omitempty" gorm:"-"`
 
 	UploadedByAgent SalesPersonnel `json:"uploaded_by_agent" gorm:"foreignKey:UploadedBy;references:PersonnelID"`
 }
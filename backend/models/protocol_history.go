@@ -5,14 +5,23 @@ import (
 	"time"
 )
 
+// Tipos de entrada do histórico
+const (
+	HistoryStatusChange = "status_change"
+	// Registrada pelo monitor de SLA, sem autor
+	HistorySLABreach = "sla_breach"
+)
+
 type ProtocolHistory struct {
-	ProtocolHistoryID int       `json:"protocol_history_id"`
-	ProtocolID        int       `json:"protocol_id"`
-	OldStatusID       *int      `json:"previous_status_id"`
-	NewStatusID       int       `json:"new_status_id"`
-	Notes             string    `json:"notes"`
-	CreatedBy         int       `json:"created_by"`
-	CreatedAt         time.Time `json:"created_at"`
+	ProtocolHistoryID int    `json:"protocol_history_id"`
+	ProtocolID        int    `json:"protocol_id"`
+	OldStatusID       *int   `json:"previous_status_id"`
+	NewStatusID       int    `json:"new_status_id"`
+	Notes             string `json:"notes"`
+	EntryType         string `json:"entry_type" gorm:"column:entry_type;not null;default:status_change"`
+	// Nulo nas entradas automáticas
+	CreatedBy *int      `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
 
 	PreviousStatus *ProtocolStatus `gorm:"foreignKey:OldStatusID;references:StatusID" json:"previous_status,omitempty"`
 	NewStatus      *ProtocolStatus `gorm:"foreignKey:NewStatusID;references:StatusID" json:"new_status,omitempty"`
@@ -16,6 +16,15 @@ type ProtocolReminder struct {
 	CreatedBy       int       `json:"created_by" gorm:"column:created_by;not null"`
 	CreatedAt       time.Time `json:"created_at" gorm:"column:created_at"`
 
+	// Controle de entrega pelo despachante de lembretes
+	SentAt        *time.Time `json:"sent_at" gorm:"column:sent_at"`
+	Attempts      int        `json:"attempts" gorm:"column:attempts;default:0"`
+	NextAttemptAt *time.Time `json:"next_attempt_at" gorm:"column:next_attempt_at"`
+	LastError     string     `json:"last_error" gorm:"column:last_error"`
+	FailedAt      *time.Time `json:"failed_at" gorm:"column:failed_at"`
+	// Reservado por um despachante até este instante
+	LeaseUntil *time.Time `json:"-" gorm:"column:lease_until"`
+
 	// Define relationship properly
 	CreatedByAgent SalesPersonnel `json:"created_by_agent" gorm:"foreignKey:CreatedBy;references:PersonnelID"`
 }
@@ -0,0 +1,14 @@
+// backend/models/protocol_sequence.go
+package models
+
+// ProtocolSequence guarda o último número emitido por ano e por escopo de
+// numeração (global, filial e/ou tipo, conforme o formato configurado)
+type ProtocolSequence struct {
+	ScopeKey  string `json:"scope_key" gorm:"primaryKey;column:scope_key"`
+	Year      int    `json:"year" gorm:"primaryKey;column:year;autoIncrement:false"`
+	LastValue int    `json:"last_value" gorm:"column:last_value;not null"`
+}
+
+func (ProtocolSequence) TableName() string {
+	return "protocol_sequences"
+}
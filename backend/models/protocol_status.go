@@ -6,6 +6,8 @@ type ProtocolStatus struct {
 	StatusName string `json:"status_name"`
 	Color      string `json:"color"`
 	IsTerminal bool   `json:"is_terminal"`
+	// Posição na listagem; novos status entram no fim
+	OrderSequence int `json:"order_sequence" gorm:"column:order_sequence;not null;default:0"`
 }
 
 func (ProtocolStatus) TableName() string {
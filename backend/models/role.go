@@ -0,0 +1,20 @@
+// models/role.go
+package models
+
+// Papéis aceitos em User.Role
+const (
+	RoleAdmin         = "admin"
+	RoleBranchManager = "branch_manager"
+	RoleAgent         = "agent"
+	RoleReadOnly      = "read_only"
+)
+
+// IsValidRole informa se o papel pertence ao conjunto definido
+func IsValidRole(role string) bool {
+	switch role {
+	case RoleAdmin, RoleBranchManager, RoleAgent, RoleReadOnly:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,34 @@
+// backend/models/sla.go
+package models
+
+import "time"
+
+// Estados de SLA de um protocolo
+const (
+	SLAOnTrack  = "on_track"
+	SLAAtRisk   = "at_risk"
+	SLABreached = "breached"
+)
+
+// SLAStateAt calcula o estado de SLA no instante informado. Protocolos sem
+// prazo estão sempre em dia; protocolos encerrados são avaliados pela data
+// de encerramento. atRiskWindow é quanto antes do prazo um protocolo aberto
+// passa a ser considerado em risco.
+func (p Protocol) SLAStateAt(now time.Time, atRiskWindow time.Duration) string {
+	if p.Deadline == nil {
+		return SLAOnTrack
+	}
+	if p.ClosedAt != nil {
+		if p.ClosedAt.After(*p.Deadline) {
+			return SLABreached
+		}
+		return SLAOnTrack
+	}
+	if now.After(*p.Deadline) {
+		return SLABreached
+	}
+	if now.Add(atRiskWindow).After(*p.Deadline) {
+		return SLAAtRisk
+	}
+	return SLAOnTrack
+}
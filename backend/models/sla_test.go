@@ -0,0 +1,23 @@
+// backend/models/sla_test.go
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSLAStateAtUsesTheWindow(t *testing.T) {
+	now := time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)
+	deadline := now.Add(6 * time.Hour)
+	p := Protocol{Deadline: &deadline}
+
+	if got := p.SLAStateAt(now, 24*time.Hour); got != SLAAtRisk {
+		t.Errorf("24h window: got %s, want %s", got, SLAAtRisk)
+	}
+	if got := p.SLAStateAt(now, time.Hour); got != SLAOnTrack {
+		t.Errorf("1h window: got %s, want %s", got, SLAOnTrack)
+	}
+	if got := p.SLAStateAt(now.Add(7*time.Hour), time.Hour); got != SLABreached {
+		t.Errorf("after the deadline: got %s, want %s", got, SLABreached)
+	}
+}
@@ -0,0 +1,54 @@
+// backend/models/status_transition.go
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// StatusTransition define uma mudança de status permitida para um tipo de
+// protocolo. Quando um tipo não tem transições cadastradas, qualquer
+// mudança é aceita.
+type StatusTransition struct {
+	TransitionID int `json:"transition_id" gorm:"primaryKey;column:transition_id"`
+	TypeID       int `json:"type_id" gorm:"column:type_id;not null;uniqueIndex:idx_status_transition"`
+	FromStatusID int `json:"from_status_id" gorm:"column:from_status_id;not null;uniqueIndex:idx_status_transition"`
+	ToStatusID   int `json:"to_status_id" gorm:"column:to_status_id;not null;uniqueIndex:idx_status_transition"`
+	// Papéis separados por vírgula; vazio libera para qualquer papel
+	AllowedRoles string    `json:"allowed_roles" gorm:"column:allowed_roles"`
+	RequiresNote bool      `json:"requires_note" gorm:"column:requires_note;default:false"`
+	CreatedAt    time.Time `json:"created_at" gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt    time.Time `json:"updated_at" gorm:"column:updated_at;autoUpdateTime"`
+
+	FromStatus ProtocolStatus `json:"from_status" gorm:"foreignKey:FromStatusID;references:StatusID"`
+	ToStatus   ProtocolStatus `json:"to_status" gorm:"foreignKey:ToStatusID;references:StatusID"`
+}
+
+func (StatusTransition) TableName() string {
+	return "protocol_status_transitions"
+}
+
+// Roles retorna a lista de papéis de AllowedRoles
+func (t StatusTransition) Roles() []string {
+	var roles []string
+	for _, role := range strings.Split(t.AllowedRoles, ",") {
+		if role = strings.TrimSpace(role); role != "" {
+			roles = append(roles, role)
+		}
+	}
+	return roles
+}
+
+// AllowsRole informa se o papel pode executar a transição
+func (t StatusTransition) AllowsRole(role string) bool {
+	roles := t.Roles()
+	if len(roles) == 0 {
+		return true
+	}
+	for _, r := range roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
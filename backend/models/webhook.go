@@ -0,0 +1,83 @@
+// backend/models/webhook.go
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// Situação de uma entrega de webhook
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	// Esgotou as tentativas; só volta a ser enviada se reenfileirada
+	DeliveryDead = "dead"
+)
+
+// WebhookSubscription cadastra uma URL que recebe eventos de protocolo
+type WebhookSubscription struct {
+	SubscriptionID int    `json:"subscription_id" gorm:"primaryKey;column:subscription_id"`
+	URL            string `json:"url" gorm:"column:url;not null"`
+	// O segredo só é informado na criação e nunca é devolvido
+	Secret string `json:"-" gorm:"column:secret;not null"`
+	// Eventos separados por vírgula; vazio recebe todos
+	Events    string    `json:"events" gorm:"column:events"`
+	Active    bool      `json:"active" gorm:"column:active;default:true"`
+	CreatedAt time.Time `json:"created_at" gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"column:updated_at;autoUpdateTime"`
+}
+
+func (WebhookSubscription) TableName() string {
+	return "webhook_subscriptions"
+}
+
+// EventList retorna a lista de eventos de Events
+func (s WebhookSubscription) EventList() []string {
+	var events []string
+	for _, event := range strings.Split(s.Events, ",") {
+		if event = strings.TrimSpace(event); event != "" {
+			events = append(events, event)
+		}
+	}
+	return events
+}
+
+// Matches informa se a assinatura recebe o evento
+func (s WebhookSubscription) Matches(event string) bool {
+	events := s.EventList()
+	if len(events) == 0 {
+		return true
+	}
+	for _, e := range events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDelivery é uma tentativa de entrega de um evento a uma assinatura.
+// O Payload é gravado pronto para que o conteúdo assinado seja sempre o
+// mesmo entre as tentativas.
+type WebhookDelivery struct {
+	DeliveryID     int        `json:"delivery_id" gorm:"primaryKey;column:delivery_id"`
+	SubscriptionID int        `json:"subscription_id" gorm:"column:subscription_id;not null;uniqueIndex:idx_webhook_delivery_event"`
+	EventID        string     `json:"event_id" gorm:"column:event_id;not null;uniqueIndex:idx_webhook_delivery_event"`
+	Event          string     `json:"event" gorm:"column:event;not null"`
+	Payload        string     `json:"payload" gorm:"column:payload;type:text;not null"`
+	Status         string     `json:"status" gorm:"column:status;not null;default:pending;index"`
+	Attempts       int        `json:"attempts" gorm:"column:attempts;default:0"`
+	NextAttemptAt  *time.Time `json:"next_attempt_at" gorm:"column:next_attempt_at"`
+	LastError      string     `json:"last_error" gorm:"column:last_error"`
+	ResponseStatus int        `json:"response_status" gorm:"column:response_status"`
+	CreatedAt      time.Time  `json:"created_at" gorm:"column:created_at;autoCreateTime"`
+	DeliveredAt    *time.Time `json:"delivered_at" gorm:"column:delivered_at"`
+	// Reservada por um despachante até este instante
+	LeaseUntil *time.Time `json:"-" gorm:"column:lease_until"`
+
+	Subscription WebhookSubscription `json:"-" gorm:"foreignKey:SubscriptionID;references:SubscriptionID"`
+}
+
+func (WebhookDelivery) TableName() string {
+	return "webhook_deliveries"
+}
@@ -0,0 +1,143 @@
+// backend/notify/consumer.go
+package notify
+
+import (
+	"ProtocolManager/backend/models"
+	"ProtocolManager/backend/repository"
+	"context"
+	"encoding/json"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+type idempotencyKeyCtx struct{}
+
+// WithIdempotencyKey associa a chave do evento de origem ao contexto. Os
+// canais que suportam deduplicação (ex.: Message-ID do e-mail) a usam para
+// que uma reentrega não gere uma mensagem diferente.
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKeyCtx{}, key)
+}
+
+func idempotencyKey(ctx context.Context) string {
+	key, _ := ctx.Value(idempotencyKeyCtx{}).(string)
+	return key
+}
+
+// OutboxConsumer enfileira as notificações de atribuição, mudança de
+// status e violação de SLA. O envio fica com o EventNotifier, chamado pelo
+// despachante de notificações, para que o relay nunca espere pelo SMTP.
+type OutboxConsumer struct {
+	Repo *repository.NotificationRepository
+}
+
+func NewOutboxConsumer(repo *repository.NotificationRepository) *OutboxConsumer {
+	return &OutboxConsumer{Repo: repo}
+}
+
+func (c *OutboxConsumer) Name() string {
+	return "notifications"
+}
+
+func (c *OutboxConsumer) Handle(
+	_ context.Context, event models.OutboxEvent,
+) error {
+	switch event.EventType {
+	case models.EventProtocolUpdated, models.EventProtocolStatusChanged,
+		models.EventProtocolSLABreached:
+	default:
+		return nil
+	}
+
+	// Só avisa sobre atribuição quando o responsável mudou
+	if event.EventType == models.EventProtocolUpdated {
+		var data models.ProtocolEventData
+		if err := json.Unmarshal([]byte(event.Payload), &data); err != nil {
+			return err
+		}
+		if data.PreviousAssignedTo == nil || data.AssignedTo == 0 {
+			return nil
+		}
+	}
+	return c.Repo.Enqueue(event)
+}
+
+// EventNotifier envia a notificação enfileirada pelo OutboxConsumer
+type EventNotifier struct {
+	DB       *gorm.DB
+	Notifier ProtocolNotifier
+}
+
+func NewEventNotifier(db *gorm.DB, notifier ProtocolNotifier) *EventNotifier {
+	return &EventNotifier{DB: db, Notifier: notifier}
+}
+
+// Send carrega o protocolo do evento e avisa os envolvidos. A chave de
+// idempotência do evento vai no contexto para que uma nova tentativa gere
+// a mesma mensagem.
+func (n *EventNotifier) Send(
+	ctx context.Context, notification models.Notification,
+) error {
+	var data models.ProtocolEventData
+	if err := json.Unmarshal([]byte(notification.Payload), &data); err != nil {
+		return err
+	}
+
+	protocol, err := n.loadProtocol(data)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// Protocolo removido antes da notificação
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	ctx = WithIdempotencyKey(ctx, notification.IdempotencyKey)
+	switch notification.EventType {
+	case models.EventProtocolUpdated:
+		return n.Notifier.NotifyAssignment(ctx, protocol)
+	case models.EventProtocolStatusChanged:
+		var previous models.ProtocolStatus
+		if data.PreviousStatusID != nil {
+			if err := n.DB.First(
+				&previous, *data.PreviousStatusID,
+			).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+		}
+		return n.Notifier.NotifyStatusChange(ctx, protocol, previous, data.Notes)
+	default:
+		return n.Notifier.NotifySLABreach(ctx, protocol)
+	}
+}
+
+// loadProtocol carrega o protocolo com as associações usadas nas mensagens.
+// Status e responsável vêm do evento, pois o protocolo pode ter mudado de
+// novo desde então.
+func (n *EventNotifier) loadProtocol(
+	data models.ProtocolEventData,
+) (models.Protocol, error) {
+	var protocol models.Protocol
+	if err := n.DB.
+		Preload("Customer").
+		Preload("CreatedByAgent").
+		First(&protocol, data.ProtocolID).Error; err != nil {
+		return protocol, err
+	}
+
+	protocol.StatusID = data.StatusID
+	protocol.AssignedTo = data.AssignedTo
+	if err := n.DB.First(&protocol.Status, data.StatusID).Error; err != nil &&
+		!errors.Is(err, gorm.ErrRecordNotFound) {
+		return protocol, err
+	}
+	if data.AssignedTo != 0 {
+		if err := n.DB.First(
+			&protocol.AssignedAgent, data.AssignedTo,
+		).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return protocol, err
+		}
+	}
+	return protocol, nil
+}
@@ -0,0 +1,386 @@
+// backend/notify/email.go
+package notify
+
+import (
+	"ProtocolManager/backend/models"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"embed"
+	"errors"
+	"fmt"
+	"html"
+	"html/template"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+//go:embed templates/*/*.html
+var templateFS embed.FS
+
+// Idiomas com templates disponíveis; o primeiro é o padrão
+var supportedLocales = []string{"pt-BR", "en"}
+
+// Formato de datas usado nas mensagens de cada idioma
+var dateLayouts = map[string]string{
+	"pt-BR": "02/01/2006 15:04",
+	"en":    "Jan 2, 2006 3:04 PM",
+}
+
+const (
+	tmplReminderDue   = "reminder_due"
+	tmplAssigned      = "protocol_assigned"
+	tmplStatusChanged = "status_changed"
+	tmplSLABreached   = "sla_breached"
+	// Versão para o cliente, sem as observações internas
+	tmplStatusChangedCustomer = "status_changed_customer"
+)
+
+// Prazo de uma entrega quando SMTPConfig.Timeout não é informado
+const defaultSMTPTimeout = 30 * time.Second
+
+// SMTPConfig reúne os dados do servidor de envio
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+	Locale   string
+	// Prazo da entrega inteira: conexão, TLS, autenticação e envio
+	Timeout time.Duration
+}
+
+// Addr devolve host:porta no formato esperado por net/smtp
+func (c SMTPConfig) Addr() string {
+	return net.JoinHostPort(c.Host, strconv.Itoa(c.Port))
+}
+
+// EmailNotifier envia notificações por e-mail. Os destinatários vêm do
+// e-mail dos funcionários (SalesPersonnel) e do cliente do protocolo.
+type EmailNotifier struct {
+	DB     *gorm.DB
+	Config SMTPConfig
+
+	// Remetente de Config.From: o endereço vai no MAIL FROM e a forma
+	// completa, com o nome, só no cabeçalho
+	from      *mail.Address
+	templates map[string]*template.Template
+}
+
+func NewEmailNotifier(db *gorm.DB, cfg SMTPConfig) (*EmailNotifier, error) {
+	if cfg.Host == "" || cfg.From == "" {
+		return nil, fmt.Errorf("SMTP host e remetente são obrigatórios")
+	}
+	from, err := mail.ParseAddress(cfg.From)
+	if err != nil {
+		return nil, fmt.Errorf("remetente inválido %q: %w", cfg.From, err)
+	}
+	if _, ok := dateLayouts[cfg.Locale]; !ok {
+		cfg.Locale = supportedLocales[0]
+	}
+
+	templates := make(map[string]*template.Template)
+	for _, locale := range supportedLocales {
+		layout := dateLayouts[locale]
+		funcs := template.FuncMap{
+			"date": func(v interface{}) string {
+				switch t := v.(type) {
+				case time.Time:
+					return t.Format(layout)
+				case *time.Time:
+					if t != nil {
+						return t.Format(layout)
+					}
+				}
+				return "-"
+			},
+		}
+		for _, name := range []string{
+			tmplReminderDue, tmplAssigned, tmplStatusChanged, tmplSLABreached,
+			tmplStatusChangedCustomer,
+		} {
+			path := fmt.Sprintf("templates/%s/%s.html", locale, name)
+			t, err := template.New(name).Funcs(funcs).ParseFS(templateFS, path)
+			if err != nil {
+				return nil, fmt.Errorf("template %s: %w", path, err)
+			}
+			templates[locale+"/"+name] = t
+		}
+	}
+
+	return &EmailNotifier{
+		DB:        db,
+		Config:    cfg,
+		from:      from,
+		templates: templates,
+	}, nil
+}
+
+// Dados disponíveis nos templates
+type emailData struct {
+	Protocol       models.Protocol
+	Reminder       models.ProtocolReminder
+	PreviousStatus models.ProtocolStatus
+	Notes          string
+}
+
+func (n *EmailNotifier) NotifyReminder(
+	ctx context.Context, reminder models.ProtocolReminder,
+) error {
+	protocol, err := n.loadProtocol(reminder.ProtocolID)
+	if err != nil {
+		return err
+	}
+	return n.send(
+		ctx, tmplReminderDue,
+		emailData{Protocol: protocol, Reminder: reminder},
+		reminder.CreatedByAgent.Email, protocol.AssignedAgent.Email,
+	)
+}
+
+func (n *EmailNotifier) NotifyAssignment(
+	ctx context.Context, protocol models.Protocol,
+) error {
+	return n.send(
+		ctx, tmplAssigned,
+		emailData{Protocol: protocol},
+		protocol.AssignedAgent.Email,
+	)
+}
+
+func (n *EmailNotifier) NotifyStatusChange(
+	ctx context.Context,
+	protocol models.Protocol,
+	previous models.ProtocolStatus,
+	notes string,
+) error {
+	// As observações são internas: só a equipe as recebe
+	if err := n.send(
+		ctx, tmplStatusChanged,
+		emailData{Protocol: protocol, PreviousStatus: previous, Notes: notes},
+		protocol.AssignedAgent.Email, protocol.CreatedByAgent.Email,
+	); err != nil {
+		return err
+	}
+
+	// A mensagem do cliente é outra, então recebe outro Message-ID
+	if key := idempotencyKey(ctx); key != "" {
+		ctx = WithIdempotencyKey(ctx, key+".customer")
+	}
+	return n.send(
+		ctx, tmplStatusChangedCustomer,
+		emailData{Protocol: protocol, PreviousStatus: previous},
+		protocol.Customer.Email,
+	)
+}
+
+func (n *EmailNotifier) NotifySLABreach(
+	ctx context.Context, protocol models.Protocol,
+) error {
+	return n.send(
+		ctx, tmplSLABreached,
+		emailData{Protocol: protocol},
+		protocol.AssignedAgent.Email, protocol.CreatedByAgent.Email,
+	)
+}
+
+func (n *EmailNotifier) loadProtocol(id int) (models.Protocol, error) {
+	var protocol models.Protocol
+	err := n.DB.
+		Preload("Status").
+		Preload("Customer").
+		Preload("AssignedAgent").
+		Preload("CreatedByAgent").
+		First(&protocol, id).Error
+	return protocol, err
+}
+
+// send monta a mensagem a partir do template e entrega aos destinatários,
+// ignorando endereços vazios ou repetidos
+func (n *EmailNotifier) send(
+	ctx context.Context, name string, data emailData, recipients ...string,
+) error {
+	to := uniqueAddresses(recipients)
+	if len(to) == 0 {
+		return nil
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	subject, body, err := n.render(name, data)
+	if err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if n.Config.Username != "" {
+		auth = smtp.PlainAuth(
+			"", n.Config.Username, n.Config.Password, n.Config.Host,
+		)
+	}
+
+	msg := buildMessage(n.from, to, subject, body, idempotencyKey(ctx))
+	if err := n.sendMail(ctx, auth, to, msg); err != nil {
+		return fmt.Errorf("envio de e-mail %s: %w", name, err)
+	}
+	return nil
+}
+
+// sendMail faz a mesma conversa de smtp.SendMail (STARTTLS quando
+// oferecido, autenticação, envio), mas respeita o contexto: a conexão é
+// aberta com net.Dialer e recebe como prazo o menor entre o do contexto e
+// Config.Timeout. Cancelar o contexto interrompe a conversa.
+func (n *EmailNotifier) sendMail(
+	ctx context.Context, auth smtp.Auth, to []string, msg []byte,
+) (err error) {
+	timeout := n.Config.Timeout
+	if timeout <= 0 {
+		timeout = defaultSMTPTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	// Um erro de E/S causado pelo prazo é informado como o erro do contexto.
+	// O prazo da conexão pode vencer antes do contexto perceber.
+	defer func() {
+		if err == nil {
+			return
+		}
+		if cause := ctx.Err(); cause != nil {
+			err = fmt.Errorf("%w: %v", cause, err)
+		} else if errors.Is(err, os.ErrDeadlineExceeded) {
+			err = fmt.Errorf("%w: %v", context.DeadlineExceeded, err)
+		}
+	}()
+
+	dialer := net.Dialer{Timeout: timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", n.Config.Addr())
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	deadline, _ := ctx.Deadline()
+	if err := conn.SetDeadline(deadline); err != nil {
+		return err
+	}
+	stop := context.AfterFunc(ctx, func() {
+		conn.SetDeadline(time.Now())
+	})
+	defer stop()
+
+	client, err := smtp.NewClient(conn, n.Config.Host)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(
+			&tls.Config{ServerName: n.Config.Host},
+		); err != nil {
+			return err
+		}
+	}
+	if auth != nil {
+		if ok, _ := client.Extension("AUTH"); !ok {
+			return errors.New("servidor SMTP não aceita autenticação")
+		}
+		if err := client.Auth(auth); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(n.from.Address); err != nil {
+		return err
+	}
+	for _, addr := range to {
+		if err := client.Rcpt(addr); err != nil {
+			return err
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// render executa os blocos "subject" e "body" do template no idioma
+// configurado
+func (n *EmailNotifier) render(name string, data emailData) (
+	string, string, error,
+) {
+	t, ok := n.templates[n.Config.Locale+"/"+name]
+	if !ok {
+		return "", "", fmt.Errorf("template %s não encontrado", name)
+	}
+
+	var subject, body bytes.Buffer
+	if err := t.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return "", "", err
+	}
+	if err := t.ExecuteTemplate(&body, "body", data); err != nil {
+		return "", "", err
+	}
+
+	// O assunto vai no cabeçalho, não em HTML
+	return strings.TrimSpace(html.UnescapeString(subject.String())),
+		body.String(), nil
+}
+
+// buildMessage monta a mensagem MIME. Com uma chave de idempotência, o
+// Message-ID é derivado dela, o que permite ao destino descartar reenvios.
+func buildMessage(
+	from *mail.Address, to []string, subject, body, key string,
+) []byte {
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", from.String())
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	if key != "" {
+		fmt.Fprintf(&msg, "Message-ID: <%s@%s>\r\n", key, messageIDDomain(from))
+	}
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/html; charset=UTF-8\r\n")
+	msg.WriteString("\r\n")
+	msg.WriteString(body)
+	return msg.Bytes()
+}
+
+func uniqueAddresses(addresses []string) []string {
+	seen := make(map[string]bool)
+	var unique []string
+	for _, addr := range addresses {
+		addr = strings.TrimSpace(addr)
+		key := strings.ToLower(addr)
+		if addr == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		unique = append(unique, addr)
+	}
+	return unique
+}
+
+func messageIDDomain(from *mail.Address) string {
+	if at := strings.LastIndex(from.Address, "@"); at >= 0 {
+		return from.Address[at+1:]
+	}
+	return "protocolmanager"
+}
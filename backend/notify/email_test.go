@@ -0,0 +1,158 @@
+// backend/notify/email_test.go
+package notify
+
+import (
+	"ProtocolManager/backend/models"
+	"ProtocolManager/backend/notify/smtptest"
+	"context"
+	"errors"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestNotifier(t *testing.T, server *smtptest.Server, timeout time.Duration) *EmailNotifier {
+	t.Helper()
+	host, port, err := net.SplitHostPort(server.Addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	portNumber, _ := strconv.Atoi(port)
+	n, err := NewEmailNotifier(nil, SMTPConfig{
+		Host:     host,
+		Port:     portNumber,
+		Username: "protocolos",
+		Password: "segredo",
+		From:     "Protocolos <protocolos@example.com>",
+		Locale:   "pt-BR",
+		Timeout:  timeout,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+var assignedProtocol = models.Protocol{
+	ProtocolNumber: "2026-0042",
+	Title:          "Segunda via da apólice",
+	AssignedAgent:  models.SalesPersonnel{FirstName: "Ana", Email: "ana@example.com"},
+}
+
+func TestEmailNotifierDeliversThroughSMTP(t *testing.T) {
+	server := smtptest.NewServer()
+	defer server.Close()
+	n := newTestNotifier(t, server, time.Second)
+
+	if err := n.NotifyAssignment(context.Background(), assignedProtocol); err != nil {
+		t.Fatal(err)
+	}
+
+	messages := server.Messages()
+	if len(messages) != 1 {
+		t.Fatalf("got %d messages, want 1", len(messages))
+	}
+	msg := messages[0]
+	// O nome do remetente só vai no cabeçalho
+	if msg.From != "protocolos@example.com" {
+		t.Errorf("MAIL FROM %q", msg.From)
+	}
+	if !strings.Contains(string(msg.Data), "From: \"Protocolos\" <protocolos@example.com>\n") {
+		t.Errorf("message without the From header:\n%s", msg.Data)
+	}
+	if len(msg.To) != 1 || msg.To[0] != "ana@example.com" {
+		t.Errorf("to %v", msg.To)
+	}
+	if !strings.Contains(string(msg.Data), "2026-0042") {
+		t.Errorf("message does not mention the protocol:\n%s", msg.Data)
+	}
+}
+
+func TestEmailNotifierKeepsStatusNotesFromTheCustomer(t *testing.T) {
+	server := smtptest.NewServer()
+	defer server.Close()
+	n := newTestNotifier(t, server, time.Second)
+
+	protocol := assignedProtocol
+	protocol.Status = models.ProtocolStatus{StatusName: "Em análise"}
+	protocol.Customer = models.Customer{FirstName: "Carlos", Email: "carlos@example.com"}
+	protocol.CreatedByAgent = models.SalesPersonnel{FirstName: "Bruno", Email: "bruno@example.com"}
+	ctx := WithIdempotencyKey(context.Background(), "event-1")
+	if err := n.NotifyStatusChange(
+		ctx, protocol, models.ProtocolStatus{StatusName: "Novo"},
+		"cliente inadimplente",
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	messages := server.Messages()
+	if len(messages) != 2 {
+		t.Fatalf("got %d messages, want 2", len(messages))
+	}
+	for _, msg := range messages {
+		customer := slices.Contains(msg.To, "carlos@example.com")
+		if customer && len(msg.To) != 1 {
+			t.Errorf("customer message also sent to %v", msg.To)
+		}
+		notes := strings.Contains(string(msg.Data), "cliente inadimplente")
+		if notes == customer {
+			t.Errorf("to %v: notes included = %v", msg.To, notes)
+		}
+	}
+	if strings.Contains(string(messages[0].Data), "Message-ID: <event-1@example.com>") ==
+		strings.Contains(string(messages[1].Data), "Message-ID: <event-1@example.com>") {
+		t.Error("staff and customer messages share a Message-ID")
+	}
+}
+
+func TestEmailNotifierReportsRejection(t *testing.T) {
+	server := smtptest.NewUnstartedServer()
+	server.Reject = "mailbox unavailable"
+	server.Start()
+	defer server.Close()
+	n := newTestNotifier(t, server, time.Second)
+
+	err := n.NotifyAssignment(context.Background(), assignedProtocol)
+	if err == nil || !strings.Contains(err.Error(), "mailbox unavailable") {
+		t.Fatalf("got %v, want the server rejection", err)
+	}
+}
+
+func TestEmailNotifierStopsAtTheDeadline(t *testing.T) {
+	server := smtptest.NewUnstartedServer()
+	server.Stall = true
+	server.Start()
+	defer server.Close()
+
+	tests := []struct {
+		name    string
+		timeout time.Duration
+		ctx     func() (context.Context, context.CancelFunc)
+	}{
+		{"context deadline", time.Minute, func() (context.Context, context.CancelFunc) {
+			return context.WithTimeout(context.Background(), 100*time.Millisecond)
+		}},
+		{"configured timeout", 100 * time.Millisecond, func() (context.Context, context.CancelFunc) {
+			return context.WithCancel(context.Background())
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n := newTestNotifier(t, server, tt.timeout)
+			ctx, cancel := tt.ctx()
+			defer cancel()
+
+			start := time.Now()
+			err := n.NotifyAssignment(ctx, assignedProtocol)
+			if !errors.Is(err, context.DeadlineExceeded) {
+				t.Fatalf("got %v, want a deadline error", err)
+			}
+			if elapsed := time.Since(start); elapsed > 5*time.Second {
+				t.Fatalf("took %v to give up", elapsed)
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+// backend/notify/notifier.go
+package notify
+
+import (
+	"ProtocolManager/backend/models"
+	"context"
+	"log"
+)
+
+// ReminderNotifier entrega um lembrete por algum canal (log, e-mail, ...)
+type ReminderNotifier interface {
+	NotifyReminder(ctx context.Context, reminder models.ProtocolReminder) error
+}
+
+// ProtocolNotifier avisa os envolvidos sobre mudanças em um protocolo.
+// O protocolo recebido deve vir com as associações carregadas.
+type ProtocolNotifier interface {
+	NotifyAssignment(ctx context.Context, protocol models.Protocol) error
+	NotifyStatusChange(
+		ctx context.Context,
+		protocol models.Protocol,
+		previous models.ProtocolStatus,
+		notes string,
+	) error
+	NotifySLABreach(ctx context.Context, protocol models.Protocol) error
+}
+
+// LogNotifier apenas registra o lembrete no log do processo
+type LogNotifier struct{}
+
+func (LogNotifier) NotifyReminder(
+	_ context.Context, reminder models.ProtocolReminder,
+) error {
+	log.Printf(
+		"Lembrete %d do protocolo %d: %s",
+		reminder.ReminderID, reminder.ProtocolID, reminder.ReminderText,
+	)
+	return nil
+}
+
+func (LogNotifier) NotifyAssignment(
+	_ context.Context, protocol models.Protocol,
+) error {
+	log.Printf(
+		"Protocolo %s atribuído a %d",
+		protocol.ProtocolNumber, protocol.AssignedTo,
+	)
+	return nil
+}
+
+func (LogNotifier) NotifyStatusChange(
+	_ context.Context,
+	protocol models.Protocol,
+	previous models.ProtocolStatus,
+	_ string,
+) error {
+	log.Printf(
+		"Protocolo %s mudou de %q para %q",
+		protocol.ProtocolNumber, previous.StatusName, protocol.Status.StatusName,
+	)
+	return nil
+}
+
+func (LogNotifier) NotifySLABreach(
+	_ context.Context, protocol models.Protocol,
+) error {
+	log.Printf("Protocolo %s violou o prazo", protocol.ProtocolNumber)
+	return nil
+}
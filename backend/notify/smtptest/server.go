@@ -0,0 +1,185 @@
+// backend/notify/smtptest/server.go
+
+// Package smtptest fornece um servidor SMTP falso para testes, no estilo do
+// httptest: ele escuta em uma porta local, aceita AUTH PLAIN e guarda as
+// mensagens recebidas.
+package smtptest
+
+import (
+	"io"
+	"net"
+	"net/textproto"
+	"strings"
+	"sync"
+)
+
+// Message é uma mensagem entregue ao servidor
+type Message struct {
+	From string
+	To   []string
+	Data []byte
+}
+
+// Server é um servidor SMTP falso
+type Server struct {
+	// Endereço host:porta do servidor
+	Addr string
+	// Com Stall, o servidor aceita a conexão e nunca responde, para testar
+	// prazos
+	Stall bool
+	// Com Reject definido, o DATA é recusado com "554 <Reject>"
+	Reject string
+
+	mu       sync.Mutex
+	messages []Message
+	listener net.Listener
+	conns    map[net.Conn]struct{}
+	wg       sync.WaitGroup
+}
+
+// NewServer começa a escutar em 127.0.0.1 numa porta livre. Chame Close
+// ao final do teste.
+func NewServer() *Server {
+	s := NewUnstartedServer()
+	s.Start()
+	return s
+}
+
+// NewUnstartedServer reserva a porta sem atender conexões, para que Stall
+// e Reject sejam ajustados antes de Start
+func NewUnstartedServer() *Server {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		panic("smtptest: " + err.Error())
+	}
+	return &Server{
+		Addr:     listener.Addr().String(),
+		listener: listener,
+		conns:    make(map[net.Conn]struct{}),
+	}
+}
+
+// Start passa a atender as conexões
+func (s *Server) Start() {
+	s.wg.Add(1)
+	go s.serve()
+}
+
+// Messages devolve as mensagens recebidas até agora
+func (s *Server) Messages() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Message(nil), s.messages...)
+}
+
+// Close para de aceitar conexões, encerra as abertas e espera o fim delas
+func (s *Server) Close() {
+	s.listener.Close()
+	s.mu.Lock()
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.conns[conn] = struct{}{}
+		s.mu.Unlock()
+
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			defer func() {
+				s.mu.Lock()
+				delete(s.conns, conn)
+				s.mu.Unlock()
+				conn.Close()
+			}()
+			s.handle(conn)
+		}()
+	}
+}
+
+// handle conduz uma sessão SMTP. Só os comandos usados por net/smtp são
+// tratados.
+func (s *Server) handle(conn net.Conn) {
+	text := textproto.NewConn(conn)
+	if s.Stall {
+		// Espera o cliente desistir ou o Close
+		io.Copy(io.Discard, conn)
+		return
+	}
+
+	reply := func(line string) bool {
+		return text.PrintfLine("%s", line) == nil
+	}
+	if !reply("220 smtptest ESMTP") {
+		return
+	}
+
+	var current Message
+	for {
+		line, err := text.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO":
+			reply("250-smtptest")
+			reply("250 AUTH PLAIN")
+		case "HELO", "NOOP":
+			reply("250 OK")
+		case "AUTH":
+			reply("235 2.7.0 Authentication successful")
+		case "MAIL":
+			current = Message{From: address(arg)}
+			reply("250 OK")
+		case "RCPT":
+			current.To = append(current.To, address(arg))
+			reply("250 OK")
+		case "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			data, err := text.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			if s.Reject != "" {
+				reply("554 " + s.Reject)
+				continue
+			}
+			current.Data = data
+			s.mu.Lock()
+			s.messages = append(s.messages, current)
+			s.mu.Unlock()
+			current = Message{}
+			reply("250 OK")
+		case "RSET":
+			current = Message{}
+			reply("250 OK")
+		case "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("502 Command not implemented")
+		}
+	}
+}
+
+// address extrai o endereço de "FROM:<a@b>" ou "TO:<a@b>"
+func address(arg string) string {
+	_, value, _ := strings.Cut(arg, ":")
+	value = strings.TrimSpace(value)
+	if end := strings.Index(value, ">"); end >= 0 {
+		value = value[:end]
+	}
+	return strings.TrimPrefix(value, "<")
+}
@@ -0,0 +1,179 @@
+// backend/preview/thumbnail.go
+package preview
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+const (
+	// ThumbnailSize é o maior lado da miniatura em pixels
+	ThumbnailSize = 320
+	// ThumbnailContentType é o formato de todas as miniaturas
+	ThumbnailContentType = "image/jpeg"
+
+	thumbnailQuality = 80
+	// Imagens maiores que isso não são decodificadas, para um arquivo
+	// pequeno não ocupar gigabytes de memória ao ser expandido
+	maxSourcePixels = 50_000_000
+	maxSourceBytes  = 64 << 20
+)
+
+// ErrUnsupported indica que o tipo do arquivo não tem prévia
+var ErrUnsupported = errors.New("tipo de arquivo sem prévia")
+
+// inlineImages são os tipos servidos direto no navegador. SVG fica de fora
+// por poder conter scripts.
+var inlineImages = map[string]bool{
+	"image/png":  true,
+	"image/jpeg": true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+// IsInlineImage informa se o anexo pode ser exibido no navegador como está
+func IsInlineImage(contentType string) bool {
+	return inlineImages[contentType]
+}
+
+// Renderer gera as miniaturas dos anexos. Imagens são reduzidas em Go; a
+// primeira página dos PDFs é renderizada pelo pdftoppm (poppler-utils).
+type Renderer struct {
+	// Caminho do pdftoppm; vazio desativa as miniaturas de PDF
+	PDFToPPM string
+}
+
+// NewRenderer procura o pdftoppm indicado no PATH. Sem ele as miniaturas de
+// PDF ficam desativadas.
+func NewRenderer(pdftoppm string) *Renderer {
+	if pdftoppm != "" {
+		path, err := exec.LookPath(pdftoppm)
+		if err != nil {
+			pdftoppm = ""
+		} else {
+			pdftoppm = path
+		}
+	}
+	return &Renderer{PDFToPPM: pdftoppm}
+}
+
+// Supports informa se o tipo tem miniatura
+func (r *Renderer) Supports(contentType string) bool {
+	if contentType == "application/pdf" {
+		return r.PDFToPPM != ""
+	}
+	return IsInlineImage(contentType)
+}
+
+// Thumbnail devolve a miniatura JPEG do arquivo, com o maior lado de até
+// ThumbnailSize pixels
+func (r *Renderer) Thumbnail(
+	ctx context.Context, file io.Reader, contentType string,
+) ([]byte, error) {
+	if !r.Supports(contentType) {
+		return nil, ErrUnsupported
+	}
+
+	data, err := io.ReadAll(io.LimitReader(file, maxSourceBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxSourceBytes {
+		return nil, fmt.Errorf("arquivo maior que %d bytes", maxSourceBytes)
+	}
+	if contentType == "application/pdf" {
+		if data, err = r.renderPDF(ctx, data); err != nil {
+			return nil, err
+		}
+	}
+
+	src, err := decode(data)
+	if err != nil {
+		return nil, err
+	}
+	return encodeThumbnail(src)
+}
+
+func decode(data []byte) (image.Image, error) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if config.Width <= 0 || config.Height <= 0 ||
+		config.Width*config.Height > maxSourcePixels {
+		return nil, fmt.Errorf(
+			"dimensões da imagem fora do limite: %dx%d",
+			config.Width, config.Height,
+		)
+	}
+	src, _, err := image.Decode(bytes.NewReader(data))
+	return src, err
+}
+
+// encodeThumbnail reduz a imagem mantendo a proporção, sem ampliar as
+// pequenas. Transparência vira fundo branco, já que JPEG não tem canal alfa.
+func encodeThumbnail(src image.Image) ([]byte, error) {
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width > ThumbnailSize || height > ThumbnailSize {
+		if width >= height {
+			width, height = ThumbnailSize, max(height*ThumbnailSize/width, 1)
+		} else {
+			width, height = max(width*ThumbnailSize/height, 1), ThumbnailSize
+		}
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, bounds, draw.Over, nil)
+
+	var out bytes.Buffer
+	if err := jpeg.Encode(&out, dst, &jpeg.Options{Quality: thumbnailQuality}); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// renderPDF renderiza a primeira página do PDF como PNG. O pdftoppm só lê
+// arquivos, então o PDF passa por um diretório temporário.
+func (r *Renderer) renderPDF(ctx context.Context, data []byte) ([]byte, error) {
+	dir, err := os.MkdirTemp("", "pm-preview-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	input := filepath.Join(dir, "input.pdf")
+	if err := os.WriteFile(input, data, 0o600); err != nil {
+		return nil, err
+	}
+	output := filepath.Join(dir, "page")
+
+	// Renderiza já no dobro do tamanho final; a redução suaviza o texto
+	cmd := exec.CommandContext(
+		ctx, r.PDFToPPM,
+		"-f", "1", "-l", "1", "-singlefile", "-png",
+		"-scale-to", fmt.Sprint(2*ThumbnailSize),
+		input, output,
+	)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("pdftoppm: %w: %s", err, bytes.TrimSpace(stderr.Bytes()))
+	}
+	return os.ReadFile(output + ".png")
+}
@@ -0,0 +1,91 @@
+// backend/realtime/filter.go
+package realtime
+
+import (
+	"ProtocolManager/backend/models"
+	"ProtocolManager/backend/repository"
+	"encoding/json"
+	"strings"
+)
+
+// Eventos enviados quando o cliente não escolhe nenhum
+var DefaultEvents = []string{
+	models.EventProtocolCreated,
+	models.EventProtocolUpdated,
+	models.EventProtocolStatusChanged,
+	models.EventAttachmentAdded,
+	models.EventAttachmentScanned,
+	models.EventAttachmentVersionAdded,
+	models.EventAttachmentThumbnailReady,
+}
+
+// StreamableEvents são os eventos que podem ser assinados pelo stream
+var StreamableEvents = map[string]bool{
+	models.EventProtocolCreated:          true,
+	models.EventProtocolUpdated:          true,
+	models.EventProtocolStatusChanged:    true,
+	models.EventProtocolDeleted:          true,
+	models.EventProtocolSLABreached:      true,
+	models.EventAttachmentAdded:          true,
+	models.EventAttachmentScanned:        true,
+	models.EventAttachmentVersionAdded:   true,
+	models.EventAttachmentThumbnailReady: true,
+}
+
+// Filter seleciona os eventos de uma conexão. Campos nil não filtram.
+type Filter struct {
+	Scope  repository.Scope
+	Events map[string]bool
+
+	ProtocolID *int
+	StatusID   *int
+	TypeID     *int
+	AssignedTo *int
+	BranchID   *int
+	Priority   string
+}
+
+// hasAttributeFilters informa se a conexão filtra por campos do protocolo
+func (f Filter) hasAttributeFilters() bool {
+	return f.StatusID != nil || f.TypeID != nil || f.AssignedTo != nil ||
+		f.BranchID != nil || f.Priority != ""
+}
+
+// Match decide se o evento vai para a conexão. A filial gravada no evento
+// é a do protocolo no momento da alteração, então o escopo é verificado
+// contra ela.
+func (f Filter) Match(event models.OutboxEvent) bool {
+	if !f.Events[event.EventType] || !f.Scope.Allows(event.BranchID) {
+		return false
+	}
+	if f.ProtocolID != nil && *f.ProtocolID != event.ProtocolID {
+		return false
+	}
+	if !f.hasAttributeFilters() {
+		return true
+	}
+
+	// Eventos de anexo não trazem os campos do protocolo; numa lista
+	// filtrada eles só passam quando o protocolo foi escolhido
+	if strings.HasPrefix(event.EventType, "attachment.") {
+		return f.ProtocolID != nil
+	}
+
+	var data models.ProtocolEventData
+	if err := json.Unmarshal([]byte(event.Payload), &data); err != nil {
+		return false
+	}
+	// Valores anteriores também contam, para o cliente saber que o
+	// protocolo saiu da lista
+	return matchInt(f.StatusID, data.StatusID, data.PreviousStatusID) &&
+		matchInt(f.TypeID, data.TypeID, nil) &&
+		matchInt(f.AssignedTo, data.AssignedTo, data.PreviousAssignedTo) &&
+		(f.BranchID == nil ||
+			(data.BranchID != nil && *data.BranchID == *f.BranchID)) &&
+		(f.Priority == "" || f.Priority == data.Priority)
+}
+
+func matchInt(want *int, got int, previous *int) bool {
+	return want == nil || *want == got ||
+		(previous != nil && *want == *previous)
+}
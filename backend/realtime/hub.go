@@ -0,0 +1,162 @@
+// backend/realtime/hub.go
+package realtime
+
+import (
+	"ProtocolManager/backend/models"
+	"ProtocolManager/backend/repository"
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+const (
+	hubBatchSize = 500
+	// Eventos em espera por assinante; quem não acompanha é desconectado e
+	// retoma pelo Last-Event-ID
+	subscriberBuffer = 256
+)
+
+// Hub acompanha o outbox e repassa os novos eventos às conexões abertas
+// nesta réplica. Cada réplica lê o outbox por conta própria, então
+// conexões em réplicas diferentes recebem os mesmos eventos.
+type Hub struct {
+	Repo     *repository.OutboxRepository
+	Interval time.Duration
+
+	mu    sync.Mutex
+	subs  map[*Subscription]struct{}
+	last  models.OutboxPosition
+	ready chan struct{}
+}
+
+func NewHub(repo *repository.OutboxRepository, interval time.Duration) *Hub {
+	return &Hub{
+		Repo:     repo,
+		Interval: interval,
+		subs:     make(map[*Subscription]struct{}),
+		ready:    make(chan struct{}),
+	}
+}
+
+// Subscription recebe os eventos publicados depois de Start
+type Subscription struct {
+	C chan models.OutboxEvent
+	// Último evento já publicado pelo hub quando a assinatura começou;
+	// eventos até ele devem ser buscados no banco
+	Start models.OutboxPosition
+}
+
+// Run acompanha o outbox até o contexto ser cancelado
+func (h *Hub) Run(ctx context.Context) {
+	for {
+		head, err := h.Repo.Head()
+		if err == nil {
+			h.mu.Lock()
+			h.last = head
+			h.mu.Unlock()
+			close(h.ready)
+			break
+		}
+		log.Printf("Realtime hub: %v", err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(h.Interval):
+		}
+	}
+
+	ticker := time.NewTicker(h.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			h.closeAll()
+			return
+		case <-ticker.C:
+			h.poll()
+		}
+	}
+}
+
+func (h *Hub) poll() {
+	for {
+		h.mu.Lock()
+		last := h.last
+		h.mu.Unlock()
+
+		events, err := h.Repo.EventsAfter(h.Repo.DB, last, hubBatchSize)
+		if err != nil {
+			log.Printf("Realtime hub: %v", err)
+			return
+		}
+		if len(events) == 0 {
+			return
+		}
+		h.publish(events)
+		if len(events) < hubBatchSize {
+			return
+		}
+	}
+}
+
+func (h *Hub) publish(events []models.OutboxEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, event := range events {
+		for sub := range h.subs {
+			select {
+			case sub.C <- event:
+			default:
+				// Assinante lento: encerra a conexão em vez de perder eventos
+				delete(h.subs, sub)
+				close(sub.C)
+			}
+		}
+		h.last = event.Position()
+	}
+}
+
+// Subscribe registra uma nova conexão. Bloqueia até o hub conhecer a posição
+// atual do outbox ou o contexto ser cancelado.
+func (h *Hub) Subscribe(ctx context.Context) (*Subscription, error) {
+	select {
+	case <-h.ready:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	sub := &Subscription{
+		C:     make(chan models.OutboxEvent, subscriberBuffer),
+		Start: h.last,
+	}
+	h.subs[sub] = struct{}{}
+	return sub, nil
+}
+
+// Unsubscribe remove a conexão; pode ser chamada mais de uma vez
+func (h *Hub) Unsubscribe(sub *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.subs[sub]; ok {
+		delete(h.subs, sub)
+		close(sub.C)
+	}
+}
+
+func (h *Hub) closeAll() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for sub := range h.subs {
+		delete(h.subs, sub)
+		close(sub.C)
+	}
+}
@@ -0,0 +1,10 @@
+// backend/repository/actor.go
+package repository
+
+// Actor identifica quem executa uma operação para fins de auditoria. É
+// sempre obtido da sessão autenticada, nunca do corpo da requisição.
+type Actor struct {
+	UserID      int
+	PersonnelID int
+	Role        string
+}
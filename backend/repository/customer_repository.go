@@ -14,27 +14,38 @@ func NewCustomerRepository(db *gorm.DB) *CustomerRepository {
 	return &CustomerRepository{DB: db}
 }
 
-func (r *CustomerRepository) GetAll() ([]models.Customer, error) {
+func (r *CustomerRepository) GetAll(scope Scope) ([]models.Customer, error) {
 	var customers []models.Customer
-	result := r.DB.Find(&customers)
+	result := scope.Apply(r.DB, "customers").Find(&customers)
 	return customers, result.Error
 }
 
-func (r *CustomerRepository) GetByID(id int) (models.Customer, error) {
+func (r *CustomerRepository) GetByID(scope Scope, id int) (
+	models.Customer, error,
+) {
 	var customer models.Customer
-	result := r.DB.First(&customer, id)
+	result := scope.Apply(r.DB, "customers").First(&customer, id)
 	return customer, result.Error
 }
 
-func (r *CustomerRepository) Create(customer models.Customer) (
+func (r *CustomerRepository) Create(scope Scope, customer models.Customer) (
 	models.Customer, error,
 ) {
+	if !scope.Allows(customer.BranchID) {
+		return customer, ErrOutOfScope
+	}
 	result := r.DB.Create(&customer)
 	return customer, result.Error
 }
 
-func (r *CustomerRepository) Update(id int, customer models.Customer) error {
-	result := r.DB.Model(&models.Customer{}).Where(
+func (r *CustomerRepository) Update(
+	scope Scope, id int, customer models.Customer,
+) error {
+	if !scope.Allows(customer.BranchID) {
+		return ErrOutOfScope
+	}
+
+	result := scope.Apply(r.DB.Model(&models.Customer{}), "customers").Where(
 		"customer_id = ?", id,
 	).Updates(
 		map[string]interface{}{
@@ -50,10 +61,10 @@ func (r *CustomerRepository) Update(id int, customer models.Customer) error {
 			"active":      customer.Active,
 		},
 	)
-	return result.Error
+	return requireAffected(result)
 }
 
-func (r *CustomerRepository) Delete(id int) error {
-	result := r.DB.Delete(&models.Customer{}, id)
-	return result.Error
+func (r *CustomerRepository) Delete(scope Scope, id int) error {
+	result := scope.Apply(r.DB, "customers").Delete(&models.Customer{}, id)
+	return requireAffected(result)
 }
@@ -0,0 +1,62 @@
+// backend/repository/deadline.go
+package repository
+
+import (
+	"ProtocolManager/backend/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// DeadlineCalculator soma os dias de prazo do tipo do protocolo, em dias
+// corridos ou em dias úteis (sem sábados, domingos e feriados cadastrados)
+type DeadlineCalculator struct {
+	BusinessDays bool
+}
+
+func NewDeadlineCalculator(businessDays bool) *DeadlineCalculator {
+	return &DeadlineCalculator{BusinessDays: businessDays}
+}
+
+// Deadline retorna o prazo a partir de start. Em dias úteis são
+// considerados os feriados gerais e os da filial informada.
+func (d *DeadlineCalculator) Deadline(
+	tx *gorm.DB, start time.Time, days int, branchID *int,
+) (time.Time, error) {
+	if !d.BusinessDays {
+		return start.AddDate(0, 0, days), nil
+	}
+
+	// Janela folgada o bastante para fins de semana e feriados
+	until := start.AddDate(0, 0, days*2+14)
+
+	var holidays []models.Holiday
+	query := tx.Where("date > ? AND date <= ?", start, until)
+	if branchID != nil {
+		query = query.Where("(branch_id IS NULL OR branch_id = ?)", *branchID)
+	} else {
+		query = query.Where("branch_id IS NULL")
+	}
+	if err := query.Find(&holidays).Error; err != nil {
+		return time.Time{}, err
+	}
+
+	closed := make(map[string]bool, len(holidays))
+	for _, h := range holidays {
+		closed[h.Date.Format("2006-01-02")] = true
+	}
+
+	deadline := start
+	for remaining := days; remaining > 0; {
+		deadline = deadline.AddDate(0, 0, 1)
+		weekday := deadline.Weekday()
+		if weekday == time.Saturday || weekday == time.Sunday {
+			continue
+		}
+		if closed[deadline.Format("2006-01-02")] {
+			continue
+		}
+		remaining--
+	}
+	return deadline, nil
+}
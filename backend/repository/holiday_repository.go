@@ -0,0 +1,33 @@
+// backend/repository/holiday_repository.go
+package repository
+
+import (
+	"ProtocolManager/backend/models"
+	"gorm.io/gorm"
+)
+
+type HolidayRepository struct {
+	DB *gorm.DB
+}
+
+func NewHolidayRepository(db *gorm.DB) *HolidayRepository {
+	return &HolidayRepository{DB: db}
+}
+
+func (r *HolidayRepository) GetAll() ([]models.Holiday, error) {
+	var holidays []models.Holiday
+	result := r.DB.Order("date").Find(&holidays)
+	return holidays, result.Error
+}
+
+func (r *HolidayRepository) Create(holiday models.Holiday) (
+	models.Holiday, error,
+) {
+	result := r.DB.Create(&holiday)
+	return holiday, result.Error
+}
+
+func (r *HolidayRepository) Delete(id int) error {
+	result := r.DB.Delete(&models.Holiday{}, id)
+	return requireAffected(result)
+}
@@ -0,0 +1,103 @@
+// backend/repository/notification_repository.go
+package repository
+
+import (
+	"ProtocolManager/backend/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// NotificationRepository guarda a fila de notificações por e-mail
+type NotificationRepository struct {
+	DB *gorm.DB
+}
+
+func NewNotificationRepository(db *gorm.DB) *NotificationRepository {
+	return &NotificationRepository{DB: db}
+}
+
+// Enqueue grava a notificação do evento. Repetições do mesmo evento são
+// ignoradas pelo índice único da chave de idempotência.
+func (r *NotificationRepository) Enqueue(event models.OutboxEvent) error {
+	return r.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(
+		&models.Notification{
+			IdempotencyKey: event.IdempotencyKey,
+			EventType:      event.EventType,
+			ProtocolID:     event.ProtocolID,
+			Payload:        event.Payload,
+			Status:         models.NotificationPending,
+			CreatedAt:      time.Now().UTC(),
+		},
+	).Error
+}
+
+// ClaimDue reserva até limit notificações pendentes numa transação curta.
+// SKIP LOCKED e a reserva (lease_until) impedem que outra réplica envie a
+// mesma notificação enquanto o e-mail é enviado, fora de qualquer
+// transação.
+func (r *NotificationRepository) ClaimDue(
+	now time.Time, limit int, lease time.Duration,
+) ([]models.Notification, error) {
+	var notifications []models.Notification
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.
+			Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where(
+				`status = ? AND (next_attempt_at IS NULL OR next_attempt_at <= ?)
+				AND (lease_until IS NULL OR lease_until <= ?)`,
+				models.NotificationPending, now, now,
+			).
+			Order("notification_id").
+			Limit(limit).
+			Find(&notifications).Error
+		if err != nil || len(notifications) == 0 {
+			return err
+		}
+		ids := make([]int64, len(notifications))
+		for i, n := range notifications {
+			ids[i] = n.NotificationID
+		}
+		return tx.Model(&models.Notification{}).
+			Where("notification_id IN ?", ids).
+			Update("lease_until", now.Add(lease)).Error
+	})
+	return notifications, err
+}
+
+// MarkSent registra o envio e libera a reserva
+func (r *NotificationRepository) MarkSent(
+	id int64, attempts int, now time.Time,
+) error {
+	return r.DB.Model(&models.Notification{}).
+		Where("notification_id = ?", id).
+		Updates(map[string]interface{}{
+			"status":          models.NotificationSent,
+			"attempts":        attempts,
+			"sent_at":         now,
+			"next_attempt_at": nil,
+			"last_error":      "",
+			"lease_until":     nil,
+		}).Error
+}
+
+// MarkAttemptFailed registra uma tentativa que falhou e libera a reserva.
+// Sem nextAttempt a notificação vai para o estado dead.
+func (r *NotificationRepository) MarkAttemptFailed(
+	id int64, attempts int, nextAttempt *time.Time, cause error,
+) error {
+	status := models.NotificationPending
+	if nextAttempt == nil {
+		status = models.NotificationDead
+	}
+	return r.DB.Model(&models.Notification{}).
+		Where("notification_id = ?", id).
+		Updates(map[string]interface{}{
+			"status":          status,
+			"attempts":        attempts,
+			"next_attempt_at": nextAttempt,
+			"last_error":      cause.Error(),
+			"lease_until":     nil,
+		}).Error
+}
@@ -0,0 +1,319 @@
+// backend/repository/outbox_repository.go
+package repository
+
+import (
+	"ProtocolManager/backend/models"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Só são lidos eventos de transações anteriores ao xmin do snapshot, ou
+// seja, já encerradas. Uma transação ainda aberta tem txid igual ou maior
+// que o xmin, então seus eventos entram depois de tudo que já foi lido.
+const outboxSettled = "txid < pg_snapshot_xmin(pg_current_snapshot())"
+
+// Condição para eventos posteriores a uma posição
+const outboxAfter = "(txid, event_id) > (?::xid8, ?)"
+
+type OutboxRepository struct {
+	DB *gorm.DB
+}
+
+func NewOutboxRepository(db *gorm.DB) *OutboxRepository {
+	return &OutboxRepository{DB: db}
+}
+
+// recordEvent grava um evento no outbox dentro da transação da alteração,
+// de modo que ele só exista se a alteração for confirmada
+func recordEvent(
+	tx *gorm.DB, eventType string, protocolID int, branchID *int,
+	data interface{},
+) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	return tx.Create(&models.OutboxEvent{
+		IdempotencyKey: uuid.NewString(),
+		EventType:      eventType,
+		ProtocolID:     protocolID,
+		BranchID:       branchID,
+		Payload:        string(payload),
+		OccurredAt:     time.Now().UTC(),
+	}).Error
+}
+
+// recordProtocolEvent grava um evento buscando a filial do protocolo, para
+// alterações em registros filhos (histórico, anexos, lembretes)
+func recordProtocolEvent(
+	tx *gorm.DB, eventType string, protocolID int, data interface{},
+) error {
+	var protocol models.Protocol
+	if err := tx.Select("protocol_id", "branch_id").
+		First(&protocol, protocolID).Error; err != nil {
+		return err
+	}
+	return recordEvent(tx, eventType, protocolID, protocol.BranchID, data)
+}
+
+// ErrOutboxLeaseLost indica que a reserva do cursor venceu e outra réplica
+// pode ter assumido o consumidor
+var ErrOutboxLeaseLost = errors.New("reserva do cursor do outbox perdida")
+
+// ClaimCursor reserva o cursor do consumidor por lease numa transação
+// curta. Os eventos são processados fora de qualquer transação; enquanto a
+// reserva valer, outra réplica não pega o mesmo consumidor. Devolve false
+// se o cursor já estiver reservado.
+func (r *OutboxRepository) ClaimCursor(
+	consumer string, now time.Time, lease time.Duration,
+) (models.OutboxCursor, bool, error) {
+	if err := r.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(
+		&models.OutboxCursor{Consumer: consumer},
+	).Error; err != nil {
+		return models.OutboxCursor{}, false, err
+	}
+
+	// O banco guarda microssegundos; a reserva é comparada por igualdade
+	until := now.Add(lease).Truncate(time.Microsecond)
+	var cursors []models.OutboxCursor
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.
+			Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where(
+				"consumer = ? AND (lease_until IS NULL OR lease_until <= ?)",
+				consumer, now,
+			).
+			Limit(1).
+			Find(&cursors).Error
+		if err != nil || len(cursors) == 0 {
+			return err
+		}
+		return tx.Model(&models.OutboxCursor{}).
+			Where("consumer = ?", consumer).
+			Update("lease_until", until).Error
+	})
+	if err != nil || len(cursors) == 0 {
+		return models.OutboxCursor{}, false, err
+	}
+	cursors[0].LeaseUntil = &until
+	return cursors[0], true, nil
+}
+
+// ReleaseCursor libera a reserva obtida com ClaimCursor
+func (r *OutboxRepository) ReleaseCursor(cursor models.OutboxCursor) error {
+	return r.DB.Model(&models.OutboxCursor{}).
+		Where("consumer = ? AND lease_until = ?", cursor.Consumer, cursor.LeaseUntil).
+		Update("lease_until", nil).Error
+}
+
+// EventsAfter devolve até limit eventos de transações encerradas
+// posteriores a after, em ordem
+func (r *OutboxRepository) EventsAfter(
+	tx *gorm.DB, after models.OutboxPosition, limit int,
+) ([]models.OutboxEvent, error) {
+	var events []models.OutboxEvent
+	result := tx.Where(outboxAfter, after.TxID, after.EventID).
+		Where(outboxSettled).
+		Order("txid, event_id").
+		Limit(limit).
+		Find(&events)
+	return events, result.Error
+}
+
+// PositionOf devolve a posição do evento eventID, para retomar a leitura a
+// partir de um id recebido pelo cliente. Devolve false se o evento já foi
+// removido pela retenção ou ocorreu antes de notBefore: os eventos
+// seguintes podem não estar mais no outbox, e o cliente precisa recarregar
+// os dados em vez de retomar.
+func (r *OutboxRepository) PositionOf(eventID int64, notBefore time.Time) (
+	models.OutboxPosition, bool, error,
+) {
+	var events []models.OutboxEvent
+	err := r.DB.Select("txid", "event_id").
+		Where("event_id = ? AND occurred_at >= ?", eventID, notBefore).
+		Where(outboxSettled).
+		Limit(1).
+		Find(&events).Error
+	if err != nil || len(events) == 0 {
+		return models.OutboxPosition{}, false, err
+	}
+	return events[0].Position(), true, nil
+}
+
+// OutboxFailure descreve a falha que interrompeu o lote de um consumidor:
+// o evento, quantas tentativas ele já consumiu e o último erro
+type OutboxFailure struct {
+	EventID  int64
+	Attempts int
+	Cause    error
+}
+
+// AdvanceCursor registra o último evento processado pelo consumidor numa
+// atualização curta. Uma falha não nula fica registrada junto com as
+// tentativas do evento que interrompeu o lote. Só vale enquanto a reserva
+// de cursor for a atual; caso contrário devolve ErrOutboxLeaseLost.
+func (r *OutboxRepository) AdvanceCursor(
+	cursor models.OutboxCursor, last models.OutboxPosition,
+	failure *OutboxFailure, now time.Time,
+) error {
+	return advanceCursor(r.DB, cursor, last, failure, now)
+}
+
+func advanceCursor(
+	tx *gorm.DB, cursor models.OutboxCursor, last models.OutboxPosition,
+	failure *OutboxFailure, now time.Time,
+) error {
+	fields := map[string]interface{}{
+		"last_event_id":   last.EventID,
+		"last_txid":       last.TxID,
+		"last_error":      "",
+		"last_error_at":   nil,
+		"failed_event_id": 0,
+		"attempts":        0,
+	}
+	if failure != nil {
+		fields["last_error"] = failure.Cause.Error()
+		fields["last_error_at"] = now
+		fields["failed_event_id"] = failure.EventID
+		fields["attempts"] = failure.Attempts
+	}
+	result := tx.Model(&models.OutboxCursor{}).
+		Where("consumer = ? AND lease_until = ?", cursor.Consumer, cursor.LeaseUntil).
+		Updates(fields)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrOutboxLeaseLost
+	}
+	return nil
+}
+
+// ParkEvent copia o evento para outbox_dead_letters e avança o cursor
+// além dele na mesma transação curta, para que o consumidor siga adiante
+func (r *OutboxRepository) ParkEvent(
+	cursor models.OutboxCursor, event models.OutboxEvent, attempts int,
+	cause error, now time.Time,
+) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(
+			&models.OutboxDeadLetter{
+				Consumer:       cursor.Consumer,
+				EventID:        event.EventID,
+				IdempotencyKey: event.IdempotencyKey,
+				EventType:      event.EventType,
+				ProtocolID:     event.ProtocolID,
+				Payload:        event.Payload,
+				Attempts:       attempts,
+				LastError:      cause.Error(),
+				ParkedAt:       now,
+			},
+		).Error; err != nil {
+			return err
+		}
+		return advanceCursor(tx, cursor, event.Position(), nil, now)
+	})
+}
+
+// Prune remove eventos anteriores a before que todos os consumidores já
+// processaram
+func (r *OutboxRepository) Prune(before time.Time) (int64, error) {
+	result := r.DB.Where(
+		`occurred_at < ? AND EXISTS (SELECT 1 FROM outbox_cursors)
+		AND (txid, event_id) <= ALL (
+			SELECT last_txid, last_event_id FROM outbox_cursors
+		)`,
+		before,
+	).Delete(&models.OutboxEvent{})
+	return result.RowsAffected, result.Error
+}
+
+// OutboxLag descreve o atraso de um consumidor em relação ao outbox
+type OutboxLag struct {
+	Consumer    string `json:"consumer"`
+	LastEventID int64  `json:"last_event_id"`
+	HeadEventID int64  `json:"head_event_id"`
+	Pending     int64  `json:"pending"`
+	// Idade do evento pendente mais antigo; zero quando em dia
+	LagSeconds  float64    `json:"lag_seconds"`
+	LastError   string     `json:"last_error,omitempty"`
+	LastErrorAt *time.Time `json:"last_error_at,omitempty"`
+	// Evento que está falhando e tentativas já feitas
+	FailedEventID int64 `json:"failed_event_id,omitempty"`
+	Attempts      int   `json:"attempts,omitempty"`
+	// Eventos abandonados pelo consumidor
+	DeadLetters int64     `json:"dead_letters"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// Lag calcula o atraso de cada consumidor registrado
+func (r *OutboxRepository) Lag(now time.Time) ([]OutboxLag, error) {
+	var cursors []models.OutboxCursor
+	if err := r.DB.Order("consumer").Find(&cursors).Error; err != nil {
+		return nil, err
+	}
+
+	head, err := r.Head()
+	if err != nil {
+		return nil, err
+	}
+
+	lags := make([]OutboxLag, 0, len(cursors))
+	for _, cursor := range cursors {
+		lag := OutboxLag{
+			Consumer:    cursor.Consumer,
+			LastEventID: cursor.LastEventID,
+			HeadEventID: head.EventID,
+			LastError:   cursor.LastError,
+			LastErrorAt: cursor.LastErrorAt,
+			UpdatedAt:   cursor.UpdatedAt,
+
+			FailedEventID: cursor.FailedEventID,
+			Attempts:      cursor.Attempts,
+		}
+
+		if err := r.DB.Model(&models.OutboxDeadLetter{}).
+			Where("consumer = ?", cursor.Consumer).
+			Count(&lag.DeadLetters).Error; err != nil {
+			return nil, err
+		}
+
+		var pending struct {
+			Count  int64
+			Oldest *time.Time
+		}
+		if err := r.DB.Model(&models.OutboxEvent{}).
+			Select("COUNT(*) AS count, MIN(occurred_at) AS oldest").
+			Where(outboxAfter, cursor.LastTxID, cursor.LastEventID).
+			Scan(&pending).Error; err != nil {
+			return nil, err
+		}
+		lag.Pending = pending.Count
+		if pending.Oldest != nil {
+			lag.LagSeconds = now.Sub(*pending.Oldest).Seconds()
+		}
+		lags = append(lags, lag)
+	}
+	return lags, nil
+}
+
+// Head devolve a posição do último evento de transação encerrada, ou a
+// posição zero com o outbox vazio
+func (r *OutboxRepository) Head() (models.OutboxPosition, error) {
+	var events []models.OutboxEvent
+	err := r.DB.Select("txid", "event_id").
+		Where(outboxSettled).
+		Order("txid DESC, event_id DESC").
+		Limit(1).
+		Find(&events).Error
+	if err != nil || len(events) == 0 {
+		return models.OutboxPosition{}, err
+	}
+	return events[0].Position(), nil
+}
@@ -0,0 +1,136 @@
+// backend/repository/outbox_repository_test.go
+package repository
+
+import (
+	"ProtocolManager/backend/models"
+	"ProtocolManager/backend/testdb"
+	"errors"
+	"testing"
+	"time"
+)
+
+// Um evento com event_id menor confirmado depois de um maior não pode ficar
+// para trás do cursor
+func TestEventsAfterDoesNotSkipLateCommits(t *testing.T) {
+	db := testdb.Open(t)
+	repo := NewOutboxRepository(db)
+
+	// early obtém o txid antes de late, mas grava o evento depois
+	early := db.Begin()
+	defer early.Rollback()
+	if err := early.Exec("SELECT pg_current_xact_id()").Error; err != nil {
+		t.Fatal(err)
+	}
+	late := db.Begin()
+	defer late.Rollback()
+
+	if err := recordEvent(late, models.EventProtocolUpdated, 1, nil, "late"); err != nil {
+		t.Fatal(err)
+	}
+	if err := recordEvent(early, models.EventProtocolUpdated, 1, nil, "early"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Só early confirmada: late ainda está aberta e seu evento não existe
+	if err := early.Commit().Error; err != nil {
+		t.Fatal(err)
+	}
+	events, err := repo.EventsAfter(db, models.OutboxPosition{}, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || events[0].Payload != `"early"` {
+		t.Fatalf("before the late commit: got %+v", events)
+	}
+	cursor := events[0].Position()
+
+	if err := late.Commit().Error; err != nil {
+		t.Fatal(err)
+	}
+	events, err = repo.EventsAfter(db, cursor, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || events[0].Payload != `"late"` {
+		t.Fatalf("after the late commit: got %+v", events)
+	}
+	if events[0].EventID > cursor.EventID {
+		t.Fatalf("expected the late event to have the smaller event_id")
+	}
+}
+
+// Enquanto a reserva vale outra réplica não pega o cursor; depois que ela
+// vence, a réplica antiga não consegue mais movê-lo
+func TestClaimCursorFencesExpiredLease(t *testing.T) {
+	db := testdb.Open(t)
+	repo := NewOutboxRepository(db)
+	now := time.Now()
+
+	stale, ok, err := repo.ClaimCursor("test", now, time.Minute)
+	if err != nil || !ok {
+		t.Fatalf("first claim: ok=%v err=%v", ok, err)
+	}
+	if _, ok, err := repo.ClaimCursor("test", now, time.Minute); err != nil || ok {
+		t.Fatalf("claim while leased: ok=%v err=%v", ok, err)
+	}
+
+	current, ok, err := repo.ClaimCursor("test", now.Add(2*time.Minute), time.Minute)
+	if err != nil || !ok {
+		t.Fatalf("claim after expiry: ok=%v err=%v", ok, err)
+	}
+
+	position := models.OutboxPosition{TxID: 1, EventID: 1}
+	err = repo.AdvanceCursor(stale, position, nil, now)
+	if !errors.Is(err, ErrOutboxLeaseLost) {
+		t.Fatalf("advance with the stale lease: got %v", err)
+	}
+	if err := repo.AdvanceCursor(current, position, nil, now); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := repo.ReleaseCursor(current); err != nil {
+		t.Fatal(err)
+	}
+	cursor, ok, err := repo.ClaimCursor("test", now.Add(2*time.Minute), time.Minute)
+	if err != nil || !ok {
+		t.Fatalf("claim after release: ok=%v err=%v", ok, err)
+	}
+	if cursor.Position() != position {
+		t.Errorf("cursor at %+v, want %+v", cursor.Position(), position)
+	}
+}
+
+// Um cliente não retoma a partir de um evento removido ou fora da janela
+func TestPositionOfRequiresAKeptEvent(t *testing.T) {
+	db := testdb.Open(t)
+	repo := NewOutboxRepository(db)
+
+	event := models.OutboxEvent{
+		IdempotencyKey: "event-1",
+		EventType:      models.EventProtocolUpdated,
+		ProtocolID:     1,
+		Payload:        "{}",
+		OccurredAt:     time.Now().Add(-2 * time.Hour),
+	}
+	testdb.Create(t, db, &event)
+
+	if _, found, err := repo.PositionOf(
+		event.EventID, time.Now().Add(-3*time.Hour),
+	); err != nil || !found {
+		t.Fatalf("kept event: found=%v err=%v", found, err)
+	}
+	if _, found, err := repo.PositionOf(
+		event.EventID, time.Now().Add(-time.Hour),
+	); err != nil || found {
+		t.Fatalf("event outside the window: found=%v err=%v", found, err)
+	}
+
+	if err := db.Delete(&event).Error; err != nil {
+		t.Fatal(err)
+	}
+	if _, found, err := repo.PositionOf(
+		event.EventID, time.Now().Add(-3*time.Hour),
+	); err != nil || found {
+		t.Fatalf("pruned event: found=%v err=%v", found, err)
+	}
+}
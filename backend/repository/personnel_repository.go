@@ -13,29 +13,42 @@ func NewPersonnelRepository(db *gorm.DB) *PersonnelRepository {
 	return &PersonnelRepository{DB: db}
 }
 
-func (r *PersonnelRepository) GetAll() ([]models.SalesPersonnel, error) {
+func (r *PersonnelRepository) GetAll(scope Scope) (
+	[]models.SalesPersonnel, error,
+) {
 	var personnel []models.SalesPersonnel
-	result := r.DB.Find(&personnel)
+	result := scope.Apply(r.DB, "sales_personnel").Find(&personnel)
 	return personnel, result.Error
 }
 
-func (r *PersonnelRepository) GetByID(id int) (models.SalesPersonnel, error) {
+func (r *PersonnelRepository) GetByID(scope Scope, id int) (
+	models.SalesPersonnel, error,
+) {
 	var personnel models.SalesPersonnel
-	result := r.DB.First(&personnel, id)
+	result := scope.Apply(r.DB, "sales_personnel").First(&personnel, id)
 	return personnel, result.Error
 }
 
-func (r *PersonnelRepository) Create(personnel models.SalesPersonnel) (
-	models.SalesPersonnel, error,
-) {
+func (r *PersonnelRepository) Create(
+	scope Scope, personnel models.SalesPersonnel,
+) (models.SalesPersonnel, error) {
+	if !scope.Allows(personnel.BranchID) {
+		return personnel, ErrOutOfScope
+	}
 	result := r.DB.Create(&personnel)
 	return personnel, result.Error
 }
 
 func (r *PersonnelRepository) Update(
-	id int, personnel models.SalesPersonnel,
+	scope Scope, id int, personnel models.SalesPersonnel,
 ) error {
-	result := r.DB.Model(&models.SalesPersonnel{}).Where(
+	if !scope.Allows(personnel.BranchID) {
+		return ErrOutOfScope
+	}
+
+	result := scope.Apply(
+		r.DB.Model(&models.SalesPersonnel{}), "sales_personnel",
+	).Where(
 		"personnel_id = ?", id,
 	).Updates(
 		map[string]interface{}{
@@ -47,10 +60,12 @@ func (r *PersonnelRepository) Update(
 			"active":     personnel.Active,
 		},
 	)
-	return result.Error
+	return requireAffected(result)
 }
 
-func (r *PersonnelRepository) Delete(id int) error {
-	result := r.DB.Delete(&models.SalesPersonnel{}, id)
-	return result.Error
+func (r *PersonnelRepository) Delete(scope Scope, id int) error {
+	result := scope.Apply(r.DB, "sales_personnel").Delete(
+		&models.SalesPersonnel{}, id,
+	)
+	return requireAffected(result)
 }
@@ -3,9 +3,17 @@ package repository
 
 import (
 	"ProtocolManager/backend/models"
+	"errors"
+	"time"
+
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// ErrAttachmentQuotaExceeded indica que o anexo ultrapassaria a cota de
+// armazenamento do protocolo
+var ErrAttachmentQuotaExceeded = errors.New("cota de anexos do protocolo excedida")
+
 type ProtocolAttachmentRepository struct {
 	DB *gorm.DB
 }
@@ -14,41 +22,516 @@ func NewProtocolAttachmentRepository(db *gorm.DB) *ProtocolAttachmentRepository
 	return &ProtocolAttachmentRepository{DB: db}
 }
 
-func (r *ProtocolAttachmentRepository) GetAll() (
+func (r *ProtocolAttachmentRepository) GetAll(scope Scope) (
 	[]models.ProtocolAttachment, error,
 ) {
 	var attachments []models.ProtocolAttachment
-	result := r.DB.Preload("UploadedByAgent").Find(&attachments)
+	result := scope.ApplyProtocol(r.DB, "protocol_attachments").
+		Preload("UploadedByAgent").
+		Find(&attachments)
 	return attachments, result.Error
 }
 
-func (r *ProtocolAttachmentRepository) GetByID(id int) (
+func (r *ProtocolAttachmentRepository) GetByID(scope Scope, id int) (
 	models.ProtocolAttachment, error,
 ) {
 	var attachment models.ProtocolAttachment
-	result := r.DB.Preload("UploadedByAgent").First(&attachment, id)
+	result := scope.ApplyProtocol(r.DB, "protocol_attachments").
+		Preload("UploadedByAgent").
+		First(&attachment, id)
 	return attachment, result.Error
 }
 
-func (r *ProtocolAttachmentRepository) GetByProtocolID(protocolID int) (
-	[]models.ProtocolAttachment, error,
-) {
+func (r *ProtocolAttachmentRepository) GetByProtocolID(
+	scope Scope, protocolID int,
+) ([]models.ProtocolAttachment, error) {
 	var attachments []models.ProtocolAttachment
-	result := r.DB.Where("protocol_id = ?", protocolID).
+	result := scope.ApplyProtocol(r.DB, "protocol_attachments").
+		Where("protocol_id = ?", protocolID).
 		Preload("UploadedByAgent").
 		Order("uploaded_at DESC").
 		Find(&attachments)
 	return attachments, result.Error
 }
 
-func (r *ProtocolAttachmentRepository) Create(attachment models.ProtocolAttachment) (
-	models.ProtocolAttachment, error,
-) {
-	result := r.DB.Create(&attachment)
-	return attachment, result.Error
+// UploadTarget confirma que o protocolo existe e é visível antes de gravar
+// o arquivo e devolve o tipo do protocolo e o espaço já ocupado pelos
+// anexos, para aplicar a política de upload
+func (r *ProtocolAttachmentRepository) UploadTarget(
+	scope Scope, protocolID int,
+) (models.ProtocolType, int64, error) {
+	var protocolType models.ProtocolType
+	if err := scope.CheckProtocol(r.DB, protocolID); err != nil {
+		return protocolType, 0, err
+	}
+
+	err := r.DB.
+		Joins("JOIN protocols ON protocols.type_id = protocol_types.type_id").
+		Where("protocols.protocol_id = ?", protocolID).
+		First(&protocolType).Error
+	if err != nil {
+		return protocolType, 0, err
+	}
+
+	used, err := attachmentUsage(r.DB, protocolID)
+	return protocolType, used, err
+}
+
+// attachmentUsage soma todas as versões dos anexos do protocolo, que
+// continuam ocupando espaço depois de substituídas
+func attachmentUsage(db *gorm.DB, protocolID int) (int64, error) {
+	var used int64
+	err := db.Model(&models.AttachmentVersion{}).
+		Joins(
+			"JOIN protocol_attachments ON protocol_attachments.attachment_id = "+
+				"protocol_attachment_versions.attachment_id",
+		).
+		Where("protocol_attachments.protocol_id = ?", protocolID).
+		Select("COALESCE(SUM(protocol_attachment_versions.file_size), 0)").
+		Scan(&used).Error
+	return used, err
+}
+
+// checkQuota confere a cota com o protocolo travado, porque uploads
+// simultâneos passam juntos pela verificação feita antes do envio
+func checkQuota(tx *gorm.DB, protocolID int, size, quota int64) error {
+	if quota <= 0 {
+		return nil
+	}
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Select("protocol_id").
+		First(&models.Protocol{}, protocolID).Error; err != nil {
+		return err
+	}
+	used, err := attachmentUsage(tx, protocolID)
+	if err != nil {
+		return err
+	}
+	if used+size > quota {
+		return ErrAttachmentQuotaExceeded
+	}
+	return nil
+}
+
+// Create grava o anexo e sua primeira versão. Com quota > 0 o espaço
+// ocupado é conferido de novo dentro da transação.
+func (r *ProtocolAttachmentRepository) Create(
+	scope Scope, attachment models.ProtocolAttachment, quota int64,
+) (models.ProtocolAttachment, error) {
+	if err := scope.CheckProtocol(r.DB, attachment.ProtocolID); err != nil {
+		return attachment, err
+	}
+
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		err := checkQuota(tx, attachment.ProtocolID, attachment.FileSize, quota)
+		if err != nil {
+			return err
+		}
+
+		attachment.Version = 1
+		if err := tx.Omit("UploadedByAgent").Create(&attachment).Error; err != nil {
+			return err
+		}
+		version := models.NewAttachmentVersion(attachment)
+		if err := tx.Omit("UploadedByAgent").Create(&version).Error; err != nil {
+			return err
+		}
+		return recordProtocolEvent(
+			tx, models.EventAttachmentAdded, attachment.ProtocolID,
+			models.NewAttachmentEventData(attachment),
+		)
+	})
+	return attachment, err
+}
+
+// CreateVersion grava um novo conteúdo para o anexo. As versões anteriores
+// são mantidas e o anexo passa a apontar para a nova.
+func (r *ProtocolAttachmentRepository) CreateVersion(
+	scope Scope, attachmentID int, version models.AttachmentVersion,
+	quota int64,
+) (models.ProtocolAttachment, error) {
+	var attachment models.ProtocolAttachment
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		// O anexo fica travado para duas versões não receberem o mesmo número
+		if err := scope.ApplyProtocol(tx, "protocol_attachments").
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&attachment, attachmentID).Error; err != nil {
+			return err
+		}
+		err := checkQuota(tx, attachment.ProtocolID, version.FileSize, quota)
+		if err != nil {
+			return err
+		}
+
+		version.AttachmentID = attachmentID
+		version.Version = attachment.Version + 1
+		if err := tx.Omit("UploadedByAgent").Create(&version).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&models.ProtocolAttachment{}).
+			Where("attachment_id = ?", attachmentID).
+			Updates(map[string]interface{}{
+				"version":      version.Version,
+				"file_name":    version.FileName,
+				"file_path":    version.FilePath,
+				"file_size":    version.FileSize,
+				"content_type": version.ContentType,
+				"uploaded_by":  version.UploadedBy,
+				"uploaded_at":  version.UploadedAt,
+				"checksum":     version.Checksum,
+				"scan_status":  version.ScanStatus,
+				"scan_result":  version.ScanResult,
+				"scanned_at":   version.ScannedAt,
+				// A miniatura da versão anterior deixa de valer
+				"thumbnail_status": version.ThumbnailStatus,
+				"thumbnail_path":   version.ThumbnailPath,
+			}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Preload("UploadedByAgent").
+			First(&attachment, attachmentID).Error; err != nil {
+			return err
+		}
+		return recordProtocolEvent(
+			tx, models.EventAttachmentVersionAdded, attachment.ProtocolID,
+			models.NewAttachmentEventData(attachment),
+		)
+	})
+	return attachment, err
+}
+
+// GetVersions lista as versões do anexo, da mais nova para a mais antiga
+func (r *ProtocolAttachmentRepository) GetVersions(
+	scope Scope, attachmentID int,
+) ([]models.AttachmentVersion, error) {
+	var attachment models.ProtocolAttachment
+	if err := scope.ApplyProtocol(r.DB, "protocol_attachments").
+		Select("attachment_id").
+		First(&attachment, attachmentID).Error; err != nil {
+		return nil, err
+	}
+
+	var versions []models.AttachmentVersion
+	result := r.DB.Where("attachment_id = ?", attachmentID).
+		Preload("UploadedByAgent").
+		Order("version DESC").
+		Find(&versions)
+	return versions, result.Error
 }
 
-func (r *ProtocolAttachmentRepository) Delete(id int) error {
-	result := r.DB.Delete(&models.ProtocolAttachment{}, id)
-	return result.Error
+// GetVersion devolve uma versão do anexo, com as mesmas regras de escopo de
+// GetByID; version 0 devolve a atual
+func (r *ProtocolAttachmentRepository) GetVersion(
+	scope Scope, attachmentID, version int,
+) (models.AttachmentVersion, error) {
+	var attachment models.ProtocolAttachment
+	if err := scope.ApplyProtocol(r.DB, "protocol_attachments").
+		Select("attachment_id", "version").
+		First(&attachment, attachmentID).Error; err != nil {
+		return models.AttachmentVersion{}, err
+	}
+	if version == 0 {
+		version = attachment.Version
+	}
+
+	var v models.AttachmentVersion
+	result := r.DB.
+		Where("attachment_id = ? AND version = ?", attachmentID, version).
+		First(&v)
+	return v, result.Error
+}
+
+// RecordChecksum grava o SHA-256 de uma versão enviada antes da verificação
+// de integridade e, se ela ainda for a atual, do anexo. Um checksum já
+// gravado nunca é substituído.
+func (r *ProtocolAttachmentRepository) RecordChecksum(
+	version models.AttachmentVersion, checksum string,
+) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.AttachmentVersion{}).
+			Where("version_id = ? AND COALESCE(checksum, '') = ''", version.VersionID).
+			Update("checksum", checksum).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.ProtocolAttachment{}).
+			Where(
+				"attachment_id = ? AND version = ? AND COALESCE(checksum, '') = ''",
+				version.AttachmentID, version.Version,
+			).
+			Update("checksum", checksum).Error
+	})
+}
+
+// Delete apaga o anexo com todas as versões e devolve as chaves dos
+// arquivos e miniaturas, removidos pelo chamador depois do commit
+func (r *ProtocolAttachmentRepository) Delete(scope Scope, id int) ([]string, error) {
+	var filePaths []string
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		var attachment models.ProtocolAttachment
+		if err := scope.ApplyProtocol(tx, "protocol_attachments").
+			First(&attachment, id).Error; err != nil {
+			return err
+		}
+
+		paths, err := versionFiles(tx.Where("attachment_id = ?", id))
+		if err != nil {
+			return err
+		}
+		filePaths = paths
+		if err := tx.Where("attachment_id = ?", id).
+			Delete(&models.AttachmentVersion{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&models.ProtocolAttachment{}, id).Error; err != nil {
+			return err
+		}
+		return recordProtocolEvent(
+			tx, models.EventAttachmentDeleted, attachment.ProtocolID,
+			models.NewAttachmentEventData(attachment),
+		)
+	})
+	return filePaths, err
+}
+
+// versionFiles devolve as chaves dos arquivos e das miniaturas das versões
+// selecionadas por query
+func versionFiles(query *gorm.DB) ([]string, error) {
+	var versions []models.AttachmentVersion
+	if err := query.Model(&models.AttachmentVersion{}).
+		Select("file_path", "thumbnail_path").
+		Find(&versions).Error; err != nil {
+		return nil, err
+	}
+
+	paths := make([]string, 0, len(versions))
+	for _, version := range versions {
+		paths = append(paths, version.FilePath)
+		if version.ThumbnailPath != "" {
+			paths = append(paths, version.ThumbnailPath)
+		}
+	}
+	return paths, nil
+}
+
+// ClaimPendingScans reserva até limit versões aguardando o antivírus numa
+// transação curta e as devolve. SKIP LOCKED e a reserva (scan_lease_until)
+// impedem que outra réplica varra o mesmo arquivo enquanto o clamd responde,
+// fora de qualquer transação; se o processo cair, a versão volta a ficar
+// disponível quando a reserva expirar.
+func (r *ProtocolAttachmentRepository) ClaimPendingScans(
+	now time.Time, limit int, lease time.Duration,
+) ([]models.AttachmentVersion, error) {
+	var versions []models.AttachmentVersion
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.
+			Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where(
+				`scan_status = ? AND (next_scan_at IS NULL OR next_scan_at <= ?)
+				AND (scan_lease_until IS NULL OR scan_lease_until <= ?)`,
+				models.ScanPending, now, now,
+			).
+			Order("version_id").
+			Limit(limit).
+			Find(&versions).Error
+		if err != nil || len(versions) == 0 {
+			return err
+		}
+		ids := make([]int, len(versions))
+		for i, version := range versions {
+			ids[i] = version.VersionID
+		}
+		return tx.Model(&models.AttachmentVersion{}).
+			Where("version_id IN ?", ids).
+			Update("scan_lease_until", now.Add(lease)).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
+
+// MarkScanned grava o veredito do antivírus na versão e, se ela ainda for
+// a atual, no anexo, e libera a reserva. Uma versão que já saiu de pending
+// (reserva expirada e varrida por outra réplica) não é alterada nem
+// anunciada de novo.
+func (r *ProtocolAttachmentRepository) MarkScanned(
+	version models.AttachmentVersion, status, result string,
+	scannedAt time.Time,
+) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		updated := tx.Model(&models.AttachmentVersion{}).
+			Where("version_id = ? AND scan_status = ?", version.VersionID, models.ScanPending).
+			Updates(map[string]interface{}{
+				"scan_status":      status,
+				"scan_result":      result,
+				"scanned_at":       scannedAt,
+				"next_scan_at":     nil,
+				"scan_lease_until": nil,
+			})
+		if updated.Error != nil || updated.RowsAffected == 0 {
+			return updated.Error
+		}
+
+		if err := tx.Model(&models.ProtocolAttachment{}).
+			Where("attachment_id = ? AND version = ?", version.AttachmentID, version.Version).
+			Updates(map[string]interface{}{
+				"scan_status": status,
+				"scan_result": result,
+				"scanned_at":  scannedAt,
+			}).Error; err != nil {
+			return err
+		}
+
+		var attachment models.ProtocolAttachment
+		if err := tx.First(&attachment, version.AttachmentID).Error; err != nil {
+			return err
+		}
+		version.ScanStatus = status
+		version.ScanResult = result
+		version.ScannedAt = &scannedAt
+		return recordProtocolEvent(
+			tx, models.EventAttachmentScanned, attachment.ProtocolID,
+			models.NewAttachmentVersionEventData(attachment, version),
+		)
+	})
+}
+
+// MarkScanFailed mantém a versão pendente, agenda uma nova tentativa e
+// libera a reserva
+func (r *ProtocolAttachmentRepository) MarkScanFailed(
+	versionID, attempts int, nextScan time.Time, cause error,
+) error {
+	return r.DB.Model(&models.AttachmentVersion{}).
+		Where("version_id = ?", versionID).
+		Updates(map[string]interface{}{
+			"scan_attempts":    attempts,
+			"scan_result":      cause.Error(),
+			"next_scan_at":     nextScan,
+			"scan_lease_until": nil,
+		}).Error
+}
+
+// ClaimPendingThumbnails reserva até limit versões já verificadas e ainda
+// sem miniatura numa transação curta e as devolve. SKIP LOCKED e a reserva
+// (thumbnail_lease_until) impedem que outra réplica renderize a mesma
+// versão enquanto o original é lido e convertido, fora de qualquer
+// transação.
+func (r *ProtocolAttachmentRepository) ClaimPendingThumbnails(
+	now time.Time, limit int, lease time.Duration,
+) ([]models.AttachmentVersion, error) {
+	var versions []models.AttachmentVersion
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.
+			Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where(
+				`scan_status = ? AND thumbnail_status = ?
+				AND (thumbnail_lease_until IS NULL OR thumbnail_lease_until <= ?)`,
+				models.ScanClean, models.ThumbnailPending, now,
+			).
+			Order("version_id").
+			Limit(limit).
+			Find(&versions).Error
+		if err != nil || len(versions) == 0 {
+			return err
+		}
+		ids := make([]int, len(versions))
+		for i, version := range versions {
+			ids[i] = version.VersionID
+		}
+		return tx.Model(&models.AttachmentVersion{}).
+			Where("version_id IN ?", ids).
+			Update("thumbnail_lease_until", now.Add(lease)).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
+
+// MarkThumbnail grava a situação da miniatura na versão e, se ela ainda
+// for a atual, no anexo, e libera a reserva. Só as miniaturas prontas são
+// anunciadas, para o cliente recarregar a lista de anexos. Uma versão que
+// já saiu de pending não é alterada nem anunciada de novo.
+func (r *ProtocolAttachmentRepository) MarkThumbnail(
+	version models.AttachmentVersion, status, path string,
+) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		updated := tx.Model(&models.AttachmentVersion{}).
+			Where(
+				"version_id = ? AND thumbnail_status = ?",
+				version.VersionID, models.ThumbnailPending,
+			).
+			Updates(map[string]interface{}{
+				"thumbnail_status":      status,
+				"thumbnail_path":        path,
+				"thumbnail_lease_until": nil,
+			})
+		if updated.Error != nil || updated.RowsAffected == 0 {
+			return updated.Error
+		}
+
+		if err := tx.Model(&models.ProtocolAttachment{}).
+			Where("attachment_id = ? AND version = ?", version.AttachmentID, version.Version).
+			Updates(map[string]interface{}{
+				"thumbnail_status": status,
+				"thumbnail_path":   path,
+			}).Error; err != nil {
+			return err
+		}
+		if status != models.ThumbnailReady {
+			return nil
+		}
+
+		var attachment models.ProtocolAttachment
+		if err := tx.First(&attachment, version.AttachmentID).Error; err != nil {
+			return err
+		}
+		version.ThumbnailStatus = status
+		version.ThumbnailPath = path
+		return recordProtocolEvent(
+			tx, models.EventAttachmentThumbnailReady, attachment.ProtocolID,
+			models.NewAttachmentVersionEventData(attachment, version),
+		)
+	})
+}
+
+// ReleaseThumbnail libera a reserva mantendo a versão pendente, para uma
+// nova tentativa no próximo ciclo
+func (r *ProtocolAttachmentRepository) ReleaseThumbnail(versionID int) error {
+	return r.DB.Model(&models.AttachmentVersion{}).
+		Where("version_id = ?", versionID).
+		Update("thumbnail_lease_until", nil).Error
+}
+
+// GetArchiveEntries devolve o protocolo e as versões que entram no arquivo
+// ZIP dos anexos: só as atuais ou, com allVersions, todas
+func (r *ProtocolAttachmentRepository) GetArchiveEntries(
+	scope Scope, protocolID int, allVersions bool,
+) (models.Protocol, []models.AttachmentVersion, error) {
+	var protocol models.Protocol
+	if err := scope.Apply(r.DB, "protocols").
+		First(&protocol, protocolID).Error; err != nil {
+		return protocol, nil, err
+	}
+
+	query := r.DB.
+		Joins(
+			"JOIN protocol_attachments ON protocol_attachments.attachment_id = "+
+				"protocol_attachment_versions.attachment_id",
+		).
+		Where("protocol_attachments.protocol_id = ?", protocolID)
+	if !allVersions {
+		query = query.Where(
+			"protocol_attachment_versions.version = protocol_attachments.version",
+		)
+	}
+
+	var versions []models.AttachmentVersion
+	err := query.Preload("UploadedByAgent").
+		Order("protocol_attachment_versions.attachment_id").
+		Order("protocol_attachment_versions.version").
+		Find(&versions).Error
+	return protocol, versions, err
 }
@@ -14,27 +14,30 @@ func NewProtocolHistoryRepository(db *gorm.DB) *ProtocolHistoryRepository {
 	return &ProtocolHistoryRepository{DB: db}
 }
 
-func (r *ProtocolHistoryRepository) GetAll() ([]models.ProtocolHistory, error) {
+func (r *ProtocolHistoryRepository) GetAll(scope Scope) (
+	[]models.ProtocolHistory, error,
+) {
 	var histories []models.ProtocolHistory
-	result := r.DB.Preload("PreviousStatus").Preload("NewStatus").Preload("CreatedByAgent").Find(&histories)
+	result := scope.ApplyProtocol(r.DB, "protocol_history").Preload("PreviousStatus").Preload("NewStatus").Preload("CreatedByAgent").Find(&histories)
 	return histories, result.Error
 }
 
-func (r *ProtocolHistoryRepository) GetByID(id int) (
+func (r *ProtocolHistoryRepository) GetByID(scope Scope, id int) (
 	models.ProtocolHistory, error,
 ) {
 	var history models.ProtocolHistory
-	result := r.DB.Preload("PreviousStatus").Preload("NewStatus").Preload("CreatedByAgent").First(
+	result := scope.ApplyProtocol(r.DB, "protocol_history").Preload("PreviousStatus").Preload("NewStatus").Preload("CreatedByAgent").First(
 		&history, id,
 	)
 	return history, result.Error
 }
 
-func (r *ProtocolHistoryRepository) GetByProtocolID(protocolID int) (
-	[]models.ProtocolHistory, error,
-) {
+func (r *ProtocolHistoryRepository) GetByProtocolID(
+	scope Scope, protocolID int,
+) ([]models.ProtocolHistory, error) {
 	var histories []models.ProtocolHistory
-	result := r.DB.Where("protocol_id = ?", protocolID).
+	result := scope.ApplyProtocol(r.DB, "protocol_history").
+		Where("protocol_id = ?", protocolID).
 		Preload("PreviousStatus").
 		Preload("NewStatus").
 		Preload("CreatedByAgent").
@@ -43,9 +46,23 @@ func (r *ProtocolHistoryRepository) GetByProtocolID(protocolID int) (
 	return histories, result.Error
 }
 
-func (r *ProtocolHistoryRepository) Create(history models.ProtocolHistory) (
-	models.ProtocolHistory, error,
-) {
-	result := r.DB.Create(&history)
-	return history, result.Error
+func (r *ProtocolHistoryRepository) Create(
+	scope Scope, history models.ProtocolHistory,
+) (models.ProtocolHistory, error) {
+	if err := scope.CheckProtocol(r.DB, history.ProtocolID); err != nil {
+		return history, err
+	}
+
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(
+			"PreviousStatus", "NewStatus", "CreatedByAgent",
+		).Create(&history).Error; err != nil {
+			return err
+		}
+		return recordProtocolEvent(
+			tx, models.EventHistoryCreated, history.ProtocolID,
+			models.NewHistoryEventData(history),
+		)
+	})
+	return history, err
 }
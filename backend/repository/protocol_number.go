@@ -0,0 +1,148 @@
+// backend/repository/protocol_number.go
+package repository
+
+import (
+	"ProtocolManager/backend/models"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const DefaultProtocolNumberFormat = "{YYYY}-{SEQ:04}"
+
+// Código usado em {BRANCH} quando o protocolo não tem filial
+const noBranchCode = "GERAL"
+
+var numberToken = regexp.MustCompile(`\{([A-Z]+)(?::(\d+))?\}`)
+
+// ProtocolNumberGenerator emite números de protocolo a partir de um formato
+// como "{BRANCH}-{YYYY}-{SEQ:05}". Tokens aceitos: {YYYY}, {YY}, {BRANCH}
+// (código da filial), {TYPE} (id do tipo) e {SEQ:n} (sequência com n
+// dígitos). A sequência reinicia a cada ano e é separada por filial e/ou
+// tipo quando {BRANCH} e/ou {TYPE} aparecem no formato.
+type ProtocolNumberGenerator struct {
+	Format    string
+	perBranch bool
+	perType   bool
+}
+
+func NewProtocolNumberGenerator(format string) (
+	*ProtocolNumberGenerator, error,
+) {
+	if format == "" {
+		format = DefaultProtocolNumberFormat
+	}
+
+	g := &ProtocolNumberGenerator{Format: format}
+	hasSeq := false
+	for _, m := range numberToken.FindAllStringSubmatch(format, -1) {
+		switch m[1] {
+		case "SEQ":
+			hasSeq = true
+		case "BRANCH":
+			g.perBranch = true
+		case "TYPE":
+			g.perType = true
+		case "YYYY", "YY":
+		default:
+			return nil, fmt.Errorf("token desconhecido no formato do protocolo: %s", m[0])
+		}
+	}
+	if !hasSeq {
+		return nil, fmt.Errorf("formato do protocolo precisa conter {SEQ}: %s", format)
+	}
+	return g, nil
+}
+
+// Next reserva o próximo número para o protocolo. Deve ser chamado dentro da
+// transação que insere o protocolo: o UPSERT trava a linha da sequência até
+// o commit, então criações concorrentes no mesmo escopo são serializadas, e
+// um rollback devolve o número sem deixar lacunas.
+func (g *ProtocolNumberGenerator) Next(
+	tx *gorm.DB, protocol models.Protocol, now time.Time,
+) (string, error) {
+	branchCode := noBranchCode
+	if g.perBranch && protocol.BranchID != nil {
+		var branch models.Branch
+		if err := tx.Select("branch_code").
+			First(&branch, *protocol.BranchID).Error; err != nil {
+			return "", fmt.Errorf("filial do protocolo não encontrada: %w", err)
+		}
+		branchCode = branch.BranchCode
+	}
+
+	key := g.scopeKey(protocol)
+	year := now.Year()
+
+	// Números emitidos antes desta sequência existir são pulados, então a
+	// migração a partir da numeração antiga não gera duplicatas
+	for {
+		var seq int
+		err := tx.Raw(
+			`INSERT INTO protocol_sequences (scope_key, year, last_value)
+			VALUES (?, ?, 1)
+			ON CONFLICT (scope_key, year)
+			DO UPDATE SET last_value = protocol_sequences.last_value + 1
+			RETURNING last_value`,
+			key, year,
+		).Scan(&seq).Error
+		if err != nil {
+			return "", err
+		}
+
+		number := g.render(seq, year, branchCode, protocol.TypeID)
+
+		var count int64
+		if err := tx.Model(&models.Protocol{}).
+			Where("protocol_number = ?", number).
+			Count(&count).Error; err != nil {
+			return "", err
+		}
+		if count == 0 {
+			return number, nil
+		}
+	}
+}
+
+func (g *ProtocolNumberGenerator) scopeKey(protocol models.Protocol) string {
+	parts := []string{}
+	if g.perBranch {
+		branch := "none"
+		if protocol.BranchID != nil {
+			branch = strconv.Itoa(*protocol.BranchID)
+		}
+		parts = append(parts, "branch:"+branch)
+	}
+	if g.perType {
+		parts = append(parts, "type:"+strconv.Itoa(protocol.TypeID))
+	}
+	if len(parts) == 0 {
+		return "global"
+	}
+	return strings.Join(parts, "|")
+}
+
+func (g *ProtocolNumberGenerator) render(
+	seq, year int, branchCode string, typeID int,
+) string {
+	return numberToken.ReplaceAllStringFunc(g.Format, func(token string) string {
+		m := numberToken.FindStringSubmatch(token)
+		switch m[1] {
+		case "YYYY":
+			return fmt.Sprintf("%04d", year)
+		case "YY":
+			return fmt.Sprintf("%02d", year%100)
+		case "BRANCH":
+			return branchCode
+		case "TYPE":
+			return strconv.Itoa(typeID)
+		default: // SEQ
+			width, _ := strconv.Atoi(m[2])
+			return fmt.Sprintf("%0*d", width, seq)
+		}
+	})
+}
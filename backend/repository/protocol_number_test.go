@@ -0,0 +1,130 @@
+// backend/repository/protocol_number_test.go
+package repository
+
+import (
+	"ProtocolManager/backend/models"
+	"ProtocolManager/backend/testdb"
+	"sync"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const concurrentCreates = 50
+
+func TestProtocolNumberGeneratorFormats(t *testing.T) {
+	tests := []struct {
+		format string
+		seq    int
+		want   string
+	}{
+		{"{YYYY}-{SEQ:04}", 7, "2026-0007"},
+		{"{BRANCH}-{YY}-{SEQ:05}", 42, "SP-26-00042"},
+		{"{TYPE}/{SEQ:3}", 1, "3/001"},
+	}
+	for _, tt := range tests {
+		g, err := NewProtocolNumberGenerator(tt.format)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.format, err)
+		}
+		if got := g.render(tt.seq, 2026, "SP", 3); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.format, got, tt.want)
+		}
+	}
+
+	for _, format := range []string{"{YYYY}", "{YYYY}-{FOO}-{SEQ:4}"} {
+		if _, err := NewProtocolNumberGenerator(format); err == nil {
+			t.Errorf("%s: expected an error", format)
+		}
+	}
+}
+
+func TestNextIsUniqueUnderConcurrency(t *testing.T) {
+	db := testdb.Open(t)
+	refs := testdb.SeedProtocolRefs(t, db)
+	numbers, err := NewProtocolNumberGenerator(DefaultProtocolNumberFormat)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	protocol := models.Protocol{TypeID: refs.TypeID}
+	now := time.Now()
+	got := make([]string, concurrentCreates)
+	errs := make([]error, concurrentCreates)
+
+	var wg sync.WaitGroup
+	for i := range concurrentCreates {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = db.Transaction(func(tx *gorm.DB) error {
+				number, err := numbers.Next(tx, protocol, now)
+				got[i] = number
+				return err
+			})
+		}()
+	}
+	wg.Wait()
+
+	assertUnique(t, got, errs)
+}
+
+func TestCreateIsUniqueUnderConcurrency(t *testing.T) {
+	db := testdb.Open(t)
+	refs := testdb.SeedProtocolRefs(t, db)
+	numbers, err := NewProtocolNumberGenerator(DefaultProtocolNumberFormat)
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := NewProtocolRepository(db, numbers, NewDeadlineCalculator(false), 24*time.Hour)
+	actor := Actor{PersonnelID: refs.PersonnelID, Role: models.RoleAdmin}
+
+	got := make([]string, concurrentCreates)
+	errs := make([]error, concurrentCreates)
+
+	var wg sync.WaitGroup
+	for i := range concurrentCreates {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			created, err := repo.Create(AllBranchesScope(), actor, models.Protocol{
+				Title:      "Concorrência",
+				TypeID:     refs.TypeID,
+				StatusID:   refs.StatusID,
+				CustomerID: refs.CustomerID,
+				AssignedTo: refs.PersonnelID,
+			})
+			got[i], errs[i] = created.ProtocolNumber, err
+		}()
+	}
+	wg.Wait()
+
+	assertUnique(t, got, errs)
+
+	var stored int64
+	if err := db.Model(&models.Protocol{}).
+		Distinct("protocol_number").Count(&stored).Error; err != nil {
+		t.Fatal(err)
+	}
+	if stored != concurrentCreates {
+		t.Errorf("stored %d distinct numbers, want %d", stored, concurrentCreates)
+	}
+}
+
+func assertUnique(t *testing.T, numbers []string, errs []error) {
+	t.Helper()
+	seen := map[string]int{}
+	for i, number := range numbers {
+		if errs[i] != nil {
+			t.Fatalf("call %d: %v", i, errs[i])
+		}
+		if number == "" {
+			t.Fatalf("call %d: empty protocol number", i)
+		}
+		if previous, ok := seen[number]; ok {
+			t.Fatalf("calls %d and %d both got %s", previous, i, number)
+		}
+		seen[number] = i
+	}
+}
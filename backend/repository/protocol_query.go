@@ -0,0 +1,191 @@
+// backend/repository/protocol_query.go
+package repository
+
+import (
+	"ProtocolManager/backend/models"
+	"gorm.io/gorm"
+	"time"
+)
+
+const (
+	DefaultProtocolPageSize = 20
+	MaxProtocolPageSize     = 100
+)
+
+// Colunas aceitas no parâmetro sort; qualquer outro valor é rejeitado para
+// não interpolar texto do cliente na cláusula ORDER BY
+var protocolSortColumns = map[string]string{
+	"protocol_id":     "protocols.protocol_id",
+	"protocol_number": "protocols.protocol_number",
+	"title":           "protocols.title",
+	"priority":        "protocols.priority",
+	"status_id":       "protocols.status_id",
+	"type_id":         "protocols.type_id",
+	"deadline":        "protocols.deadline",
+	"created_at":      "protocols.created_at",
+	"updated_at":      "protocols.updated_at",
+	"closed_at":       "protocols.closed_at",
+}
+
+// ValidProtocolSort informa se o campo pode ser usado para ordenação
+func ValidProtocolSort(field string) bool {
+	_, ok := protocolSortColumns[field]
+	return ok
+}
+
+// ProtocolQuery descreve paginação, ordenação e filtros da listagem de
+// protocolos. Filtros nulos ou vazios são ignorados.
+type ProtocolQuery struct {
+	Page      int
+	PageSize  int
+	SortField string
+	SortDesc  bool
+
+	StatusID   *int
+	TypeID     *int
+	CustomerID *int
+	BranchID   *int
+	AssignedTo *int
+	Priority   string
+	IsClosed   *bool
+	SLAState   string
+
+	CreatedFrom  *time.Time
+	CreatedTo    *time.Time
+	DeadlineFrom *time.Time
+	DeadlineTo   *time.Time
+}
+
+// ProtocolPage é uma página da listagem com os totais da consulta
+type ProtocolPage struct {
+	Data       []models.Protocol `json:"data"`
+	Total      int64             `json:"total"`
+	Page       int               `json:"page"`
+	PageSize   int               `json:"page_size"`
+	TotalPages int               `json:"total_pages"`
+}
+
+// List retorna uma página de protocolos visíveis no escopo, aplicando os
+// filtros no banco antes de carregar as associações
+func (r *ProtocolRepository) List(scope Scope, q ProtocolQuery) (
+	ProtocolPage, error,
+) {
+	if q.Page < 1 {
+		q.Page = 1
+	}
+	if q.PageSize < 1 {
+		q.PageSize = DefaultProtocolPageSize
+	}
+	if q.PageSize > MaxProtocolPageSize {
+		q.PageSize = MaxProtocolPageSize
+	}
+
+	query := scope.Apply(r.DB.Model(&models.Protocol{}), "protocols")
+
+	if q.StatusID != nil {
+		query = query.Where("protocols.status_id = ?", *q.StatusID)
+	}
+	if q.TypeID != nil {
+		query = query.Where("protocols.type_id = ?", *q.TypeID)
+	}
+	if q.CustomerID != nil {
+		query = query.Where("protocols.customer_id = ?", *q.CustomerID)
+	}
+	if q.BranchID != nil {
+		query = query.Where("protocols.branch_id = ?", *q.BranchID)
+	}
+	if q.AssignedTo != nil {
+		query = query.Where("protocols.assigned_to = ?", *q.AssignedTo)
+	}
+	if q.Priority != "" {
+		query = query.Where("protocols.priority = ?", q.Priority)
+	}
+	if q.IsClosed != nil {
+		if *q.IsClosed {
+			query = query.Where("protocols.closed_at IS NOT NULL")
+		} else {
+			query = query.Where("protocols.closed_at IS NULL")
+		}
+	}
+	if q.SLAState != "" {
+		query = applySLAFilter(query, q.SLAState, time.Now(), r.AtRiskWindow)
+	}
+	if q.CreatedFrom != nil {
+		query = query.Where("protocols.created_at >= ?", *q.CreatedFrom)
+	}
+	if q.CreatedTo != nil {
+		query = query.Where("protocols.created_at <= ?", *q.CreatedTo)
+	}
+	if q.DeadlineFrom != nil {
+		query = query.Where("protocols.deadline >= ?", *q.DeadlineFrom)
+	}
+	if q.DeadlineTo != nil {
+		query = query.Where("protocols.deadline <= ?", *q.DeadlineTo)
+	}
+
+	// Session permite reutilizar os filtros no Count e no Find
+	query = query.Session(&gorm.Session{})
+
+	page := ProtocolPage{Page: q.Page, PageSize: q.PageSize}
+	if err := query.Count(&page.Total).Error; err != nil {
+		return page, err
+	}
+	page.TotalPages = int((page.Total + int64(q.PageSize) - 1) / int64(q.PageSize))
+
+	column, ok := protocolSortColumns[q.SortField]
+	if !ok {
+		column = protocolSortColumns["created_at"]
+		q.SortDesc = true
+	}
+	direction := " ASC"
+	if q.SortDesc {
+		direction = " DESC"
+	}
+
+	page.Data = []models.Protocol{}
+	result := query.
+		Preload("Type").
+		Preload("Status").
+		Preload("Customer").
+		Preload("Branch").
+		Preload("Requestor").
+		Preload("AssignedAgent").
+		Preload("CreatedByAgent").
+		Order(column + direction).
+		Order("protocols.protocol_id" + direction).
+		Offset((q.Page - 1) * q.PageSize).
+		Limit(q.PageSize).
+		Find(&page.Data)
+
+	r.withSLAState(page.Data)
+	return page, result.Error
+}
+
+// applySLAFilter reproduz em SQL a regra de models.Protocol.SLAStateAt
+func applySLAFilter(
+	query *gorm.DB, state string, now time.Time, atRiskWindow time.Duration,
+) *gorm.DB {
+	riskLimit := now.Add(atRiskWindow)
+
+	switch state {
+	case models.SLABreached:
+		return query.Where(
+			`((protocols.closed_at IS NULL AND protocols.deadline < ?)
+			OR (protocols.closed_at IS NOT NULL AND protocols.closed_at > protocols.deadline))`,
+			now,
+		)
+	case models.SLAAtRisk:
+		return query.Where(
+			`protocols.closed_at IS NULL
+			AND protocols.deadline >= ? AND protocols.deadline < ?`,
+			now, riskLimit,
+		)
+	default: // models.SLAOnTrack
+		return query.Where(
+			`(protocols.deadline IS NULL
+			OR (protocols.closed_at IS NULL AND protocols.deadline >= ?)
+			OR (protocols.closed_at IS NOT NULL AND protocols.closed_at <= protocols.deadline))`,
+			riskLimit,
+		)
+	}
+}
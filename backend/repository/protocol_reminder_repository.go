@@ -4,6 +4,7 @@ package repository
 import (
 	"ProtocolManager/backend/models"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"time"
 )
 
@@ -15,41 +16,46 @@ func NewProtocolReminderRepository(db *gorm.DB) *ProtocolReminderRepository {
 	return &ProtocolReminderRepository{DB: db}
 }
 
-func (r *ProtocolReminderRepository) GetAll() (
+func (r *ProtocolReminderRepository) GetAll(scope Scope) (
 	[]models.ProtocolReminder, error,
 ) {
 	var reminders []models.ProtocolReminder
-	result := r.DB.Preload("CreatedByAgent").Find(&reminders)
+	result := scope.ApplyProtocol(r.DB, "protocol_reminders").
+		Preload("CreatedByAgent").
+		Find(&reminders)
 	return reminders, result.Error
 }
 
-func (r *ProtocolReminderRepository) GetByID(id int) (
+func (r *ProtocolReminderRepository) GetByID(scope Scope, id int) (
 	models.ProtocolReminder, error,
 ) {
 	var reminder models.ProtocolReminder
-	result := r.DB.Preload("CreatedByAgent").First(&reminder, id)
+	result := scope.ApplyProtocol(r.DB, "protocol_reminders").
+		Preload("CreatedByAgent").
+		First(&reminder, id)
 	return reminder, result.Error
 }
 
-func (r *ProtocolReminderRepository) GetByProtocolID(protocolID int) (
-	[]models.ProtocolReminder, error,
-) {
+func (r *ProtocolReminderRepository) GetByProtocolID(
+	scope Scope, protocolID int,
+) ([]models.ProtocolReminder, error) {
 	var reminders []models.ProtocolReminder
-	result := r.DB.Where("protocol_id = ?", protocolID).
+	result := scope.ApplyProtocol(r.DB, "protocol_reminders").
+		Where("protocol_id = ?", protocolID).
 		Preload("CreatedByAgent").
 		Order("reminder_date").
 		Find(&reminders)
 	return reminders, result.Error
 }
 
-func (r *ProtocolReminderRepository) GetUpcomingReminders(withinHours int) (
-	[]models.ProtocolReminder, error,
-) {
+func (r *ProtocolReminderRepository) GetUpcomingReminders(
+	scope Scope, withinHours int,
+) ([]models.ProtocolReminder, error) {
 	var reminders []models.ProtocolReminder
 	now := time.Now()
 	cutoff := now.Add(time.Duration(withinHours) * time.Hour)
 
-	result := r.DB.Where(
+	result := scope.ApplyProtocol(r.DB, "protocol_reminders").Where(
 		"is_sent = ? AND reminder_date BETWEEN ? AND ?", false, now, cutoff,
 	).
 		Preload("CreatedByAgent").
@@ -57,36 +63,201 @@ func (r *ProtocolReminderRepository) GetUpcomingReminders(withinHours int) (
 	return reminders, result.Error
 }
 
-func (r *ProtocolReminderRepository) Create(reminder models.ProtocolReminder) (
-	models.ProtocolReminder, error,
-) {
-	result := r.DB.Create(&reminder)
-	return reminder, result.Error
+func (r *ProtocolReminderRepository) Create(
+	scope Scope, reminder models.ProtocolReminder,
+) (models.ProtocolReminder, error) {
+	if err := scope.CheckProtocol(r.DB, reminder.ProtocolID); err != nil {
+		return reminder, err
+	}
+
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("CreatedByAgent").Create(&reminder).Error; err != nil {
+			return err
+		}
+		return recordProtocolEvent(
+			tx, models.EventReminderCreated, reminder.ProtocolID,
+			models.NewReminderEventData(reminder),
+		)
+	})
+	return reminder, err
 }
 
+// Update edits a reminder. A new date resets the delivery state (attempts,
+// failure, next attempt and lease) in the same statement, so a reminder
+// that failed is delivered again at the new date.
 func (r *ProtocolReminderRepository) Update(
-	id int, reminder models.ProtocolReminder,
+	scope Scope, id int, reminder models.ProtocolReminder,
 ) error {
-	result := r.DB.Model(&models.ProtocolReminder{}).Where(
-		"reminder_id = ?", id,
-	).Updates(
-		map[string]interface{}{
-			"reminder_date":    reminder.ReminderDate,
-			"reminder_message": reminder.ReminderText,
-			"is_sent":          reminder.IsCompleted,
-		},
-	)
-	return result.Error
+	// Inside SET, reminder_date still holds the value before the update
+	resetIfRescheduled := func(column, initial string) clause.Expr {
+		return gorm.Expr(
+			"CASE WHEN reminder_date = ? THEN "+column+" ELSE "+initial+" END",
+			reminder.ReminderDate,
+		)
+	}
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		result := scope.ApplyProtocol(
+			tx.Model(&models.ProtocolReminder{}), "protocol_reminders",
+		).Where(
+			"reminder_id = ?", id,
+		).Updates(
+			map[string]interface{}{
+				"reminder_date":    reminder.ReminderDate,
+				"reminder_message": reminder.ReminderText,
+				"is_sent":          reminder.IsCompleted,
+				"attempts":         resetIfRescheduled("attempts", "0"),
+				"failed_at":        resetIfRescheduled("failed_at", "NULL"),
+				"next_attempt_at":  resetIfRescheduled("next_attempt_at", "NULL"),
+				"last_error":       resetIfRescheduled("last_error", "''"),
+				"lease_until":      resetIfRescheduled("lease_until", "NULL"),
+			},
+		)
+		if err := requireAffected(result); err != nil {
+			return err
+		}
+		return recordReminderEvent(tx, models.EventReminderUpdated, id)
+	})
 }
 
-func (r *ProtocolReminderRepository) MarkAsSent(id int) error {
-	result := r.DB.Model(&models.ProtocolReminder{}).Where(
-		"reminder_id = ?", id,
-	).Update("is_sent", true)
-	return result.Error
+func (r *ProtocolReminderRepository) MarkAsSent(scope Scope, id int) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		result := scope.ApplyProtocol(
+			tx.Model(&models.ProtocolReminder{}), "protocol_reminders",
+		).Where("reminder_id = ?", id).Updates(
+			map[string]interface{}{"is_sent": true, "sent_at": time.Now()},
+		)
+		if err := requireAffected(result); err != nil {
+			return err
+		}
+		return recordReminderEvent(tx, models.EventReminderUpdated, id)
+	})
+}
+
+func (r *ProtocolReminderRepository) Delete(scope Scope, id int) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		var reminder models.ProtocolReminder
+		if err := scope.ApplyProtocol(tx, "protocol_reminders").
+			First(&reminder, id).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Delete(&models.ProtocolReminder{}, id).Error; err != nil {
+			return err
+		}
+		return recordProtocolEvent(
+			tx, models.EventReminderDeleted, reminder.ProtocolID,
+			models.NewReminderEventData(reminder),
+		)
+	})
 }
 
-func (r *ProtocolReminderRepository) Delete(id int) error {
-	result := r.DB.Delete(&models.ProtocolReminder{}, id)
-	return result.Error
+// ClaimDueReminders leases up to limit reminders that are due for delivery
+// in a short transaction and returns them. Leased reminders are skipped by
+// other replicas until the lease expires, so delivery happens outside any
+// transaction; a dispatcher that dies mid-batch only delays its reminders.
+func (r *ProtocolReminderRepository) ClaimDueReminders(
+	now time.Time, limit int, lease time.Duration,
+) ([]models.ProtocolReminder, error) {
+	var reminders []models.ProtocolReminder
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.
+			Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where(
+				`is_sent = ? AND is_completed = ? AND failed_at IS NULL
+				AND reminder_date <= ?
+				AND (next_attempt_at IS NULL OR next_attempt_at <= ?)
+				AND (lease_until IS NULL OR lease_until <= ?)`,
+				false, false, now, now, now,
+			).
+			Order("reminder_date").
+			Limit(limit).
+			Find(&reminders).Error
+		if err != nil || len(reminders) == 0 {
+			return err
+		}
+		return tx.Model(&models.ProtocolReminder{}).
+			Where("reminder_id IN ?", reminderIDs(reminders)).
+			Update("lease_until", now.Add(lease)).Error
+	})
+	if err != nil || len(reminders) == 0 {
+		return nil, err
+	}
+
+	// Associations are loaded after the claim so the lock only covers the
+	// reminder rows
+	var loaded []models.ProtocolReminder
+	if err := r.DB.Preload("CreatedByAgent").
+		Where("reminder_id IN ?", reminderIDs(reminders)).
+		Find(&loaded).Error; err != nil {
+		return nil, err
+	}
+	byID := make(map[int]models.SalesPersonnel, len(loaded))
+	for _, a := range loaded {
+		byID[a.ReminderID] = a.CreatedByAgent
+	}
+	for i := range reminders {
+		reminders[i].CreatedByAgent = byID[reminders[i].ReminderID]
+	}
+	return reminders, nil
+}
+
+func reminderIDs(reminders []models.ProtocolReminder) []int {
+	ids := make([]int, len(reminders))
+	for i, reminder := range reminders {
+		ids[i] = reminder.ReminderID
+	}
+	return ids
+}
+
+// MarkDelivered records a successful delivery made by the dispatcher,
+// releases the lease and publishes reminder.updated
+func (r *ProtocolReminderRepository) MarkDelivered(
+	id int, sentAt time.Time,
+) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.ProtocolReminder{}).
+			Where("reminder_id = ?", id).
+			Updates(map[string]interface{}{
+				"is_sent":         true,
+				"sent_at":         sentAt,
+				"next_attempt_at": nil,
+				"last_error":      "",
+				"lease_until":     nil,
+			}).Error; err != nil {
+			return err
+		}
+		return recordReminderEvent(tx, models.EventReminderUpdated, id)
+	})
+}
+
+// MarkAttemptFailed records a failed delivery and releases the lease. A nil
+// nextAttempt means the dispatcher gave up and the reminder is marked as
+// failed.
+func (r *ProtocolReminderRepository) MarkAttemptFailed(
+	id, attempts int, nextAttempt *time.Time, cause error, now time.Time,
+) error {
+	fields := map[string]interface{}{
+		"attempts":        attempts,
+		"next_attempt_at": nextAttempt,
+		"last_error":      cause.Error(),
+		"lease_until":     nil,
+	}
+	if nextAttempt == nil {
+		fields["failed_at"] = now
+	}
+	return r.DB.Model(&models.ProtocolReminder{}).
+		Where("reminder_id = ?", id).
+		Updates(fields).Error
+}
+
+// recordReminderEvent grava no outbox o estado atual do lembrete
+func recordReminderEvent(tx *gorm.DB, eventType string, id int) error {
+	var reminder models.ProtocolReminder
+	if err := tx.First(&reminder, id).Error; err != nil {
+		return err
+	}
+	return recordProtocolEvent(
+		tx, eventType, reminder.ProtocolID,
+		models.NewReminderEventData(reminder),
+	)
 }
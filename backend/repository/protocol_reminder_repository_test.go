@@ -0,0 +1,71 @@
+// backend/repository/protocol_reminder_repository_test.go
+package repository
+
+import (
+	"ProtocolManager/backend/models"
+	"ProtocolManager/backend/testdb"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestClaimDueRemindersLeasesUntilMarked(t *testing.T) {
+	db := testdb.Open(t)
+	refs := testdb.SeedProtocolRefs(t, db)
+	numbers, err := NewProtocolNumberGenerator(DefaultProtocolNumberFormat)
+	if err != nil {
+		t.Fatal(err)
+	}
+	protocols := NewProtocolRepository(db, numbers, NewDeadlineCalculator(false), 24*time.Hour)
+	protocol, err := protocols.Create(
+		AllBranchesScope(),
+		Actor{PersonnelID: refs.PersonnelID, Role: models.RoleAdmin},
+		models.Protocol{
+			Title:      "Lembrete",
+			TypeID:     refs.TypeID,
+			StatusID:   refs.StatusID,
+			CustomerID: refs.CustomerID,
+			AssignedTo: refs.PersonnelID,
+		},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	testdb.Create(t, db, &models.ProtocolReminder{
+		ProtocolID:   protocol.ProtocolID,
+		ReminderText: "Retornar ao cliente",
+		ReminderDate: now.Add(-time.Minute),
+		CreatedBy:    refs.PersonnelID,
+	})
+	repo := NewProtocolReminderRepository(db)
+
+	claimed, err := repo.ClaimDueReminders(now, 10, time.Hour)
+	if err != nil || len(claimed) != 1 {
+		t.Fatalf("first claim: %d reminders, %v", len(claimed), err)
+	}
+	id := claimed[0].ReminderID
+
+	// Reservado: outra réplica não recebe o mesmo lembrete
+	if again, err := repo.ClaimDueReminders(now, 10, time.Hour); err != nil || len(again) != 0 {
+		t.Fatalf("claim while leased: %d reminders, %v", len(again), err)
+	}
+
+	// A falha libera a reserva para a próxima tentativa
+	retry := now.Add(time.Second)
+	if err := repo.MarkAttemptFailed(id, 1, &retry, errors.New("smtp"), now); err != nil {
+		t.Fatal(err)
+	}
+	claimed, err = repo.ClaimDueReminders(retry, 10, time.Hour)
+	if err != nil || len(claimed) != 1 {
+		t.Fatalf("claim after failure: %d reminders, %v", len(claimed), err)
+	}
+
+	if err := repo.MarkDelivered(id, retry); err != nil {
+		t.Fatal(err)
+	}
+	if done, err := repo.ClaimDueReminders(retry.Add(2*time.Hour), 10, time.Hour); err != nil || len(done) != 0 {
+		t.Fatalf("claim after delivery: %d reminders, %v", len(done), err)
+	}
+}
@@ -5,21 +5,45 @@ import (
 	"ProtocolManager/backend/models"
 	"fmt"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"sort"
 	"time"
 )
 
 type ProtocolRepository struct {
-	DB *gorm.DB
+	DB        *gorm.DB
+	Numbers   *ProtocolNumberGenerator
+	Deadlines *DeadlineCalculator
+	// Quanto antes do prazo um protocolo aberto passa a estar em risco
+	AtRiskWindow time.Duration
 }
 
-func NewProtocolRepository(db *gorm.DB) *ProtocolRepository {
-	return &ProtocolRepository{DB: db}
+func NewProtocolRepository(
+	db *gorm.DB,
+	numbers *ProtocolNumberGenerator,
+	deadlines *DeadlineCalculator,
+	atRiskWindow time.Duration,
+) *ProtocolRepository {
+	return &ProtocolRepository{
+		DB:           db,
+		Numbers:      numbers,
+		Deadlines:    deadlines,
+		AtRiskWindow: atRiskWindow,
+	}
+}
+
+// withSLAState preenche o estado de SLA exposto no JSON dos protocolos
+func (r *ProtocolRepository) withSLAState(protocols []models.Protocol) {
+	now := time.Now()
+	for i := range protocols {
+		protocols[i].SLAState = protocols[i].SLAStateAt(now, r.AtRiskWindow)
+	}
 }
 
-func (r *ProtocolRepository) GetAll() ([]models.Protocol, error) {
+func (r *ProtocolRepository) GetAll(scope Scope) ([]models.Protocol, error) {
 	var protocols []models.Protocol
 
-	result := r.DB.
+	result := scope.Apply(r.DB, "protocols").
 		Preload("Type").
 		Preload("Status").
 		Preload("Customer").
@@ -33,12 +57,15 @@ func (r *ProtocolRepository) GetAll() ([]models.Protocol, error) {
 		return nil, result.Error
 	}
 
+	r.withSLAState(protocols)
 	return protocols, nil
 }
 
-func (r *ProtocolRepository) GetByID(id int) (models.Protocol, error) {
+func (r *ProtocolRepository) GetByID(scope Scope, id int) (
+	models.Protocol, error,
+) {
 	var protocol models.Protocol
-	result := r.DB.
+	result := scope.Apply(r.DB, "protocols").
 		Preload("Type").
 		Preload("Status").
 		Preload("Customer").
@@ -47,58 +74,82 @@ func (r *ProtocolRepository) GetByID(id int) (models.Protocol, error) {
 		Preload("AssignedAgent").
 		Preload("CreatedByAgent").
 		First(&protocol, id)
+	protocol.SLAState = protocol.SLAStateAt(time.Now(), r.AtRiskWindow)
 	return protocol, result.Error
 }
 
 // backend/repository/protocol_repository.go
-func (r *ProtocolRepository) Create(protocol models.Protocol) (
-	models.Protocol, error,
-) {
+func (r *ProtocolRepository) Create(
+	scope Scope, actor Actor, protocol models.Protocol,
+) (models.Protocol, error) {
+	if !scope.Allows(protocol.BranchID) {
+		return protocol, ErrOutOfScope
+	}
+
 	// Set a default type_id if not provided or if it's zero
+	var protocolType models.ProtocolType
 	if protocol.TypeID == 0 {
 		// Get the first available type from the database
-		var defaultType models.ProtocolType
-		if err := r.DB.First(&defaultType).Error; err != nil {
+		if err := r.DB.First(&protocolType).Error; err != nil {
 			return protocol, fmt.Errorf("no protocol types available: %w", err)
 		}
-		protocol.TypeID = defaultType.TypeID
+		protocol.TypeID = protocolType.TypeID
+	} else if err := r.DB.First(&protocolType, protocol.TypeID).Error; err != nil {
+		return protocol, fmt.Errorf("protocol type not found: %w", err)
 	}
 
-	// Ensure we have a valid created_by
-	if protocol.CreatedBy == 0 {
-		var personnel models.SalesPersonnel
-		if err := r.DB.First(&personnel).Error; err != nil {
-			return protocol, fmt.Errorf(
-				"no personnel available for created_by: %w", err,
-			)
-		}
-		protocol.CreatedBy = personnel.PersonnelID
+	// created_by always comes from the authenticated session
+	protocol.CreatedBy = actor.PersonnelID
+
+	if err := checkInitialStatus(r.DB, protocol.TypeID, protocol.StatusID); err != nil {
+		return protocol, err
 	}
 
-	// Generate protocol number
-	currentYear := time.Now().Year()
-	var count int64
-	r.DB.Model(&models.Protocol{}).
-		Where("EXTRACT(YEAR FROM created_at) = ?", currentYear).
-		Count(&count)
+	// Number, protocol and initial history are committed together
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		protocol.CreatedAt = now
 
-	protocol.ProtocolNumber = fmt.Sprintf("%d-%04d", currentYear, count+1)
+		number, err := r.Numbers.Next(tx, protocol, now)
+		if err != nil {
+			return err
+		}
+		protocol.ProtocolNumber = number
 
-	// Create the protocol
-	result := r.DB.Create(&protocol)
-	if result.Error != nil {
-		return protocol, result.Error
-	}
+		// Without an explicit deadline, use the type's default SLA
+		if protocol.Deadline == nil && protocolType.DefaultDeadlineDays > 0 {
+			deadline, err := r.Deadlines.Deadline(
+				tx, now, protocolType.DefaultDeadlineDays, protocol.BranchID,
+			)
+			if err != nil {
+				return err
+			}
+			protocol.Deadline = &deadline
+		}
 
-	// Create initial history record
-	history := models.ProtocolHistory{
-		ProtocolID:  protocol.ProtocolID,
-		NewStatusID: protocol.StatusID,
-		Notes:       "Protocol created",
-		CreatedBy:   protocol.CreatedBy,
-	}
+		if err := tx.Create(&protocol).Error; err != nil {
+			return err
+		}
 
-	r.DB.Create(&history)
+		history := models.ProtocolHistory{
+			ProtocolID:  protocol.ProtocolID,
+			NewStatusID: protocol.StatusID,
+			Notes:       "Protocol created",
+			EntryType:   models.HistoryStatusChange,
+			CreatedBy:   &protocol.CreatedBy,
+		}
+		if err := tx.Create(&history).Error; err != nil {
+			return err
+		}
+
+		return recordEvent(
+			tx, models.EventProtocolCreated, protocol.ProtocolID,
+			protocol.BranchID, models.NewProtocolEventData(protocol),
+		)
+	})
+	if err != nil {
+		return protocol, err
+	}
 
 	// Fetch the complete protocol with associations
 	r.DB.
@@ -111,13 +162,52 @@ func (r *ProtocolRepository) Create(protocol models.Protocol) (
 		Preload("CreatedByAgent").
 		First(&protocol, protocol.ProtocolID)
 
+	protocol.SLAState = protocol.SLAStateAt(time.Now(), r.AtRiskWindow)
 	return protocol, nil
 }
 
+// ReadOnlyFieldError indica um campo controlado pelo servidor enviado
+// numa alteração do protocolo
+type ReadOnlyFieldError struct {
+	Field string
+}
+
+func (e *ReadOnlyFieldError) Error() string {
+	return "campo somente leitura: " + e.Field
+}
+
+// Campos controlados pelo servidor não podem ser alterados pelo cliente. O
+// tipo define o fluxo de status e o prazo; closed_at acompanha o status,
+// então nenhum deles muda por UpdateFields.
+var protocolReadOnlyFields = []string{
+	"protocol_id", "protocol_number", "created_by", "created_at",
+	"sla_state", "sla_breached_at", "type_id", "closed_at", "deadline",
+}
+
 // backend/repository/protocol_repository.go
 func (r *ProtocolRepository) UpdateFields(
-	id int, fields map[string]interface{},
+	scope Scope, actor Actor, id int, fields map[string]interface{},
 ) error {
+	// Um campo somente leitura é recusado em vez de descartado, para o
+	// cliente não achar que a alteração foi aplicada
+	for _, readOnly := range protocolReadOnlyFields {
+		if _, ok := fields[readOnly]; ok {
+			return &ReadOnlyFieldError{Field: readOnly}
+		}
+	}
+
+	if err := scope.CheckProtocol(r.DB, id); err != nil {
+		return err
+	}
+
+	// Usuários de filial não podem mover o protocolo para outra filial
+	if b, ok := fields["branch_id"]; ok && !scope.AllBranches {
+		branchID, ok := toInt(b)
+		if !ok || !scope.Allows(&branchID) {
+			return ErrOutOfScope
+		}
+	}
+
 	// Validação de prioridade, se enviada
 	if p, ok := fields["priority"]; ok {
 		if pStr, ok := p.(string); ok {
@@ -128,47 +218,112 @@ func (r *ProtocolRepository) UpdateFields(
 		}
 	}
 
-	// Verificar se vai atualizar o status e gerar histórico se necessário
+	// notes não é coluna do protocolo: vai para o histórico da mudança
+	var notes string
+	if n, ok := fields["notes"]; ok {
+		notes, _ = n.(string)
+		delete(fields, "notes")
+	}
+
+	var newStatusID int
 	if newStatusRaw, ok := fields["status_id"]; ok {
-		newStatusID, ok := toInt(newStatusRaw)
-		if !ok {
+		if newStatusID, ok = toInt(newStatusRaw); !ok {
 			return fmt.Errorf("status_id inválido")
 		}
+	}
 
+	// Protocolo e histórico são gravados na mesma transação; a linha do
+	// protocolo fica travada para que mudanças de status concorrentes não
+	// gerem históricos inconsistentes
+	return r.DB.Transaction(func(tx *gorm.DB) error {
 		var current models.Protocol
-		if err := r.DB.First(&current, id).Error; err != nil {
+		if err := scope.Apply(tx, "protocols").
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&current, id).Error; err != nil {
 			return err
 		}
 
-		if current.StatusID != newStatusID {
-			oldStatusID := current.StatusID
+		var history *models.ProtocolHistory
+		if newStatusID != 0 && current.StatusID != newStatusID {
+			if err := checkStatusTransition(
+				tx, current.TypeID, current.StatusID, newStatusID,
+				actor.Role, notes,
+			); err != nil {
+				return err
+			}
 
-			history := models.ProtocolHistory{
+			var newStatus models.ProtocolStatus
+			if err := tx.First(&newStatus, newStatusID).Error; err != nil {
+				return err
+			}
+
+			// Reabrir o protocolo limpa a data de encerramento
+			if !newStatus.IsTerminal {
+				fields["closed_at"] = nil
+			} else if current.ClosedAt == nil {
+				fields["closed_at"] = time.Now()
+			}
+
+			oldStatusID := current.StatusID
+			history = &models.ProtocolHistory{
 				ProtocolID:  current.ProtocolID,
 				OldStatusID: &oldStatusID,
 				NewStatusID: newStatusID,
-				CreatedBy:   current.CreatedBy, // ou 0 por enquanto
+				Notes:       notes,
+				EntryType:   models.HistoryStatusChange,
+				CreatedBy:   &actor.PersonnelID,
 			}
+		}
 
-			if err := r.DB.Create(&history).Error; err != nil {
-				return err
-			}
+		// Executar update antes do histórico: se falhar, nada é gravado
+		if err := tx.Model(&models.Protocol{}).Where(
+			"protocol_id = ?", id,
+		).Updates(fields).Error; err != nil {
+			return err
+		}
 
-			var newStatus models.ProtocolStatus
-			if err := r.DB.First(&newStatus, newStatusID).Error; err != nil {
+		if history != nil {
+			if err := tx.Create(history).Error; err != nil {
 				return err
 			}
+		}
 
-			if newStatus.IsTerminal {
-				fields["closed_at"] = time.Now()
-			}
+		var updated models.Protocol
+		if err := tx.First(&updated, id).Error; err != nil {
+			return err
+		}
+
+		changed := make([]string, 0, len(fields))
+		for field := range fields {
+			changed = append(changed, field)
+		}
+		sort.Strings(changed)
+
+		data := models.NewProtocolEventData(updated)
+		data.ChangedFields = changed
+		if current.AssignedTo != updated.AssignedTo {
+			previous := current.AssignedTo
+			data.PreviousAssignedTo = &previous
+		}
+		if history != nil {
+			data.PreviousStatusID = history.OldStatusID
+		}
+		if err := recordEvent(
+			tx, models.EventProtocolUpdated, id, updated.BranchID, data,
+		); err != nil {
+			return err
 		}
-	}
 
-	// Executar update
-	return r.DB.Model(&models.Protocol{}).Where(
-		"protocol_id = ?", id,
-	).Updates(fields).Error
+		if history == nil {
+			return nil
+		}
+		data = models.NewProtocolEventData(updated)
+		data.PreviousStatusID = history.OldStatusID
+		data.Notes = notes
+		return recordEvent(
+			tx, models.EventProtocolStatusChanged, id, updated.BranchID, data,
+		)
+	})
 }
 
 // Função auxiliar para garantir que qualquer tipo seja convertido corretamente em int
@@ -185,39 +340,78 @@ func toInt(val interface{}) (int, bool) {
 	}
 }
 
-func (r *ProtocolRepository) Delete(id int) error {
-	// Delete attachments
-	if err := r.DB.Where(
-		"protocol_id = ?", id,
-	).Delete(&models.ProtocolAttachment{}).Error; err != nil {
-		return err
-	}
+// Delete removes the protocol and its attachments, reminders and history in
+// a single transaction. It returns the file paths of the removed
+// attachments so the caller can delete them only after the commit.
+func (r *ProtocolRepository) Delete(scope Scope, id int) ([]string, error) {
+	var filePaths []string
 
-	// Delete reminders (se aplicável)
-	if err := r.DB.Where(
-		"protocol_id = ?", id,
-	).Delete(&models.ProtocolReminder{}).Error; err != nil {
-		return err
-	}
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		var protocol models.Protocol
+		if err := scope.Apply(tx, "protocols").First(&protocol, id).Error; err != nil {
+			return err
+		}
 
-	// Delete history
-	if err := r.DB.Where(
-		"protocol_id = ?", id,
-	).Delete(&models.ProtocolHistory{}).Error; err != nil {
-		return err
+		// Collect the files and thumbnails of every attachment version
+		// before deleting the rows
+		attachmentIDs := tx.Model(&models.ProtocolAttachment{}).
+			Select("attachment_id").
+			Where("protocol_id = ?", id)
+		paths, err := versionFiles(tx.Where("attachment_id IN (?)", attachmentIDs))
+		if err != nil {
+			return err
+		}
+		filePaths = paths
+
+		// Delete attachments
+		if err := tx.Where("attachment_id IN (?)", attachmentIDs).
+			Delete(&models.AttachmentVersion{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where(
+			"protocol_id = ?", id,
+		).Delete(&models.ProtocolAttachment{}).Error; err != nil {
+			return err
+		}
+
+		// Delete reminders (se aplicável)
+		if err := tx.Where(
+			"protocol_id = ?", id,
+		).Delete(&models.ProtocolReminder{}).Error; err != nil {
+			return err
+		}
+
+		// Delete history
+		if err := tx.Where(
+			"protocol_id = ?", id,
+		).Delete(&models.ProtocolHistory{}).Error; err != nil {
+			return err
+		}
+
+		// Delete the protocol itself
+		if err := tx.Delete(&models.Protocol{}, id).Error; err != nil {
+			return err
+		}
+
+		return recordEvent(
+			tx, models.EventProtocolDeleted, id, protocol.BranchID,
+			models.NewProtocolEventData(protocol),
+		)
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	// Delete the protocol itself
-	return r.DB.Delete(&models.Protocol{}, id).Error
+	return filePaths, nil
 }
 
 // Additional useful methods
 
-func (r *ProtocolRepository) GetByStatus(statusID int) (
+func (r *ProtocolRepository) GetByStatus(scope Scope, statusID int) (
 	[]models.Protocol, error,
 ) {
 	var protocols []models.Protocol
-	result := r.DB.
+	result := scope.Apply(r.DB, "protocols").
 		Preload("Type").
 		Preload("Status").
 		Preload("Customer").
@@ -227,14 +421,15 @@ func (r *ProtocolRepository) GetByStatus(statusID int) (
 		Where("status_id = ?", statusID).
 		Order("created_at DESC").
 		Find(&protocols)
+	r.withSLAState(protocols)
 	return protocols, result.Error
 }
 
-func (r *ProtocolRepository) GetHistory(protocolID int) (
+func (r *ProtocolRepository) GetHistory(scope Scope, protocolID int) (
 	[]models.ProtocolHistory, error,
 ) {
 	var history []models.ProtocolHistory
-	result := r.DB.
+	result := scope.ApplyProtocol(r.DB, "protocol_history").
 		Preload("PreviousStatus").
 		Preload("NewStatus").
 		Preload("CreatedByAgent").
@@ -243,3 +438,64 @@ func (r *ProtocolRepository) GetHistory(protocolID int) (
 		Find(&history)
 	return history, result.Error
 }
+
+// RecordSLABreaches marks open protocols whose deadline has passed and adds
+// a history entry for each one. Rows are locked with SKIP LOCKED so several
+// replicas can run the check concurrently without duplicating entries.
+func (r *ProtocolRepository) RecordSLABreaches(now time.Time, limit int) (
+	[]models.Protocol, error,
+) {
+	var breached []models.Protocol
+
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.
+			Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where(
+				"closed_at IS NULL AND sla_breached_at IS NULL AND deadline < ?",
+				now,
+			).
+			Order("deadline").
+			Limit(limit).
+			Find(&breached).Error; err != nil {
+			return err
+		}
+
+		for i := range breached {
+			p := &breached[i]
+			if err := tx.Model(&models.Protocol{}).
+				Where("protocol_id = ?", p.ProtocolID).
+				Update("sla_breached_at", now).Error; err != nil {
+				return err
+			}
+			p.SLABreachedAt = &now
+
+			// Automatic entry: no one made this change, so it has no author
+			statusID := p.StatusID
+			history := models.ProtocolHistory{
+				ProtocolID:  p.ProtocolID,
+				OldStatusID: &statusID,
+				NewStatusID: statusID,
+				Notes: fmt.Sprintf(
+					"SLA violado: prazo era %s",
+					p.Deadline.Format("02/01/2006 15:04"),
+				),
+				EntryType: models.HistorySLABreach,
+			}
+			if err := tx.Create(&history).Error; err != nil {
+				return err
+			}
+
+			if err := recordEvent(
+				tx, models.EventProtocolSLABreached, p.ProtocolID, p.BranchID,
+				models.NewProtocolEventData(*p),
+			); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return breached, nil
+}
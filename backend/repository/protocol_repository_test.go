@@ -0,0 +1,174 @@
+// backend/repository/protocol_repository_test.go
+package repository
+
+import (
+	"ProtocolManager/backend/models"
+	"ProtocolManager/backend/testdb"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestUpdateFieldsRejectsServerFieldsAndReopens(t *testing.T) {
+	db := testdb.Open(t)
+	refs := testdb.SeedProtocolRefs(t, db)
+	numbers, err := NewProtocolNumberGenerator(DefaultProtocolNumberFormat)
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := NewProtocolRepository(db, numbers, NewDeadlineCalculator(false), 24*time.Hour)
+	actor := Actor{PersonnelID: refs.PersonnelID, Role: models.RoleAdmin}
+
+	closed := models.ProtocolStatus{StatusName: "Encerrado", IsTerminal: true}
+	testdb.Create(t, db, &closed)
+	otherType := models.ProtocolType{TypeName: "Outro"}
+	testdb.Create(t, db, &otherType)
+
+	created, err := repo.Create(AllBranchesScope(), actor, models.Protocol{
+		Title:      "Reabertura",
+		TypeID:     refs.TypeID,
+		StatusID:   refs.StatusID,
+		CustomerID: refs.CustomerID,
+		AssignedTo: refs.PersonnelID,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Tipo, prazo e encerramento enviados pelo cliente são recusados e nada
+	// é alterado
+	for field, value := range map[string]interface{}{
+		"type_id":   float64(otherType.TypeID),
+		"deadline":  time.Now().AddDate(1, 0, 0),
+		"closed_at": time.Now().AddDate(-1, 0, 0),
+	} {
+		err := repo.UpdateFields(AllBranchesScope(), actor, created.ProtocolID,
+			map[string]interface{}{
+				"status_id": float64(closed.StatusID),
+				field:       value,
+			})
+		var readOnly *ReadOnlyFieldError
+		if !errors.As(err, &readOnly) || readOnly.Field != field {
+			t.Fatalf("%s: got %v, want a read-only field error", field, err)
+		}
+	}
+	got, err := repo.GetByID(AllBranchesScope(), created.ProtocolID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.TypeID != refs.TypeID || got.StatusID != refs.StatusID || got.ClosedAt != nil {
+		t.Fatalf("protocol changed by a rejected update: %+v", got)
+	}
+
+	// closed_at acompanha a mudança para um status terminal
+	if err := repo.UpdateFields(AllBranchesScope(), actor, created.ProtocolID,
+		map[string]interface{}{"status_id": float64(closed.StatusID)}); err != nil {
+		t.Fatal(err)
+	}
+	got, err = repo.GetByID(AllBranchesScope(), created.ProtocolID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.ClosedAt == nil || time.Since(*got.ClosedAt) > time.Minute {
+		t.Errorf("closed_at = %v, want the time of the status change", got.ClosedAt)
+	}
+
+	// Voltar a um status não terminal limpa closed_at
+	if err := repo.UpdateFields(AllBranchesScope(), actor, created.ProtocolID,
+		map[string]interface{}{"status_id": float64(refs.StatusID)}); err != nil {
+		t.Fatal(err)
+	}
+	got, err = repo.GetByID(AllBranchesScope(), created.ProtocolID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.ClosedAt != nil {
+		t.Errorf("closed_at = %v after reopening, want nil", got.ClosedAt)
+	}
+}
+
+func TestCreateRequiresWorkflowInitialStatus(t *testing.T) {
+	db := testdb.Open(t)
+	refs := testdb.SeedProtocolRefs(t, db)
+	numbers, err := NewProtocolNumberGenerator(DefaultProtocolNumberFormat)
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := NewProtocolRepository(db, numbers, NewDeadlineCalculator(false), 24*time.Hour)
+	actor := Actor{PersonnelID: refs.PersonnelID, Role: models.RoleAdmin}
+
+	review := models.ProtocolStatus{StatusName: "Em análise"}
+	testdb.Create(t, db, &review)
+	closed := models.ProtocolStatus{StatusName: "Encerrado", IsTerminal: true}
+	testdb.Create(t, db, &closed)
+	for _, transition := range []models.StatusTransition{
+		{TypeID: refs.TypeID, FromStatusID: refs.StatusID, ToStatusID: review.StatusID},
+		{TypeID: refs.TypeID, FromStatusID: review.StatusID, ToStatusID: closed.StatusID},
+	} {
+		if err := db.Omit("FromStatus", "ToStatus").Create(&transition).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	protocol := func(statusID int) models.Protocol {
+		return models.Protocol{
+			Title:      "Status inicial",
+			TypeID:     refs.TypeID,
+			StatusID:   statusID,
+			CustomerID: refs.CustomerID,
+			AssignedTo: refs.PersonnelID,
+		}
+	}
+	if _, err := repo.Create(AllBranchesScope(), actor, protocol(refs.StatusID)); err != nil {
+		t.Fatalf("initial status of the workflow: %v", err)
+	}
+	if _, err := repo.Create(AllBranchesScope(), actor, protocol(closed.StatusID)); !errors.Is(err, ErrInvalidInitialStatus) {
+		t.Fatalf("terminal status: got %v, want ErrInvalidInitialStatus", err)
+	}
+}
+
+// A violação de SLA é registrada pelo sistema, sem autor
+func TestRecordSLABreachesHasNoAuthor(t *testing.T) {
+	db := testdb.Open(t)
+	refs := testdb.SeedProtocolRefs(t, db)
+	numbers, err := NewProtocolNumberGenerator(DefaultProtocolNumberFormat)
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := NewProtocolRepository(db, numbers, NewDeadlineCalculator(false), 24*time.Hour)
+
+	created, err := repo.Create(
+		AllBranchesScope(),
+		Actor{PersonnelID: refs.PersonnelID, Role: models.RoleAdmin},
+		models.Protocol{
+			Title:      "Prazo vencido",
+			TypeID:     refs.TypeID,
+			StatusID:   refs.StatusID,
+			CustomerID: refs.CustomerID,
+			AssignedTo: refs.PersonnelID,
+		},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Model(&models.Protocol{}).
+		Where("protocol_id = ?", created.ProtocolID).
+		Update("deadline", time.Now().Add(-time.Hour)).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	breached, err := repo.RecordSLABreaches(time.Now(), 10)
+	if err != nil || len(breached) != 1 {
+		t.Fatalf("got %d breaches, %v", len(breached), err)
+	}
+
+	var history []models.ProtocolHistory
+	if err := db.Where(
+		"protocol_id = ? AND entry_type = ?", created.ProtocolID, models.HistorySLABreach,
+	).Find(&history).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 1 || history[0].CreatedBy != nil {
+		t.Errorf("breach history %+v, want one entry without an author", history)
+	}
+}
@@ -16,7 +16,7 @@ func NewProtocolStatusRepository(db *gorm.DB) *ProtocolStatusRepository {
 
 func (r *ProtocolStatusRepository) GetAll() ([]models.ProtocolStatus, error) {
 	var statuses []models.ProtocolStatus
-	err := r.DB.Order("order_sequence").Find(&statuses).Error
+	err := r.DB.Order("order_sequence, status_id").Find(&statuses).Error
 	return statuses, err
 }
 
@@ -31,6 +31,13 @@ func (r *ProtocolStatusRepository) GetByID(id int) (
 func (r *ProtocolStatusRepository) Create(status models.ProtocolStatus) (
 	models.ProtocolStatus, error,
 ) {
+	if status.OrderSequence == 0 {
+		if err := r.DB.Model(&models.ProtocolStatus{}).
+			Select("COALESCE(MAX(order_sequence), 0) + 1").
+			Scan(&status.OrderSequence).Error; err != nil {
+			return status, err
+		}
+	}
 	err := r.DB.Create(&status).Error
 	return status, err
 }
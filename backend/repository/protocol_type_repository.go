@@ -42,11 +42,27 @@ func (r *ProtocolTypeRepository) Update(
 			"type_name":             protocolType.TypeName,
 			"description":           protocolType.Description,
 			"default_deadline_days": protocolType.DefaultDeadlineDays,
+			"allowed_mime_types":    protocolType.AllowedMimeTypes,
+			"denied_mime_types":     protocolType.DeniedMimeTypes,
 		},
 	)
 	return result.Error
 }
 
+// UpdateAttachmentPolicy altera só as listas de tipos de anexo aceitos e
+// negados do tipo de protocolo
+func (r *ProtocolTypeRepository) UpdateAttachmentPolicy(
+	id int, allowed, denied string,
+) error {
+	result := r.DB.Model(&models.ProtocolType{TypeID: id}).Updates(
+		map[string]interface{}{
+			"allowed_mime_types": allowed,
+			"denied_mime_types":  denied,
+		},
+	)
+	return requireAffected(result)
+}
+
 func (r *ProtocolTypeRepository) Delete(id int) error {
 	result := r.DB.Delete(&models.ProtocolType{}, id)
 	return result.Error
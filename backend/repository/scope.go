@@ -0,0 +1,91 @@
+// backend/repository/scope.go
+package repository
+
+import (
+	"ProtocolManager/backend/models"
+	"errors"
+	"gorm.io/gorm"
+)
+
+// ErrOutOfScope indica uma tentativa de gravar um registro em uma filial
+// que o usuário não enxerga
+var ErrOutOfScope = errors.New("registro fora da filial do usuário")
+
+// Scope limita as consultas às filiais visíveis para o usuário autenticado.
+// O valor zero não enxerga nenhum registro.
+type Scope struct {
+	AllBranches bool
+	BranchID    *int
+}
+
+// AllBranchesScope é usado para administradores e rotinas internas
+func AllBranchesScope() Scope {
+	return Scope{AllBranches: true}
+}
+
+// BranchScope restringe as consultas a uma única filial
+func BranchScope(branchID int) Scope {
+	return Scope{BranchID: &branchID}
+}
+
+// Allows informa se um registro da filial informada é visível
+func (s Scope) Allows(branchID *int) bool {
+	if s.AllBranches {
+		return true
+	}
+	return s.BranchID != nil && branchID != nil && *s.BranchID == *branchID
+}
+
+// Apply filtra pela coluna branch_id da tabela informada
+func (s Scope) Apply(db *gorm.DB, table string) *gorm.DB {
+	if s.AllBranches {
+		return db
+	}
+	if s.BranchID == nil {
+		return db.Where("1 = 0")
+	}
+	return db.Where(table+".branch_id = ?", *s.BranchID)
+}
+
+// ApplyProtocol filtra registros ligados a um protocolo (histórico, anexos,
+// lembretes) pela filial do protocolo
+func (s Scope) ApplyProtocol(db *gorm.DB, table string) *gorm.DB {
+	if s.AllBranches {
+		return db
+	}
+	if s.BranchID == nil {
+		return db.Where("1 = 0")
+	}
+	return db.Where(
+		table+".protocol_id IN (SELECT protocol_id FROM protocols WHERE branch_id = ?)",
+		*s.BranchID,
+	)
+}
+
+// CheckProtocol retorna gorm.ErrRecordNotFound quando o protocolo não
+// existe ou pertence a outra filial
+func (s Scope) CheckProtocol(db *gorm.DB, protocolID int) error {
+	var count int64
+	err := s.Apply(db.Model(&models.Protocol{}), "protocols").
+		Where("protocol_id = ?", protocolID).
+		Count(&count).Error
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// requireAffected converte um UPDATE/DELETE que não alcançou nenhuma linha
+// em gorm.ErrRecordNotFound
+func requireAffected(result *gorm.DB) error {
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
@@ -0,0 +1,178 @@
+// backend/repository/search_repository.go
+package repository
+
+import (
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// Expressões tsvector usadas nas consultas. Os índices GIN ficam em
+// migrations/sql/0004_search_indexes.up.sql e precisam repetir a expressão
+// exata para que o PostgreSQL os use.
+const (
+	protocolSearchVector = `to_tsvector('portuguese', coalesce(title, '') || ' ' || coalesce(description, ''))`
+	customerSearchVector = `to_tsvector('simple', coalesce(first_name, '') || ' ' || coalesce(last_name, '') || ' ' || coalesce(email, ''))`
+	historySearchVector  = `to_tsvector('portuguese', coalesce(notes, ''))`
+)
+
+const (
+	SearchTypeProtocol = "protocol"
+	SearchTypeCustomer = "customer"
+	SearchTypeHistory  = "history"
+)
+
+// Delimitadores internos do ts_headline; trocados por <mark> depois que o
+// texto é escapado, para que o conteúdo do usuário nunca vire HTML
+const (
+	highlightStart = "\x01"
+	highlightStop  = "\x02"
+)
+
+// SearchResult é um item da busca textual, já com link para a tela de
+// destino e trecho destacado
+type SearchResult struct {
+	Type       string  `json:"type"`
+	ID         int     `json:"id"`
+	ProtocolID *int    `json:"protocol_id,omitempty"`
+	Title      string  `json:"title"`
+	Snippet    string  `json:"snippet"`
+	Rank       float64 `json:"rank"`
+	Link       string  `json:"link"`
+}
+
+type SearchRepository struct {
+	DB *gorm.DB
+}
+
+func NewSearchRepository(db *gorm.DB) *SearchRepository {
+	return &SearchRepository{DB: db}
+}
+
+// Search procura o termo em protocolos, clientes e notas do histórico
+// visíveis no escopo e devolve os resultados ordenados por relevância
+func (r *SearchRepository) Search(
+	scope Scope, term string, types []string, limit int,
+) ([]SearchResult, error) {
+	results := []SearchResult{}
+
+	for _, t := range types {
+		var found []SearchResult
+		var err error
+
+		switch t {
+		case SearchTypeProtocol:
+			found, err = r.searchProtocols(scope, term, limit)
+		case SearchTypeCustomer:
+			found, err = r.searchCustomers(scope, term, limit)
+		case SearchTypeHistory:
+			found, err = r.searchHistory(scope, term, limit)
+		default:
+			return nil, fmt.Errorf("tipo de busca inválido: %s", t)
+		}
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, found...)
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Rank > results[j].Rank
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
+
+	for i := range results {
+		results[i].Snippet = highlight(results[i].Snippet)
+	}
+	return results, nil
+}
+
+func (r *SearchRepository) searchProtocols(
+	scope Scope, term string, limit int,
+) ([]SearchResult, error) {
+	var results []SearchResult
+	err := scope.Apply(r.DB.Table("protocols"), "protocols").
+		Select(
+			`'protocol' AS type, protocol_id AS id, protocol_id,
+			protocol_number || ' - ' || title AS title,
+			ts_headline('portuguese', coalesce(title, '') || ' ' || coalesce(description, ''), websearch_to_tsquery('portuguese', @q), @opts) AS snippet,
+			ts_rank(`+protocolSearchVector+`, websearch_to_tsquery('portuguese', @q)) AS rank`,
+			map[string]interface{}{"q": term, "opts": headlineOptions()},
+		).
+		Where(protocolSearchVector+` @@ websearch_to_tsquery('portuguese', ?)`, term).
+		Order("rank DESC").
+		Limit(limit).
+		Scan(&results).Error
+
+	for i := range results {
+		results[i].Link = fmt.Sprintf("/protocols/%d", results[i].ID)
+	}
+	return results, err
+}
+
+func (r *SearchRepository) searchCustomers(
+	scope Scope, term string, limit int,
+) ([]SearchResult, error) {
+	var results []SearchResult
+	err := scope.Apply(r.DB.Table("customers"), "customers").
+		Select(
+			`'customer' AS type, customer_id AS id,
+			first_name || ' ' || last_name AS title,
+			ts_headline('simple', coalesce(first_name, '') || ' ' || coalesce(last_name, '') || ' ' || coalesce(email, ''), websearch_to_tsquery('simple', @q), @opts) AS snippet,
+			ts_rank(`+customerSearchVector+`, websearch_to_tsquery('simple', @q)) AS rank`,
+			map[string]interface{}{"q": term, "opts": headlineOptions()},
+		).
+		Where(customerSearchVector+` @@ websearch_to_tsquery('simple', ?)`, term).
+		Order("rank DESC").
+		Limit(limit).
+		Scan(&results).Error
+
+	for i := range results {
+		results[i].Link = fmt.Sprintf("/customers/%d", results[i].ID)
+	}
+	return results, err
+}
+
+func (r *SearchRepository) searchHistory(
+	scope Scope, term string, limit int,
+) ([]SearchResult, error) {
+	var results []SearchResult
+	err := scope.ApplyProtocol(r.DB.Table("protocol_history"), "protocol_history").
+		Select(
+			`'history' AS type, protocol_history.protocol_history_id AS id,
+			protocol_history.protocol_id,
+			protocols.protocol_number || ' - ' || protocols.title AS title,
+			ts_headline('portuguese', coalesce(protocol_history.notes, ''), websearch_to_tsquery('portuguese', @q), @opts) AS snippet,
+			ts_rank(`+historySearchVector+`, websearch_to_tsquery('portuguese', @q)) AS rank`,
+			map[string]interface{}{"q": term, "opts": headlineOptions()},
+		).
+		Joins("JOIN protocols ON protocols.protocol_id = protocol_history.protocol_id").
+		Where(historySearchVector+` @@ websearch_to_tsquery('portuguese', ?)`, term).
+		Order("rank DESC").
+		Limit(limit).
+		Scan(&results).Error
+
+	for i := range results {
+		if results[i].ProtocolID != nil {
+			results[i].Link = fmt.Sprintf("/protocols/%d", *results[i].ProtocolID)
+		}
+	}
+	return results, err
+}
+
+func headlineOptions() string {
+	return "StartSel=" + highlightStart + ", StopSel=" + highlightStop +
+		", MaxFragments=2, MaxWords=25, MinWords=8"
+}
+
+// highlight escapa o trecho e converte os delimitadores em <mark>
+func highlight(snippet string) string {
+	escaped := html.EscapeString(snippet)
+	escaped = strings.ReplaceAll(escaped, highlightStart, "<mark>")
+	return strings.ReplaceAll(escaped, highlightStop, "</mark>")
+}
@@ -0,0 +1,157 @@
+// backend/repository/status_transition_repository.go
+package repository
+
+import (
+	"ProtocolManager/backend/models"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrTransitionNotAllowed indica uma mudança de status fora do fluxo
+	// configurado para o tipo do protocolo
+	ErrTransitionNotAllowed = errors.New("transição de status não permitida")
+	// ErrNoteRequired indica que a transição exige uma observação
+	ErrNoteRequired = errors.New("transição de status exige observação")
+	// ErrInvalidInitialStatus indica um status de abertura fora do fluxo do
+	// tipo do protocolo
+	ErrInvalidInitialStatus = errors.New("status inicial inválido para o tipo")
+)
+
+type StatusTransitionRepository struct {
+	DB *gorm.DB
+}
+
+func NewStatusTransitionRepository(db *gorm.DB) *StatusTransitionRepository {
+	return &StatusTransitionRepository{DB: db}
+}
+
+func (r *StatusTransitionRepository) GetByTypeID(typeID int) (
+	[]models.StatusTransition, error,
+) {
+	var transitions []models.StatusTransition
+	result := r.DB.Where("type_id = ?", typeID).
+		Preload("FromStatus").
+		Preload("ToStatus").
+		Order("from_status_id, to_status_id").
+		Find(&transitions)
+	return transitions, result.Error
+}
+
+func (r *StatusTransitionRepository) GetByID(id int) (
+	models.StatusTransition, error,
+) {
+	var transition models.StatusTransition
+	result := r.DB.Preload("FromStatus").Preload("ToStatus").First(&transition, id)
+	return transition, result.Error
+}
+
+func (r *StatusTransitionRepository) Create(
+	transition models.StatusTransition,
+) (models.StatusTransition, error) {
+	result := r.DB.Omit("FromStatus", "ToStatus").Create(&transition)
+	return transition, result.Error
+}
+
+func (r *StatusTransitionRepository) Update(
+	id int, transition models.StatusTransition,
+) error {
+	result := r.DB.Model(&models.StatusTransition{}).Where(
+		"transition_id = ?", id,
+	).Updates(
+		map[string]interface{}{
+			"from_status_id": transition.FromStatusID,
+			"to_status_id":   transition.ToStatusID,
+			"allowed_roles":  transition.AllowedRoles,
+			"requires_note":  transition.RequiresNote,
+		},
+	)
+	return requireAffected(result)
+}
+
+func (r *StatusTransitionRepository) Delete(id int) error {
+	result := r.DB.Delete(&models.StatusTransition{}, id)
+	return requireAffected(result)
+}
+
+// checkStatusTransition valida a mudança de status contra o fluxo do tipo.
+// Tipos sem transições cadastradas aceitam qualquer mudança.
+func checkStatusTransition(
+	tx *gorm.DB, typeID, fromStatusID, toStatusID int, role, note string,
+) error {
+	var configured int64
+	if err := tx.Model(&models.StatusTransition{}).
+		Where("type_id = ?", typeID).
+		Count(&configured).Error; err != nil {
+		return err
+	}
+	if configured == 0 {
+		return nil
+	}
+
+	var transition models.StatusTransition
+	err := tx.Where(
+		"type_id = ? AND from_status_id = ? AND to_status_id = ?",
+		typeID, fromStatusID, toStatusID,
+	).First(&transition).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf(
+			"%w: status %d para %d", ErrTransitionNotAllowed,
+			fromStatusID, toStatusID,
+		)
+	}
+	if err != nil {
+		return err
+	}
+
+	if !transition.AllowsRole(role) {
+		return fmt.Errorf(
+			"%w: papel %q não pode executar esta transição",
+			ErrTransitionNotAllowed, role,
+		)
+	}
+
+	if transition.RequiresNote && note == "" {
+		return ErrNoteRequired
+	}
+
+	return nil
+}
+
+// checkInitialStatus valida o status de um protocolo novo. Com transições
+// cadastradas, o protocolo precisa abrir em um status não terminal de onde
+// o fluxo do tipo parte; sem transições vale qualquer status existente.
+func checkInitialStatus(tx *gorm.DB, typeID, statusID int) error {
+	var status models.ProtocolStatus
+	if err := tx.First(&status, statusID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("%w: status %d não existe", ErrInvalidInitialStatus, statusID)
+		}
+		return err
+	}
+
+	var configured int64
+	if err := tx.Model(&models.StatusTransition{}).
+		Where("type_id = ?", typeID).
+		Count(&configured).Error; err != nil {
+		return err
+	}
+	if configured == 0 {
+		return nil
+	}
+
+	var outgoing int64
+	if err := tx.Model(&models.StatusTransition{}).
+		Where("type_id = ? AND from_status_id = ?", typeID, statusID).
+		Count(&outgoing).Error; err != nil {
+		return err
+	}
+	if status.IsTerminal || outgoing == 0 {
+		return fmt.Errorf(
+			"%w: status %d fora do fluxo do tipo %d",
+			ErrInvalidInitialStatus, statusID, typeID,
+		)
+	}
+	return nil
+}
@@ -3,7 +3,10 @@ package repository
 
 import (
 	"ProtocolManager/backend/models"
+	"errors"
+
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type UserRepository struct {
@@ -23,3 +26,96 @@ func (r *UserRepository) GetByEmail(email string) (models.User, error) {
 	err := r.DB.Where("email = ?", email).First(&user).Error
 	return user, err
 }
+
+func (r *UserRepository) GetByID(id int) (models.User, error) {
+	var user models.User
+	err := r.DB.First(&user, id).Error
+	return user, err
+}
+
+func (r *UserRepository) GetAll() ([]models.User, error) {
+	var users []models.User
+	err := r.DB.Order("email").Find(&users).Error
+	return users, err
+}
+
+// ErrLastAdmin indica que a alteração deixaria o sistema sem administrador
+// ativo
+var ErrLastAdmin = errors.New("alteração removeria o último administrador ativo")
+
+// UserChanges lista os campos alterados em um usuário. Campos nil mantêm o
+// valor atual; com SetPersonnel, PersonnelID nil desvincula o colaborador.
+type UserChanges struct {
+	Role         *string
+	Active       *bool
+	SetPersonnel bool
+	PersonnelID  *int
+}
+
+// Update grava apenas os campos informados em changes. Os administradores
+// ativos são bloqueados, sempre na mesma ordem, antes do usuário alterado;
+// assim duas alterações simultâneas não conseguem rebaixar os dois últimos.
+func (r *UserRepository) Update(id int, changes UserChanges) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		var admins []models.User
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("role = ? AND active", models.RoleAdmin).
+			Order("user_id").Find(&admins).Error; err != nil {
+			return err
+		}
+		var user models.User
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&user, id).Error; err != nil {
+			return err
+		}
+
+		fields := map[string]interface{}{}
+		role, active := user.Role, user.Active
+		if changes.Role != nil {
+			role = *changes.Role
+			fields["role"] = role
+		}
+		if changes.Active != nil {
+			active = *changes.Active
+			fields["active"] = active
+		}
+		if changes.SetPersonnel {
+			fields["personnel_id"] = changes.PersonnelID
+		}
+		if len(fields) == 0 {
+			return nil
+		}
+
+		wasAdmin := user.Role == models.RoleAdmin && user.Active
+		isAdmin := role == models.RoleAdmin && active
+		if wasAdmin && !isAdmin && len(admins) <= 1 {
+			return ErrLastAdmin
+		}
+
+		return tx.Model(&models.User{}).Where("user_id = ?", id).
+			Updates(fields).Error
+	})
+}
+
+// ScopeFor resolve as filiais visíveis para o usuário: administradores
+// enxergam todas, os demais apenas a filial do colaborador vinculado
+func (r *UserRepository) ScopeFor(user models.User) (Scope, error) {
+	if user.Role == models.RoleAdmin {
+		return AllBranchesScope(), nil
+	}
+	if user.PersonnelID == nil {
+		return Scope{}, nil
+	}
+
+	var personnel models.SalesPersonnel
+	if err := r.DB.First(&personnel, *user.PersonnelID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return Scope{}, nil
+		}
+		return Scope{}, err
+	}
+	if personnel.BranchID == nil {
+		return Scope{}, nil
+	}
+	return BranchScope(*personnel.BranchID), nil
+}
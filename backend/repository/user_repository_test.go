@@ -0,0 +1,39 @@
+// backend/repository/user_repository_test.go
+package repository
+
+import (
+	"ProtocolManager/backend/models"
+	"ProtocolManager/backend/testdb"
+	"errors"
+	"testing"
+)
+
+func TestUpdateKeepsOneActiveAdmin(t *testing.T) {
+	db := testdb.Open(t)
+	repo := NewUserRepository(db)
+
+	first := models.User{Email: "a@example.com", Role: models.RoleAdmin, Active: true}
+	second := models.User{Email: "b@example.com", Role: models.RoleAdmin, Active: true}
+	testdb.Create(t, db, &first)
+	testdb.Create(t, db, &second)
+
+	agent, inactive := models.RoleAgent, false
+	if err := repo.Update(first.UserID, UserChanges{Role: &agent}); err != nil {
+		t.Fatalf("demote with another admin left: %v", err)
+	}
+	if err := repo.Update(second.UserID, UserChanges{Active: &inactive}); !errors.Is(err, ErrLastAdmin) {
+		t.Fatalf("deactivate last admin: got %v, want ErrLastAdmin", err)
+	}
+
+	// Campos não enviados permanecem como estavam
+	if err := repo.Update(first.UserID, UserChanges{Active: &inactive}); err != nil {
+		t.Fatal(err)
+	}
+	got, err := repo.GetByID(first.UserID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Role != models.RoleAgent || got.Active {
+		t.Errorf("got role %q active %v, want agent and inactive", got.Role, got.Active)
+	}
+}
@@ -0,0 +1,242 @@
+// backend/repository/webhook_repository.go
+package repository
+
+import (
+	"ProtocolManager/backend/models"
+	"encoding/json"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type WebhookRepository struct {
+	DB *gorm.DB
+}
+
+func NewWebhookRepository(db *gorm.DB) *WebhookRepository {
+	return &WebhookRepository{DB: db}
+}
+
+func (r *WebhookRepository) GetAll() ([]models.WebhookSubscription, error) {
+	var subscriptions []models.WebhookSubscription
+	result := r.DB.Order("subscription_id").Find(&subscriptions)
+	return subscriptions, result.Error
+}
+
+func (r *WebhookRepository) GetByID(id int) (
+	models.WebhookSubscription, error,
+) {
+	var subscription models.WebhookSubscription
+	result := r.DB.First(&subscription, id)
+	return subscription, result.Error
+}
+
+func (r *WebhookRepository) Create(
+	subscription models.WebhookSubscription,
+) (models.WebhookSubscription, error) {
+	result := r.DB.Create(&subscription)
+	return subscription, result.Error
+}
+
+// Update altera URL, eventos e situação; o segredo só muda quando
+// informado
+func (r *WebhookRepository) Update(
+	id int, subscription models.WebhookSubscription,
+) error {
+	fields := map[string]interface{}{
+		"url":    subscription.URL,
+		"events": subscription.Events,
+		"active": subscription.Active,
+	}
+	if subscription.Secret != "" {
+		fields["secret"] = subscription.Secret
+	}
+	result := r.DB.Model(&models.WebhookSubscription{}).Where(
+		"subscription_id = ?", id,
+	).Updates(fields)
+	return requireAffected(result)
+}
+
+// Delete remove a assinatura junto com o histórico de entregas
+func (r *WebhookRepository) Delete(id int) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where(
+			"subscription_id = ?", id,
+		).Delete(&models.WebhookDelivery{}).Error; err != nil {
+			return err
+		}
+		return requireAffected(tx.Delete(&models.WebhookSubscription{}, id))
+	})
+}
+
+// GetDeliveries lista as entregas mais recentes de uma assinatura,
+// opcionalmente filtradas por situação
+func (r *WebhookRepository) GetDeliveries(
+	subscriptionID int, status string, limit int,
+) ([]models.WebhookDelivery, error) {
+	query := r.DB.Where("subscription_id = ?", subscriptionID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var deliveries []models.WebhookDelivery
+	result := query.Order("delivery_id DESC").Limit(limit).Find(&deliveries)
+	return deliveries, result.Error
+}
+
+// Redeliver coloca uma entrega de volta na fila, zerando as tentativas
+func (r *WebhookRepository) Redeliver(subscriptionID, deliveryID int) error {
+	result := r.DB.Model(&models.WebhookDelivery{}).
+		Where(
+			"delivery_id = ? AND subscription_id = ? AND status <> ?",
+			deliveryID, subscriptionID, models.DeliveryPending,
+		).
+		Updates(map[string]interface{}{
+			"status":          models.DeliveryPending,
+			"attempts":        0,
+			"next_attempt_at": nil,
+			"last_error":      "",
+		})
+	return requireAffected(result)
+}
+
+// ClaimDueDeliveries reserva até limit entregas pendentes numa transação
+// curta e as devolve com a assinatura carregada. SKIP LOCKED e a reserva
+// (lease_until) impedem que outra réplica envie a mesma entrega enquanto a
+// requisição acontece, fora de qualquer transação.
+func (r *WebhookRepository) ClaimDueDeliveries(
+	now time.Time, limit int, lease time.Duration,
+) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.
+			Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where(
+				`status = ? AND (next_attempt_at IS NULL OR next_attempt_at <= ?)
+				AND (lease_until IS NULL OR lease_until <= ?)`,
+				models.DeliveryPending, now, now,
+			).
+			Order("delivery_id").
+			Limit(limit).
+			Find(&deliveries).Error
+		if err != nil || len(deliveries) == 0 {
+			return err
+		}
+		ids := make([]int, len(deliveries))
+		for i, d := range deliveries {
+			ids[i] = d.DeliveryID
+		}
+		return tx.Model(&models.WebhookDelivery{}).
+			Where("delivery_id IN ?", ids).
+			Update("lease_until", now.Add(lease)).Error
+	})
+	if err != nil || len(deliveries) == 0 {
+		return nil, err
+	}
+
+	// Assinaturas são carregadas depois da reserva, sem trava
+	ids := make([]int, 0, len(deliveries))
+	for _, d := range deliveries {
+		ids = append(ids, d.SubscriptionID)
+	}
+	var subscriptions []models.WebhookSubscription
+	if err := r.DB.Where(
+		"subscription_id IN ?", ids,
+	).Find(&subscriptions).Error; err != nil {
+		return nil, err
+	}
+	byID := make(map[int]models.WebhookSubscription, len(subscriptions))
+	for _, s := range subscriptions {
+		byID[s.SubscriptionID] = s
+	}
+	for i := range deliveries {
+		deliveries[i].Subscription = byID[deliveries[i].SubscriptionID]
+	}
+	return deliveries, nil
+}
+
+// MarkDelivered registra uma entrega aceita pelo destino e libera a reserva
+func (r *WebhookRepository) MarkDelivered(
+	id, attempts, responseStatus int, now time.Time,
+) error {
+	return r.DB.Model(&models.WebhookDelivery{}).
+		Where("delivery_id = ?", id).
+		Updates(map[string]interface{}{
+			"status":          models.DeliveryDelivered,
+			"attempts":        attempts,
+			"response_status": responseStatus,
+			"delivered_at":    now,
+			"next_attempt_at": nil,
+			"last_error":      "",
+			"lease_until":     nil,
+		}).Error
+}
+
+// MarkAttemptFailed registra uma tentativa que falhou e libera a reserva.
+// Sem nextAttempt a entrega vai para o estado dead.
+func (r *WebhookRepository) MarkAttemptFailed(
+	id, attempts, responseStatus int, nextAttempt *time.Time, cause error,
+) error {
+	status := models.DeliveryPending
+	if nextAttempt == nil {
+		status = models.DeliveryDead
+	}
+	return r.DB.Model(&models.WebhookDelivery{}).
+		Where("delivery_id = ?", id).
+		Updates(map[string]interface{}{
+			"status":          status,
+			"attempts":        attempts,
+			"response_status": responseStatus,
+			"next_attempt_at": nextAttempt,
+			"last_error":      cause.Error(),
+			"lease_until":     nil,
+		}).Error
+}
+
+// webhookEnvelope é o corpo JSON enviado às assinaturas
+type webhookEnvelope struct {
+	ID         string          `json:"id"`
+	Event      string          `json:"event"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Data       json.RawMessage `json:"data"`
+}
+
+// EnqueueEvent grava uma entrega para cada assinatura ativa que recebe o
+// evento do outbox. A chave de idempotência do evento é o event_id da
+// entrega, então reprocessar o mesmo evento não duplica entregas.
+func (r *WebhookRepository) EnqueueEvent(event models.OutboxEvent) error {
+	var subscriptions []models.WebhookSubscription
+	if err := r.DB.Where("active = ?", true).Find(&subscriptions).Error; err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(webhookEnvelope{
+		ID:         event.IdempotencyKey,
+		Event:      event.EventType,
+		OccurredAt: event.OccurredAt,
+		Data:       json.RawMessage(event.Payload),
+	})
+	if err != nil {
+		return err
+	}
+
+	var deliveries []models.WebhookDelivery
+	for _, s := range subscriptions {
+		if s.Matches(event.EventType) {
+			deliveries = append(deliveries, models.WebhookDelivery{
+				SubscriptionID: s.SubscriptionID,
+				EventID:        event.IdempotencyKey,
+				Event:          event.EventType,
+				Payload:        string(payload),
+				Status:         models.DeliveryPending,
+			})
+		}
+	}
+	if len(deliveries) == 0 {
+		return nil
+	}
+	return r.DB.Omit("Subscription").
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&deliveries).Error
+}
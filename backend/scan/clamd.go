@@ -0,0 +1,176 @@
+// backend/scan/clamd.go
+package scan
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+// Tamanho dos blocos enviados ao clamd; deve ficar abaixo do StreamMaxLength
+// configurado no daemon
+const defaultChunkSize = 64 << 10
+
+// ErrSizeLimit indica que o arquivo passou do StreamMaxLength do clamd
+var ErrSizeLimit = errors.New("clamd: arquivo excede o limite do INSTREAM")
+
+// Result é o veredito de uma varredura
+type Result struct {
+	Infected bool
+	// Nome da assinatura encontrada quando Infected
+	Signature string
+}
+
+// Scanner verifica o conteúdo de um arquivo
+type Scanner interface {
+	Scan(ctx context.Context, r io.Reader) (Result, error)
+}
+
+// Clamd fala o protocolo INSTREAM de um daemon clamd via TCP
+type Clamd struct {
+	Addr      string
+	Timeout   time.Duration
+	ChunkSize int
+}
+
+func NewClamd(addr string, timeout time.Duration) *Clamd {
+	return &Clamd{Addr: addr, Timeout: timeout, ChunkSize: defaultChunkSize}
+}
+
+// Scan envia o conteúdo em blocos prefixados pelo tamanho (uint32 big
+// endian) e lê o veredito, terminado por NUL por causa do prefixo "z"
+func (c *Clamd) Scan(ctx context.Context, r io.Reader) (Result, error) {
+	conn, err := c.dial(ctx)
+	if err != nil {
+		return Result{}, err
+	}
+	defer conn.Close()
+
+	if _, err := io.WriteString(conn, "zINSTREAM\x00"); err != nil {
+		return Result{}, fmt.Errorf("clamd: %w", err)
+	}
+	if err := c.stream(conn, r); err != nil {
+		// O clamd fecha a conexão ao passar do limite; a resposta ainda
+		// explica o motivo
+		if reply, readErr := readReply(conn); readErr == nil {
+			if _, replyErr := parseReply(reply); replyErr != nil {
+				return Result{}, replyErr
+			}
+		}
+		return Result{}, err
+	}
+
+	reply, err := readReply(conn)
+	if err != nil {
+		return Result{}, fmt.Errorf("clamd: %w", err)
+	}
+	return parseReply(reply)
+}
+
+// Ping confere se o daemon responde
+func (c *Clamd) Ping(ctx context.Context) error {
+	conn, err := c.dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := io.WriteString(conn, "zPING\x00"); err != nil {
+		return fmt.Errorf("clamd: %w", err)
+	}
+	reply, err := readReply(conn)
+	if err != nil {
+		return fmt.Errorf("clamd: %w", err)
+	}
+	if reply != "PONG" {
+		return fmt.Errorf("clamd: resposta inesperada ao PING: %q", reply)
+	}
+	return nil
+}
+
+func (c *Clamd) dial(ctx context.Context) (net.Conn, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", c.Addr)
+	if err != nil {
+		return nil, fmt.Errorf("clamd: %w", err)
+	}
+
+	deadline, ok := ctx.Deadline()
+	if c.Timeout > 0 {
+		if limit := time.Now().Add(c.Timeout); !ok || limit.Before(deadline) {
+			deadline, ok = limit, true
+		}
+	}
+	if ok {
+		conn.SetDeadline(deadline)
+	}
+	return conn, nil
+}
+
+// stream copia o conteúdo em blocos e termina com um bloco vazio
+func (c *Clamd) stream(w io.Writer, r io.Reader) error {
+	size := c.ChunkSize
+	if size <= 0 {
+		size = defaultChunkSize
+	}
+	buf := make([]byte, 4+size)
+
+	for {
+		n, readErr := io.ReadFull(r, buf[4:])
+		if n > 0 {
+			binary.BigEndian.PutUint32(buf[:4], uint32(n))
+			if _, err := w.Write(buf[:4+n]); err != nil {
+				return fmt.Errorf("clamd: %w", err)
+			}
+		}
+		if readErr == io.EOF || readErr == io.ErrUnexpectedEOF {
+			break
+		}
+		if readErr != nil {
+			return readErr
+		}
+	}
+
+	if _, err := w.Write([]byte{0, 0, 0, 0}); err != nil {
+		return fmt.Errorf("clamd: %w", err)
+	}
+	return nil
+}
+
+func readReply(r io.Reader) (string, error) {
+	reply, err := bufio.NewReader(r).ReadBytes(0)
+	if err != nil && (err != io.EOF || len(reply) == 0) {
+		return "", err
+	}
+	return string(bytes.TrimRight(reply, "\x00\n")), nil
+}
+
+// parseReply interpreta "stream: OK", "stream: <assinatura> FOUND" e
+// "<motivo> ERROR"
+func parseReply(reply string) (Result, error) {
+	verdict := strings.TrimPrefix(reply, "stream: ")
+	switch {
+	case verdict == "OK":
+		return Result{}, nil
+	case strings.HasSuffix(verdict, " FOUND"):
+		return Result{
+			Infected:  true,
+			Signature: strings.TrimSuffix(verdict, " FOUND"),
+		}, nil
+	case strings.Contains(verdict, "size limit exceeded"):
+		return Result{}, ErrSizeLimit
+	case strings.HasSuffix(verdict, " ERROR"):
+		return Result{}, fmt.Errorf(
+			"clamd: %s", strings.TrimSuffix(verdict, " ERROR"),
+		)
+	default:
+		return Result{}, fmt.Errorf("clamd: resposta inesperada: %q", reply)
+	}
+}
@@ -0,0 +1,87 @@
+// backend/scan/clamd_test.go
+package scan
+
+import (
+	"ProtocolManager/backend/scan/clamdtest"
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestClamdScan(t *testing.T) {
+	server := clamdtest.NewServer()
+	defer server.Close()
+
+	clamd := NewClamd(server.Addr, 5*time.Second)
+	// Blocos pequenos para o arquivo atravessar vários deles
+	clamd.ChunkSize = 16
+
+	result, err := clamd.Scan(context.Background(), strings.NewReader(
+		"relatório mensal sem nada suspeito, com mais de um bloco",
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Infected {
+		t.Errorf("clean file reported as infected: %+v", result)
+	}
+
+	result, err = clamd.Scan(context.Background(), strings.NewReader(
+		"cabeçalho "+clamdtest.EICAR+" rodapé",
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Infected || result.Signature != "Win.Test.EICAR_HDB-1" {
+		t.Errorf("EICAR not detected: %+v", result)
+	}
+
+	if server.Scans() != 2 {
+		t.Errorf("server saw %d scans, want 2", server.Scans())
+	}
+}
+
+func TestClamdScanErrors(t *testing.T) {
+	failing := clamdtest.NewUnstartedServer()
+	failing.Fail = "Can't allocate memory"
+	failing.Start()
+	defer failing.Close()
+
+	_, err := NewClamd(failing.Addr, 5*time.Second).
+		Scan(context.Background(), strings.NewReader("arquivo"))
+	if err == nil || !strings.Contains(err.Error(), "Can't allocate memory") {
+		t.Errorf("got %v, want the clamd error", err)
+	}
+
+	limited := clamdtest.NewUnstartedServer()
+	limited.MaxStreamSize = 1024
+	limited.Start()
+	defer limited.Close()
+
+	clamd := NewClamd(limited.Addr, 5*time.Second)
+	clamd.ChunkSize = 256
+	_, err = clamd.Scan(context.Background(), bytes.NewReader(make([]byte, 4096)))
+	if !errors.Is(err, ErrSizeLimit) {
+		t.Errorf("got %v, want ErrSizeLimit", err)
+	}
+
+	// Ninguém escutando: a falha de conexão chega ao chamador
+	addr := failing.Addr
+	failing.Close()
+	if _, err := NewClamd(addr, time.Second).
+		Scan(context.Background(), strings.NewReader("arquivo")); err == nil {
+		t.Error("expected an error without a daemon")
+	}
+}
+
+func TestClamdPing(t *testing.T) {
+	server := clamdtest.NewServer()
+	defer server.Close()
+
+	if err := NewClamd(server.Addr, 5*time.Second).Ping(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,166 @@
+// backend/scan/clamdtest/server.go
+
+// Package clamdtest fornece um clamd falso para testes, no estilo do
+// httptest: ele escuta em uma porta local e responde ao INSTREAM sem
+// precisar do ClamAV instalado.
+package clamdtest
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// EICAR é o arquivo de teste padrão dos antivírus. O servidor o reporta
+// com a mesma assinatura do ClamAV.
+const EICAR = `X5O!P%@AP[4\PZX54(P^)7CC)7}$EICAR-STANDARD-ANTIVIRUS-TEST-FILE!$H+H*`
+
+const eicarSignature = "Win.Test.EICAR_HDB-1"
+
+// Server é um clamd falso
+type Server struct {
+	// Endereço host:porta para o scan.Clamd
+	Addr string
+	// Verdict decide a resposta para o conteúdo recebido: "" é limpo, outro
+	// valor é o nome da assinatura encontrada. O padrão só detecta EICAR.
+	Verdict func(data []byte) string
+	// MaxStreamSize imita o StreamMaxLength do clamd; 0 não limita
+	MaxStreamSize int
+	// Com Fail definido, toda varredura responde "<Fail> ERROR"
+	Fail string
+
+	scans    atomic.Int64
+	listener net.Listener
+	wg       sync.WaitGroup
+}
+
+// NewServer começa a escutar em 127.0.0.1 numa porta livre. Chame Close
+// ao final do teste.
+func NewServer() *Server {
+	s := NewUnstartedServer()
+	s.Start()
+	return s
+}
+
+// NewUnstartedServer reserva a porta sem atender conexões, para que
+// Verdict, MaxStreamSize e Fail sejam ajustados antes de Start
+func NewUnstartedServer() *Server {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		panic("clamdtest: " + err.Error())
+	}
+	return &Server{Addr: listener.Addr().String(), listener: listener}
+}
+
+// Start passa a atender as conexões
+func (s *Server) Start() {
+	s.wg.Add(1)
+	go s.serve()
+}
+
+// Scans conta as varreduras recebidas
+func (s *Server) Scans() int64 {
+	return s.scans.Load()
+}
+
+// Close para de aceitar conexões e espera as que estão abertas
+func (s *Server) Close() {
+	s.listener.Close()
+	s.wg.Wait()
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			defer conn.Close()
+			s.handle(conn)
+		}()
+	}
+}
+
+// handle atende um comando por conexão, com prefixo "z" (resposta
+// terminada por NUL) ou "n" (terminada por quebra de linha)
+func (s *Server) handle(conn net.Conn) {
+	r := bufio.NewReader(conn)
+	prefix, err := r.ReadByte()
+	if err != nil {
+		return
+	}
+	delim, terminator := byte('\n'), "\n"
+	if prefix == 'z' {
+		delim, terminator = 0, "\x00"
+	}
+	command, err := r.ReadString(delim)
+	if err != nil {
+		return
+	}
+
+	reply := func(msg string) {
+		io.WriteString(conn, msg+terminator)
+	}
+	switch strings.TrimRight(command, "\x00\n") {
+	case "PING":
+		reply("PONG")
+	case "INSTREAM":
+		s.scans.Add(1)
+		data, ok := s.readStream(r)
+		switch {
+		case !ok:
+			reply("INSTREAM size limit exceeded. ERROR")
+		case s.Fail != "":
+			reply(s.Fail + " ERROR")
+		default:
+			if signature := s.verdict(data); signature != "" {
+				reply("stream: " + signature + " FOUND")
+			} else {
+				reply("stream: OK")
+			}
+		}
+	default:
+		reply("UNKNOWN COMMAND")
+	}
+}
+
+// readStream lê os blocos até o bloco vazio; false quando passa de
+// MaxStreamSize
+func (s *Server) readStream(r io.Reader) ([]byte, bool) {
+	var data bytes.Buffer
+	var size [4]byte
+	for {
+		if _, err := io.ReadFull(r, size[:]); err != nil {
+			return nil, false
+		}
+		n := binary.BigEndian.Uint32(size[:])
+		if n == 0 {
+			return data.Bytes(), true
+		}
+		if s.MaxStreamSize > 0 && data.Len()+int(n) > s.MaxStreamSize {
+			return nil, false
+		}
+		if _, err := io.CopyN(&data, r, int64(n)); err != nil {
+			return nil, false
+		}
+	}
+}
+
+func (s *Server) verdict(data []byte) string {
+	if s.Verdict != nil {
+		return s.Verdict(data)
+	}
+	if bytes.Contains(data, []byte(EICAR)) {
+		return eicarSignature
+	}
+	return ""
+}
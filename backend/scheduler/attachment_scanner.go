@@ -0,0 +1,166 @@
+// backend/scheduler/attachment_scanner.go
+package scheduler
+
+import (
+	"ProtocolManager/backend/models"
+	"ProtocolManager/backend/repository"
+	"ProtocolManager/backend/scan"
+	"ProtocolManager/backend/storage"
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+const (
+	scanBatchSize  = 10
+	scanTimeout    = 2 * time.Minute
+	maxScanBackoff = time.Hour
+	// A reserva cobre o lote inteiro varrido em sequência, com folga
+	scanLease = scanBatchSize*scanTimeout + time.Minute
+)
+
+// AttachmentScanner passa as versões de anexo pendentes pelo antivírus. As
+// versões são reservadas numa transação curta e cada uma é marcada ao fim da
+// própria varredura, sem transação aberta enquanto o clamd responde. Quando
+// o clamd falha, FailOpen libera o anexo como clean (registrando o motivo em
+// scan_result); caso contrário o anexo continua pendente e a varredura é
+// repetida com espera exponencial até dar certo.
+type AttachmentScanner struct {
+	Repo  *repository.ProtocolAttachmentRepository
+	Files storage.Storage
+	// nil significa sem antivírus: com FailOpen os anexos são liberados sem
+	// verificação; sem ele continuam pendentes e o download fica bloqueado
+	Scanner  scan.Scanner
+	Interval time.Duration
+	FailOpen bool
+	Backoff  time.Duration
+}
+
+func NewAttachmentScanner(
+	repo *repository.ProtocolAttachmentRepository,
+	files storage.Storage,
+	scanner scan.Scanner,
+	interval time.Duration,
+	failOpen bool,
+	backoff time.Duration,
+) *AttachmentScanner {
+	return &AttachmentScanner{
+		Repo:     repo,
+		Files:    files,
+		Scanner:  scanner,
+		Interval: interval,
+		FailOpen: failOpen,
+		Backoff:  backoff,
+	}
+}
+
+// Run executa a varredura até o contexto ser cancelado
+func (s *AttachmentScanner) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.Interval)
+	defer ticker.Stop()
+
+	for {
+		s.scanPending(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *AttachmentScanner) scanPending(ctx context.Context) {
+	if s.Scanner == nil && !s.FailOpen {
+		return
+	}
+	for ctx.Err() == nil {
+		versions, err := s.Repo.ClaimPendingScans(
+			time.Now(), scanBatchSize, scanLease,
+		)
+		if err != nil {
+			log.Printf("Attachment scanner: %v", err)
+			return
+		}
+		for _, version := range versions {
+			if ctx.Err() != nil {
+				// As restantes voltam a ficar disponíveis quando a reserva
+				// expirar
+				return
+			}
+			if err := s.check(ctx, version); err != nil {
+				log.Printf(
+					"Attachment %d version %d: %v",
+					version.AttachmentID, version.Version, err,
+				)
+			}
+		}
+		if len(versions) < scanBatchSize {
+			return
+		}
+	}
+}
+
+func (s *AttachmentScanner) check(
+	ctx context.Context, version models.AttachmentVersion,
+) error {
+	if s.Scanner == nil {
+		return s.Repo.MarkScanned(
+			version, models.ScanClean, "scanning disabled", time.Now(),
+		)
+	}
+
+	result, scanErr := s.scan(ctx, version)
+	if scanErr != nil && ctx.Err() != nil {
+		// Servidor encerrando: o anexo continua pendente, sem contar
+		// tentativa, e volta quando a reserva expirar
+		return nil
+	}
+	now := time.Now()
+	switch {
+	case scanErr == nil && result.Infected:
+		log.Printf(
+			"Attachment %d version %d is infected: %s",
+			version.AttachmentID, version.Version, result.Signature,
+		)
+		return s.Repo.MarkScanned(
+			version, models.ScanInfected, result.Signature, now,
+		)
+	case scanErr == nil:
+		return s.Repo.MarkScanned(version, models.ScanClean, "", now)
+	case s.FailOpen:
+		log.Printf(
+			"Attachment %d version %d released without scan (fail-open): %v",
+			version.AttachmentID, version.Version, scanErr,
+		)
+		return s.Repo.MarkScanned(
+			version, models.ScanClean, "not scanned: "+scanErr.Error(), now,
+		)
+	}
+
+	attempts := version.ScanAttempts + 1
+	next := now.Add(backoff(s.Backoff, attempts, maxScanBackoff))
+	log.Printf(
+		"Attachment %d version %d: scan attempt %d failed, retrying at %s: %v",
+		version.AttachmentID, version.Version, attempts,
+		next.Format(time.RFC3339), scanErr,
+	)
+	return s.Repo.MarkScanFailed(version.VersionID, attempts, next, scanErr)
+}
+
+// scan envia o arquivo ao antivírus direto do armazenamento
+func (s *AttachmentScanner) scan(
+	ctx context.Context, version models.AttachmentVersion,
+) (scan.Result, error) {
+	ctx, cancel := context.WithTimeout(ctx, scanTimeout)
+	defer cancel()
+
+	file, _, err := s.Files.Get(ctx, version.FilePath)
+	if err != nil {
+		return scan.Result{}, fmt.Errorf("open %s: %w", version.FilePath, err)
+	}
+	defer file.Close()
+
+	return s.Scanner.Scan(ctx, file)
+}
@@ -0,0 +1,179 @@
+// backend/scheduler/attachment_scanner_test.go
+package scheduler
+
+import (
+	"ProtocolManager/backend/models"
+	"ProtocolManager/backend/repository"
+	"ProtocolManager/backend/scan"
+	"ProtocolManager/backend/scan/clamdtest"
+	"ProtocolManager/backend/storage"
+	"ProtocolManager/backend/testdb"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// newPendingAttachment grava content no armazenamento e cria um anexo
+// aguardando a varredura
+func newPendingAttachment(
+	t *testing.T, db *gorm.DB, files storage.Storage, content string,
+) models.ProtocolAttachment {
+	t.Helper()
+	refs := testdb.SeedProtocolRefs(t, db)
+	numbers, err := repository.NewProtocolNumberGenerator(
+		repository.DefaultProtocolNumberFormat,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	protocol, err := repository.NewProtocolRepository(
+		db, numbers, repository.NewDeadlineCalculator(false), 24*time.Hour,
+	).Create(
+		repository.AllBranchesScope(),
+		repository.Actor{PersonnelID: refs.PersonnelID, Role: models.RoleAdmin},
+		models.Protocol{
+			Title:      "Varredura",
+			TypeID:     refs.TypeID,
+			StatusID:   refs.StatusID,
+			CustomerID: refs.CustomerID,
+			AssignedTo: refs.PersonnelID,
+		},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key := "protocols/scan/file.txt"
+	if err := files.Put(
+		context.Background(), key, strings.NewReader(content),
+		int64(len(content)), "text/plain",
+	); err != nil {
+		t.Fatal(err)
+	}
+	created, err := repository.NewProtocolAttachmentRepository(db).Create(
+		repository.AllBranchesScope(), models.ProtocolAttachment{
+			ProtocolID:      protocol.ProtocolID,
+			FileName:        "file.txt",
+			FilePath:        key,
+			FileSize:        int64(len(content)),
+			ContentType:     "text/plain",
+			UploadedBy:      refs.PersonnelID,
+			UploadedAt:      time.Now(),
+			ScanStatus:      models.ScanPending,
+			ThumbnailStatus: models.ThumbnailPending,
+		}, 0,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return created
+}
+
+func TestAttachmentScanner(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		noClamd  bool
+		fail     string
+		failOpen bool
+		// Situação esperada no anexo e prefixo de scan_result
+		status   string
+		result   string
+		attempts int
+	}{
+		{name: "clean", content: "relatório", status: models.ScanClean},
+		{
+			name: "infected", content: clamdtest.EICAR,
+			status: models.ScanInfected, result: "Win.Test.EICAR_HDB-1",
+		},
+		{
+			name: "fail-closed", content: "relatório", fail: "Can't allocate memory",
+			status: models.ScanPending, result: "clamd: Can't allocate memory",
+			attempts: 1,
+		},
+		{
+			name: "fail-open", content: "relatório", fail: "Can't allocate memory",
+			failOpen: true, status: models.ScanClean, result: "not scanned: ",
+		},
+		{
+			name: "no clamd", content: "relatório", noClamd: true,
+			status: models.ScanPending,
+		},
+		{
+			name: "no clamd, fail-open", content: "relatório", noClamd: true,
+			failOpen: true, status: models.ScanClean, result: "scanning disabled",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := testdb.Open(t)
+			files, err := storage.NewLocal(t.TempDir())
+			if err != nil {
+				t.Fatal(err)
+			}
+			attachment := newPendingAttachment(t, db, files, tt.content)
+
+			var scanner scan.Scanner
+			var server *clamdtest.Server
+			var lockErr error
+			if !tt.noClamd {
+				server = clamdtest.NewUnstartedServer()
+				server.Fail = tt.fail
+				server.Verdict = func(data []byte) string {
+					// Durante a varredura a versão não pode estar travada
+					lockErr = db.Exec(
+						"SELECT 1 FROM protocol_attachment_versions WHERE attachment_id = ? FOR UPDATE NOWAIT",
+						attachment.AttachmentID,
+					).Error
+					if strings.Contains(string(data), clamdtest.EICAR) {
+						return "Win.Test.EICAR_HDB-1"
+					}
+					return ""
+				}
+				server.Start()
+				scanner = scan.NewClamd(server.Addr, 5*time.Second)
+			}
+
+			NewAttachmentScanner(
+				repository.NewProtocolAttachmentRepository(db), files, scanner,
+				time.Second, tt.failOpen, time.Minute,
+			).scanPending(context.Background())
+			if server != nil {
+				// Close espera as conexões, então lockErr já foi gravado
+				server.Close()
+			}
+
+			if lockErr != nil {
+				t.Fatalf("version row locked during the scan: %v", lockErr)
+			}
+			var version models.AttachmentVersion
+			if err := db.Where(
+				"attachment_id = ?", attachment.AttachmentID,
+			).First(&version).Error; err != nil {
+				t.Fatal(err)
+			}
+			var stored models.ProtocolAttachment
+			if err := db.First(&stored, attachment.AttachmentID).Error; err != nil {
+				t.Fatal(err)
+			}
+			if version.ScanStatus != tt.status || stored.ScanStatus != tt.status {
+				t.Errorf(
+					"version %q attachment %q, want %q",
+					version.ScanStatus, stored.ScanStatus, tt.status,
+				)
+			}
+			if !strings.HasPrefix(version.ScanResult, tt.result) {
+				t.Errorf("scan_result %q, want prefix %q", version.ScanResult, tt.result)
+			}
+			if version.ScanAttempts != tt.attempts {
+				t.Errorf("scan_attempts %d, want %d", version.ScanAttempts, tt.attempts)
+			}
+			if version.ScanLeaseUntil != nil {
+				t.Errorf("lease left behind: %v", version.ScanLeaseUntil)
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+// backend/scheduler/notification_dispatcher.go
+package scheduler
+
+import (
+	"ProtocolManager/backend/models"
+	"ProtocolManager/backend/notify"
+	"ProtocolManager/backend/repository"
+	"context"
+	"log"
+	"time"
+)
+
+const (
+	notificationBatchSize   = 20
+	notificationSendTimeout = 30 * time.Second
+	maxNotificationBackoff  = time.Hour
+	// A reserva cobre o lote inteiro enviado em sequência, com folga
+	notificationLease = notificationBatchSize*notificationSendTimeout + time.Minute
+)
+
+// NotificationDispatcher envia as notificações enfileiradas pelo consumidor
+// do outbox. As notificações são reservadas numa transação curta e cada
+// uma é marcada ao fim do próprio envio, sem transação aberta durante o
+// SMTP. Falhas são tentadas novamente com espera exponencial até
+// MaxAttempts.
+type NotificationDispatcher struct {
+	Repo        *repository.NotificationRepository
+	Notifier    *notify.EventNotifier
+	Interval    time.Duration
+	MaxAttempts int
+	Backoff     time.Duration
+}
+
+func NewNotificationDispatcher(
+	repo *repository.NotificationRepository,
+	notifier *notify.EventNotifier,
+	interval time.Duration,
+	maxAttempts int,
+	backoff time.Duration,
+) *NotificationDispatcher {
+	return &NotificationDispatcher{
+		Repo:        repo,
+		Notifier:    notifier,
+		Interval:    interval,
+		MaxAttempts: maxAttempts,
+		Backoff:     backoff,
+	}
+}
+
+// Run executa o despacho até o contexto ser cancelado
+func (d *NotificationDispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.Interval)
+	defer ticker.Stop()
+
+	for {
+		d.dispatch(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (d *NotificationDispatcher) dispatch(ctx context.Context) {
+	for ctx.Err() == nil {
+		notifications, err := d.Repo.ClaimDue(
+			time.Now(), notificationBatchSize, notificationLease,
+		)
+		if err != nil {
+			log.Printf("Notification dispatcher: %v", err)
+			return
+		}
+		for _, notification := range notifications {
+			if ctx.Err() != nil {
+				// As restantes voltam a ficar disponíveis quando a reserva
+				// expirar
+				return
+			}
+			if err := d.deliver(ctx, notification); err != nil {
+				log.Printf("Notification %d: %v", notification.NotificationID, err)
+			}
+		}
+		if len(notifications) < notificationBatchSize {
+			return
+		}
+	}
+}
+
+func (d *NotificationDispatcher) deliver(
+	ctx context.Context, notification models.Notification,
+) error {
+	sendCtx, cancel := context.WithTimeout(ctx, notificationSendTimeout)
+	defer cancel()
+
+	attempts := notification.Attempts + 1
+	sendErr := d.Notifier.Send(sendCtx, notification)
+	now := time.Now()
+	if sendErr == nil {
+		return d.Repo.MarkSent(notification.NotificationID, attempts, now)
+	}
+
+	var next *time.Time
+	if attempts < d.MaxAttempts {
+		at := now.Add(backoff(d.Backoff, attempts, maxNotificationBackoff))
+		next = &at
+		log.Printf(
+			"Notification %d (%s): attempt %d failed, retrying at %s: %v",
+			notification.NotificationID, notification.EventType, attempts,
+			at.Format(time.RFC3339), sendErr,
+		)
+	} else {
+		log.Printf(
+			"Notification %d (%s): giving up after %d attempts: %v",
+			notification.NotificationID, notification.EventType, attempts, sendErr,
+		)
+	}
+
+	return d.Repo.MarkAttemptFailed(
+		notification.NotificationID, attempts, next, sendErr,
+	)
+}
@@ -0,0 +1,109 @@
+// backend/scheduler/notification_dispatcher_test.go
+package scheduler
+
+import (
+	"ProtocolManager/backend/models"
+	"ProtocolManager/backend/notify"
+	"ProtocolManager/backend/repository"
+	"ProtocolManager/backend/testdb"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// flakyNotifier falha no primeiro aviso de SLA e verifica que a
+// notificação não está travada durante o envio
+type flakyNotifier struct {
+	notify.LogNotifier
+	db      *gorm.DB
+	calls   int
+	lockErr error
+}
+
+func (n *flakyNotifier) NotifySLABreach(context.Context, models.Protocol) error {
+	n.calls++
+	if err := n.db.Exec(
+		"SELECT 1 FROM notification_queue FOR UPDATE NOWAIT",
+	).Error; err != nil && n.lockErr == nil {
+		n.lockErr = err
+	}
+	if n.calls == 1 {
+		return errors.New("smtp indisponível")
+	}
+	return nil
+}
+
+func TestNotificationDispatcherRetriesOutsideTheTransaction(t *testing.T) {
+	db := testdb.Open(t)
+	refs := testdb.SeedProtocolRefs(t, db)
+	numbers, err := repository.NewProtocolNumberGenerator(
+		repository.DefaultProtocolNumberFormat,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	protocol, err := repository.NewProtocolRepository(
+		db, numbers, repository.NewDeadlineCalculator(false), 24*time.Hour,
+	).Create(
+		repository.AllBranchesScope(),
+		repository.Actor{PersonnelID: refs.PersonnelID, Role: models.RoleAdmin},
+		models.Protocol{
+			Title:      "Prazo",
+			TypeID:     refs.TypeID,
+			StatusID:   refs.StatusID,
+			CustomerID: refs.CustomerID,
+			AssignedTo: refs.PersonnelID,
+		},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	payload, err := json.Marshal(models.NewProtocolEventData(protocol))
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := repository.NewNotificationRepository(db)
+	event := models.OutboxEvent{
+		IdempotencyKey: "event-1",
+		EventType:      models.EventProtocolSLABreached,
+		ProtocolID:     protocol.ProtocolID,
+		Payload:        string(payload),
+	}
+	// O relay pode entregar o mesmo evento de novo
+	for range 2 {
+		if err := notify.NewOutboxConsumer(repo).Handle(
+			context.Background(), event,
+		); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	notifier := &flakyNotifier{db: db}
+	dispatcher := NewNotificationDispatcher(
+		repo, notify.NewEventNotifier(db, notifier),
+		time.Second, 3, time.Millisecond,
+	)
+	dispatcher.dispatch(context.Background())
+	time.Sleep(10 * time.Millisecond)
+	dispatcher.dispatch(context.Background())
+
+	if notifier.lockErr != nil {
+		t.Fatalf("notification locked during the send: %v", notifier.lockErr)
+	}
+	var stored []models.Notification
+	if err := db.Find(&stored).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(stored) != 1 {
+		t.Fatalf("got %d notifications, want 1", len(stored))
+	}
+	if stored[0].Status != models.NotificationSent || stored[0].Attempts != 2 ||
+		stored[0].LeaseUntil != nil || notifier.calls != 2 {
+		t.Errorf("notification %+v after %d calls", stored[0], notifier.calls)
+	}
+}
@@ -0,0 +1,209 @@
+// backend/scheduler/outbox_relay.go
+package scheduler
+
+import (
+	"ProtocolManager/backend/models"
+	"ProtocolManager/backend/repository"
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+const (
+	outboxBatchSize     = 100
+	outboxHandleTimeout = 30 * time.Second
+	outboxPruneInterval = time.Hour
+	// A reserva do cursor cobre um lote inteiro no pior caso
+	outboxLease = outboxBatchSize*outboxHandleTimeout + time.Minute
+)
+
+// OutboxConsumer recebe os eventos do outbox. A entrega é at-least-once:
+// um evento pode chegar de novo após uma falha ou reinício, então Handle
+// deve usar IdempotencyKey para descartar repetições.
+type OutboxConsumer interface {
+	// Name identifica o cursor do consumidor; não deve mudar entre versões
+	Name() string
+	Handle(ctx context.Context, event models.OutboxEvent) error
+}
+
+// OutboxRelay publica os eventos do outbox para cada consumidor na ordem de
+// models.OutboxPosition. Cada consumidor tem o próprio cursor, reservado
+// por uma réplica de cada vez: um consumidor com falha para no evento
+// problemático e tenta de novo no próximo ciclo, sem atrasar os demais. Depois de MaxAttempts falhas o evento vai para
+// outbox_dead_letters e o consumidor segue para o próximo.
+type OutboxRelay struct {
+	Repo        *repository.OutboxRepository
+	Consumers   []OutboxConsumer
+	Interval    time.Duration
+	MaxAttempts int
+	// Eventos processados por todos os consumidores são removidos após
+	// Retention; zero mantém todos
+	Retention time.Duration
+
+	lastPrune time.Time
+}
+
+func NewOutboxRelay(
+	repo *repository.OutboxRepository,
+	interval time.Duration,
+	maxAttempts int,
+	retention time.Duration,
+	consumers ...OutboxConsumer,
+) *OutboxRelay {
+	return &OutboxRelay{
+		Repo:        repo,
+		Consumers:   consumers,
+		Interval:    interval,
+		MaxAttempts: maxAttempts,
+		Retention:   retention,
+	}
+}
+
+// Run publica os eventos até o contexto ser cancelado
+func (r *OutboxRelay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.Interval)
+	defer ticker.Stop()
+
+	for {
+		for _, consumer := range r.Consumers {
+			r.relay(ctx, consumer)
+		}
+		r.prune()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// relay entrega os eventos pendentes de um consumidor em lotes, até
+// alcançar o fim do outbox ou encontrar uma falha
+func (r *OutboxRelay) relay(ctx context.Context, consumer OutboxConsumer) {
+	for ctx.Err() == nil {
+		cursor, ok, err := r.Repo.ClaimCursor(
+			consumer.Name(), time.Now(), outboxLease,
+		)
+		if err != nil {
+			log.Printf("Outbox relay (%s): %v", consumer.Name(), err)
+			return
+		}
+		if !ok {
+			// Outra réplica está processando o consumidor
+			return
+		}
+
+		processed, failed, err := r.deliver(ctx, consumer, cursor)
+		if releaseErr := r.Repo.ReleaseCursor(cursor); err == nil {
+			err = releaseErr
+		}
+		if err != nil {
+			log.Printf("Outbox relay (%s): %v", consumer.Name(), err)
+			return
+		}
+		if failed || processed < outboxBatchSize {
+			return
+		}
+	}
+}
+
+// deliver entrega um lote a partir do cursor reservado. A leitura e cada
+// avanço do cursor são operações curtas; os consumidores rodam fora de
+// qualquer transação.
+func (r *OutboxRelay) deliver(
+	ctx context.Context, consumer OutboxConsumer, cursor models.OutboxCursor,
+) (processed int, failed bool, err error) {
+	events, err := r.Repo.EventsAfter(
+		r.Repo.DB, cursor.Position(), outboxBatchSize,
+	)
+	if err != nil {
+		return 0, false, err
+	}
+
+	last := cursor.Position()
+	for _, event := range events {
+		cause := r.handle(ctx, consumer, event)
+		if ctx.Err() != nil {
+			// Interrompido no desligamento: o evento não conta como tentativa
+			return processed, true, nil
+		}
+		if cause == nil {
+			if err := r.Repo.AdvanceCursor(
+				cursor, event.Position(), nil, time.Now(),
+			); err != nil {
+				return processed, false, err
+			}
+			last = event.Position()
+			processed++
+			continue
+		}
+
+		attempts := 1
+		if cursor.FailedEventID == event.EventID {
+			attempts = cursor.Attempts + 1
+		}
+		if attempts < r.MaxAttempts {
+			return processed, true, r.Repo.AdvanceCursor(
+				cursor, last, &repository.OutboxFailure{
+					EventID: event.EventID, Attempts: attempts, Cause: cause,
+				}, time.Now(),
+			)
+		}
+
+		// Tentativas esgotadas: o evento é estacionado para não bloquear os
+		// seguintes
+		if err := r.Repo.ParkEvent(
+			cursor, event, attempts, cause, time.Now(),
+		); err != nil {
+			return processed, false, err
+		}
+		log.Printf(
+			"Outbox relay (%s): event %d (%s) parked after %d attempts",
+			consumer.Name(), event.EventID, event.EventType, attempts,
+		)
+		last = event.Position()
+		processed++
+	}
+	return processed, false, nil
+}
+
+func (r *OutboxRelay) handle(
+	ctx context.Context, consumer OutboxConsumer, event models.OutboxEvent,
+) (err error) {
+	handleCtx, cancel := context.WithTimeout(ctx, outboxHandleTimeout)
+	defer cancel()
+
+	// Um consumidor com defeito não pode derrubar o relay
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("panic: %v", p)
+		}
+	}()
+
+	if err := consumer.Handle(handleCtx, event); err != nil {
+		log.Printf(
+			"Outbox relay (%s): event %d (%s) failed: %v",
+			consumer.Name(), event.EventID, event.EventType, err,
+		)
+		return err
+	}
+	return nil
+}
+
+func (r *OutboxRelay) prune() {
+	if r.Retention <= 0 || time.Since(r.lastPrune) < outboxPruneInterval {
+		return
+	}
+	r.lastPrune = time.Now()
+
+	removed, err := r.Repo.Prune(time.Now().Add(-r.Retention))
+	if err != nil {
+		log.Printf("Outbox relay: prune failed: %v", err)
+		return
+	}
+	if removed > 0 {
+		log.Printf("Outbox relay: removed %d processed events", removed)
+	}
+}
@@ -0,0 +1,83 @@
+// backend/scheduler/outbox_relay_test.go
+package scheduler
+
+import (
+	"ProtocolManager/backend/models"
+	"ProtocolManager/backend/repository"
+	"ProtocolManager/backend/testdb"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// failingConsumer recusa sempre o evento bad e registra os demais
+type failingConsumer struct {
+	bad     int64
+	handled []int64
+}
+
+func (c *failingConsumer) Name() string { return "test" }
+
+func (c *failingConsumer) Handle(_ context.Context, event models.OutboxEvent) error {
+	if event.EventID == c.bad {
+		return errors.New("payload inválido")
+	}
+	c.handled = append(c.handled, event.EventID)
+	return nil
+}
+
+func TestRelayParksEventAfterMaxAttempts(t *testing.T) {
+	db := testdb.Open(t)
+	repo := repository.NewOutboxRepository(db)
+
+	events := make([]models.OutboxEvent, 3)
+	for i := range events {
+		events[i] = models.OutboxEvent{
+			IdempotencyKey: fmt.Sprintf("event-%d", i),
+			EventType:      models.EventProtocolUpdated,
+			ProtocolID:     1,
+			Payload:        "{}",
+			OccurredAt:     time.Now(),
+		}
+		testdb.Create(t, db, &events[i])
+	}
+
+	consumer := &failingConsumer{bad: events[1].EventID}
+	relay := NewOutboxRelay(repo, time.Second, 3, 0, consumer)
+
+	cursor := func() models.OutboxCursor {
+		var c models.OutboxCursor
+		if err := db.First(&c, "consumer = ?", consumer.Name()).Error; err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+
+	// Antes de esgotar as tentativas o consumidor fica parado no evento
+	for attempt := 1; attempt < 3; attempt++ {
+		relay.relay(context.Background(), consumer)
+		c := cursor()
+		if c.LastEventID != events[0].EventID || c.FailedEventID != events[1].EventID ||
+			c.Attempts != attempt {
+			t.Fatalf("attempt %d: cursor %+v", attempt, c)
+		}
+	}
+
+	relay.relay(context.Background(), consumer)
+	if c := cursor(); c.LastEventID != events[2].EventID || c.Attempts != 0 {
+		t.Fatalf("after parking: cursor %+v", c)
+	}
+	if len(consumer.handled) != 2 || consumer.handled[1] != events[2].EventID {
+		t.Errorf("handled %v, want the first and the last event", consumer.handled)
+	}
+
+	var parked []models.OutboxDeadLetter
+	if err := db.Find(&parked).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(parked) != 1 || parked[0].EventID != events[1].EventID || parked[0].Attempts != 3 {
+		t.Errorf("dead letters %+v", parked)
+	}
+}
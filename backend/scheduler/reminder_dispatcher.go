@@ -0,0 +1,134 @@
+// backend/scheduler/reminder_dispatcher.go
+package scheduler
+
+import (
+	"ProtocolManager/backend/models"
+	"ProtocolManager/backend/notify"
+	"ProtocolManager/backend/repository"
+	"context"
+	"log"
+	"time"
+)
+
+const (
+	reminderBatchSize   = 20
+	reminderSendTimeout = 30 * time.Second
+	maxReminderBackoff  = time.Hour
+	// A reserva cobre o lote inteiro enviado em sequência, com folga
+	reminderLease = reminderBatchSize*reminderSendTimeout + time.Minute
+)
+
+// ReminderDispatcher envia os lembretes vencidos pelo notificador
+// configurado e os marca como enviados. Os lembretes são reservados numa
+// transação curta e cada um é marcado ao fim do próprio envio, sem
+// transação aberta durante o envio. Falhas são tentadas novamente com
+// espera exponencial até MaxAttempts.
+type ReminderDispatcher struct {
+	Repo        *repository.ProtocolReminderRepository
+	Notifier    notify.ReminderNotifier
+	Interval    time.Duration
+	MaxAttempts int
+	Backoff     time.Duration
+}
+
+func NewReminderDispatcher(
+	repo *repository.ProtocolReminderRepository,
+	notifier notify.ReminderNotifier,
+	interval time.Duration,
+	maxAttempts int,
+	backoff time.Duration,
+) *ReminderDispatcher {
+	return &ReminderDispatcher{
+		Repo:        repo,
+		Notifier:    notifier,
+		Interval:    interval,
+		MaxAttempts: maxAttempts,
+		Backoff:     backoff,
+	}
+}
+
+// Run executa o despacho até o contexto ser cancelado
+func (d *ReminderDispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.Interval)
+	defer ticker.Stop()
+
+	for {
+		d.dispatch(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (d *ReminderDispatcher) dispatch(ctx context.Context) {
+	for ctx.Err() == nil {
+		reminders, err := d.Repo.ClaimDueReminders(
+			time.Now(), reminderBatchSize, reminderLease,
+		)
+		if err != nil {
+			log.Printf("Reminder dispatcher: %v", err)
+			return
+		}
+		for _, reminder := range reminders {
+			if ctx.Err() != nil {
+				// Os restantes voltam a ficar disponíveis quando a reserva
+				// expirar
+				return
+			}
+			if err := d.deliver(ctx, reminder); err != nil {
+				log.Printf("Reminder %d: %v", reminder.ReminderID, err)
+			}
+		}
+		if len(reminders) < reminderBatchSize {
+			return
+		}
+	}
+}
+
+func (d *ReminderDispatcher) deliver(
+	ctx context.Context, reminder models.ProtocolReminder,
+) error {
+	sendCtx, cancel := context.WithTimeout(ctx, reminderSendTimeout)
+	defer cancel()
+
+	sendErr := d.Notifier.NotifyReminder(sendCtx, reminder)
+	now := time.Now()
+	if sendErr == nil {
+		return d.Repo.MarkDelivered(reminder.ReminderID, now)
+	}
+
+	attempts := reminder.Attempts + 1
+	var next *time.Time
+	if attempts < d.MaxAttempts {
+		at := now.Add(backoff(d.Backoff, attempts, maxReminderBackoff))
+		next = &at
+		log.Printf(
+			"Reminder %d: attempt %d failed, retrying at %s: %v",
+			reminder.ReminderID, attempts, at.Format(time.RFC3339), sendErr,
+		)
+	} else {
+		log.Printf(
+			"Reminder %d: giving up after %d attempts: %v",
+			reminder.ReminderID, attempts, sendErr,
+		)
+	}
+
+	return d.Repo.MarkAttemptFailed(
+		reminder.ReminderID, attempts, next, sendErr, now,
+	)
+}
+
+// backoff dobra a espera base a cada tentativa, limitada a max
+func backoff(base time.Duration, attempts int, max time.Duration) time.Duration {
+	wait := base
+	for i := 1; i < attempts && wait < max; i++ {
+		wait *= 2
+	}
+	if wait > max {
+		wait = max
+	}
+	return wait
+}
@@ -0,0 +1,103 @@
+// backend/scheduler/reminder_dispatcher_test.go
+package scheduler
+
+import (
+	"ProtocolManager/backend/models"
+	"ProtocolManager/backend/repository"
+	"ProtocolManager/backend/testdb"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// recordingNotifier registra os lembretes entregues; com fail, recusa todos
+type recordingNotifier struct {
+	fail      bool
+	delivered []int
+}
+
+func (n *recordingNotifier) NotifyReminder(
+	_ context.Context, reminder models.ProtocolReminder,
+) error {
+	if n.fail {
+		return errors.New("smtp indisponível")
+	}
+	n.delivered = append(n.delivered, reminder.ReminderID)
+	return nil
+}
+
+func TestRescheduledFailedReminderIsDispatched(t *testing.T) {
+	db := testdb.Open(t)
+	refs := testdb.SeedProtocolRefs(t, db)
+	numbers, err := repository.NewProtocolNumberGenerator(
+		repository.DefaultProtocolNumberFormat,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	protocol, err := repository.NewProtocolRepository(
+		db, numbers, repository.NewDeadlineCalculator(false), 24*time.Hour,
+	).Create(
+		repository.AllBranchesScope(),
+		repository.Actor{PersonnelID: refs.PersonnelID, Role: models.RoleAdmin},
+		models.Protocol{
+			Title:      "Lembrete",
+			TypeID:     refs.TypeID,
+			StatusID:   refs.StatusID,
+			CustomerID: refs.CustomerID,
+			AssignedTo: refs.PersonnelID,
+		},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reminder := models.ProtocolReminder{
+		ProtocolID:   protocol.ProtocolID,
+		ReminderText: "Retornar ao cliente",
+		ReminderDate: time.Now().Add(-time.Hour),
+		CreatedBy:    refs.PersonnelID,
+	}
+	testdb.Create(t, db, &reminder)
+
+	repo := repository.NewProtocolReminderRepository(db)
+	notifier := &recordingNotifier{fail: true}
+	dispatcher := NewReminderDispatcher(repo, notifier, time.Second, 1, time.Second)
+
+	// Uma única tentativa: o lembrete falha de vez
+	dispatcher.dispatch(context.Background())
+	stored := func() models.ProtocolReminder {
+		var r models.ProtocolReminder
+		if err := db.First(&r, reminder.ReminderID).Error; err != nil {
+			t.Fatal(err)
+		}
+		return r
+	}
+	if failed := stored(); failed.FailedAt == nil || failed.Attempts != 1 {
+		t.Fatalf("reminder did not fail: %+v", failed)
+	}
+
+	// Editar o texto mantém a falha; só uma nova data recomeça as entregas
+	failed := stored()
+	failed.ReminderText = "Retornar ao cliente hoje"
+	if err := repo.Update(repository.AllBranchesScope(), reminder.ReminderID, failed); err != nil {
+		t.Fatal(err)
+	}
+	notifier.fail = false
+	dispatcher.dispatch(context.Background())
+	if len(notifier.delivered) != 0 {
+		t.Fatalf("failed reminder dispatched without a new date")
+	}
+
+	failed.ReminderDate = time.Now().Add(-time.Minute)
+	if err := repo.Update(repository.AllBranchesScope(), reminder.ReminderID, failed); err != nil {
+		t.Fatal(err)
+	}
+	dispatcher.dispatch(context.Background())
+	if len(notifier.delivered) != 1 || notifier.delivered[0] != reminder.ReminderID {
+		t.Fatalf("delivered %v, want the rescheduled reminder", notifier.delivered)
+	}
+	if sent := stored(); !sent.IsSent || sent.FailedAt != nil {
+		t.Errorf("reminder after delivery: %+v", sent)
+	}
+}
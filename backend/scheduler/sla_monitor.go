@@ -0,0 +1,57 @@
+// backend/scheduler/sla_monitor.go
+package scheduler
+
+import (
+	"ProtocolManager/backend/repository"
+	"context"
+	"log"
+	"time"
+)
+
+// Quantidade máxima de protocolos marcados por execução
+const slaBatchSize = 100
+
+// SLAMonitor verifica periodicamente protocolos com prazo vencido e
+// registra a violação no histórico; o aviso aos envolvidos sai pelo outbox
+type SLAMonitor struct {
+	Repo     *repository.ProtocolRepository
+	Interval time.Duration
+}
+
+func NewSLAMonitor(
+	repo *repository.ProtocolRepository, interval time.Duration,
+) *SLAMonitor {
+	return &SLAMonitor{Repo: repo, Interval: interval}
+}
+
+// Run executa a verificação até o contexto ser cancelado
+func (m *SLAMonitor) Run(ctx context.Context) {
+	ticker := time.NewTicker(m.Interval)
+	defer ticker.Stop()
+
+	for {
+		m.check()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (m *SLAMonitor) check() {
+	for {
+		breached, err := m.Repo.RecordSLABreaches(time.Now(), slaBatchSize)
+		if err != nil {
+			log.Printf("SLA monitor: %v", err)
+			return
+		}
+		for _, p := range breached {
+			log.Printf("SLA monitor: protocolo %s violou o prazo", p.ProtocolNumber)
+		}
+		if len(breached) < slaBatchSize {
+			return
+		}
+	}
+}
@@ -0,0 +1,151 @@
+// backend/scheduler/thumbnail_generator.go
+package scheduler
+
+import (
+	"ProtocolManager/backend/models"
+	"ProtocolManager/backend/preview"
+	"ProtocolManager/backend/repository"
+	"ProtocolManager/backend/storage"
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+const (
+	thumbnailBatchSize = 10
+	thumbnailTimeout   = time.Minute
+	// A reserva cobre o lote inteiro renderizado em sequência, com folga
+	thumbnailLease = thumbnailBatchSize*thumbnailTimeout + time.Minute
+)
+
+// ThumbnailGenerator gera as miniaturas das versões de anexo depois que o
+// antivírus as libera, para nenhum arquivo não verificado chegar aos
+// decodificadores. A miniatura é gravada ao lado do original. As versões
+// são reservadas numa transação curta e cada uma é marcada ao fim da
+// própria renderização, sem transação aberta durante a leitura, o
+// pdftoppm e a gravação.
+type ThumbnailGenerator struct {
+	Repo     *repository.ProtocolAttachmentRepository
+	Files    storage.Storage
+	Renderer *preview.Renderer
+	Interval time.Duration
+}
+
+func NewThumbnailGenerator(
+	repo *repository.ProtocolAttachmentRepository,
+	files storage.Storage,
+	renderer *preview.Renderer,
+	interval time.Duration,
+) *ThumbnailGenerator {
+	return &ThumbnailGenerator{
+		Repo:     repo,
+		Files:    files,
+		Renderer: renderer,
+		Interval: interval,
+	}
+}
+
+// Run gera as miniaturas até o contexto ser cancelado
+func (g *ThumbnailGenerator) Run(ctx context.Context) {
+	ticker := time.NewTicker(g.Interval)
+	defer ticker.Stop()
+
+	for {
+		g.generatePending(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (g *ThumbnailGenerator) generatePending(ctx context.Context) {
+	for ctx.Err() == nil {
+		versions, err := g.Repo.ClaimPendingThumbnails(
+			time.Now(), thumbnailBatchSize, thumbnailLease,
+		)
+		if err != nil {
+			log.Printf("Thumbnail generator: %v", err)
+			return
+		}
+
+		failed := false
+		for _, version := range versions {
+			if ctx.Err() != nil {
+				// As restantes voltam a ficar disponíveis quando a reserva
+				// expirar
+				return
+			}
+			if err := g.generate(ctx, version); err != nil {
+				log.Printf(
+					"Attachment %d version %d: %v",
+					version.AttachmentID, version.Version, err,
+				)
+				failed = true
+			}
+		}
+		// Com o armazenamento falhando, as versões liberadas esperam o
+		// próximo ciclo em vez de voltarem no mesmo instante
+		if failed || len(versions) < thumbnailBatchSize {
+			return
+		}
+	}
+}
+
+// generate renderiza a miniatura fora de qualquer transação e marca a
+// versão ao final, numa atualização curta
+func (g *ThumbnailGenerator) generate(
+	ctx context.Context, version models.AttachmentVersion,
+) error {
+	if !g.Renderer.Supports(version.ContentType) {
+		return g.Repo.MarkThumbnail(version, models.ThumbnailUnavailable, "")
+	}
+
+	renderCtx, cancel := context.WithTimeout(ctx, thumbnailTimeout)
+	defer cancel()
+
+	thumbnail, err := g.render(renderCtx, version)
+	if err != nil {
+		if ctx.Err() != nil {
+			// Servidor encerrando: a versão continua pendente
+			return g.Repo.ReleaseThumbnail(version.VersionID)
+		}
+		// Arquivos que não renderizam não melhoram numa nova tentativa
+		log.Printf(
+			"Attachment %d version %d: thumbnail failed: %v",
+			version.AttachmentID, version.Version, err,
+		)
+		return g.Repo.MarkThumbnail(version, models.ThumbnailUnavailable, "")
+	}
+
+	// Falha ao gravar é do armazenamento, não do arquivo: a versão continua
+	// pendente e volta no próximo ciclo
+	key := storage.ThumbnailKey(version.FilePath)
+	if err := g.Files.Put(
+		renderCtx, key, bytes.NewReader(thumbnail), int64(len(thumbnail)),
+		preview.ThumbnailContentType,
+	); err != nil {
+		if releaseErr := g.Repo.ReleaseThumbnail(version.VersionID); releaseErr != nil {
+			log.Printf("Thumbnail generator: %v", releaseErr)
+		}
+		return fmt.Errorf("store thumbnail %s: %w", key, err)
+	}
+	return g.Repo.MarkThumbnail(version, models.ThumbnailReady, key)
+}
+
+// render lê o original do armazenamento e devolve a miniatura
+func (g *ThumbnailGenerator) render(
+	ctx context.Context, version models.AttachmentVersion,
+) ([]byte, error) {
+	file, _, err := g.Files.Get(ctx, version.FilePath)
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", version.FilePath, err)
+	}
+	defer file.Close()
+
+	return g.Renderer.Thumbnail(ctx, file, version.ContentType)
+}
@@ -0,0 +1,87 @@
+// backend/scheduler/thumbnail_generator_test.go
+package scheduler
+
+import (
+	"ProtocolManager/backend/models"
+	"ProtocolManager/backend/preview"
+	"ProtocolManager/backend/repository"
+	"ProtocolManager/backend/storage"
+	"ProtocolManager/backend/testdb"
+	"bytes"
+	"context"
+	"image"
+	"image/png"
+	"io"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// lockCheckingStorage verifica, ao ler o original, que a versão não está
+// travada por uma transação
+type lockCheckingStorage struct {
+	storage.Storage
+	db      *gorm.DB
+	lockErr error
+}
+
+func (s *lockCheckingStorage) Get(ctx context.Context, key string) (
+	io.ReadCloser, storage.ObjectInfo, error,
+) {
+	s.lockErr = s.db.Exec(
+		"SELECT 1 FROM protocol_attachment_versions FOR UPDATE NOWAIT",
+	).Error
+	return s.Storage.Get(ctx, key)
+}
+
+func TestThumbnailGeneratorRendersOutsideTheTransaction(t *testing.T) {
+	db := testdb.Open(t)
+	local, err := storage.NewLocal(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var content bytes.Buffer
+	if err := png.Encode(&content, image.NewRGBA(image.Rect(0, 0, 40, 30))); err != nil {
+		t.Fatal(err)
+	}
+	attachment := newPendingAttachment(t, db, local, content.String())
+	if err := db.Model(&models.AttachmentVersion{}).
+		Where("attachment_id = ?", attachment.AttachmentID).
+		Updates(map[string]interface{}{
+			"content_type": "image/png",
+			"scan_status":  models.ScanClean,
+		}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	files := &lockCheckingStorage{Storage: local, db: db}
+	NewThumbnailGenerator(
+		repository.NewProtocolAttachmentRepository(db), files,
+		preview.NewRenderer(""), time.Second,
+	).generatePending(context.Background())
+
+	if files.lockErr != nil {
+		t.Fatalf("version row locked while rendering: %v", files.lockErr)
+	}
+	var version models.AttachmentVersion
+	if err := db.Where(
+		"attachment_id = ?", attachment.AttachmentID,
+	).First(&version).Error; err != nil {
+		t.Fatal(err)
+	}
+	if version.ThumbnailStatus != models.ThumbnailReady || version.ThumbnailLeaseUntil != nil {
+		t.Fatalf("thumbnail %q lease %v, want ready without lease",
+			version.ThumbnailStatus, version.ThumbnailLeaseUntil)
+	}
+
+	var events int64
+	if err := db.Model(&models.OutboxEvent{}).
+		Where("event_type = ?", models.EventAttachmentThumbnailReady).
+		Count(&events).Error; err != nil {
+		t.Fatal(err)
+	}
+	if events != 1 {
+		t.Errorf("got %d thumbnail_ready events, want 1", events)
+	}
+}
@@ -0,0 +1,123 @@
+// backend/scheduler/webhook_dispatcher.go
+package scheduler
+
+import (
+	"ProtocolManager/backend/models"
+	"ProtocolManager/backend/repository"
+	"ProtocolManager/backend/webhook"
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+const (
+	webhookBatchSize  = 20
+	maxWebhookBackoff = 6 * time.Hour
+	// Folga da reserva além do tempo máximo das requisições do lote
+	webhookLeaseMargin = time.Minute
+)
+
+// WebhookDispatcher envia as entregas pendentes de webhooks. As entregas
+// são reservadas numa transação curta e as requisições HTTP acontecem fora
+// dela, cada entrega marcada ao fim da própria tentativa. Falhas são
+// tentadas novamente com espera exponencial; depois de MaxAttempts a
+// entrega fica no estado dead até ser reenfileirada pela API.
+type WebhookDispatcher struct {
+	Repo        *repository.WebhookRepository
+	Client      *webhook.Client
+	Interval    time.Duration
+	MaxAttempts int
+	Backoff     time.Duration
+}
+
+func NewWebhookDispatcher(
+	repo *repository.WebhookRepository,
+	client *webhook.Client,
+	interval time.Duration,
+	maxAttempts int,
+	backoff time.Duration,
+) *WebhookDispatcher {
+	return &WebhookDispatcher{
+		Repo:        repo,
+		Client:      client,
+		Interval:    interval,
+		MaxAttempts: maxAttempts,
+		Backoff:     backoff,
+	}
+}
+
+// Run executa o despacho até o contexto ser cancelado
+func (d *WebhookDispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.Interval)
+	defer ticker.Stop()
+
+	for {
+		d.dispatch(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (d *WebhookDispatcher) dispatch(ctx context.Context) {
+	// A reserva cobre o lote inteiro enviado em sequência
+	lease := webhookBatchSize*d.Client.HTTP.Timeout + webhookLeaseMargin
+
+	for ctx.Err() == nil {
+		deliveries, err := d.Repo.ClaimDueDeliveries(
+			time.Now(), webhookBatchSize, lease,
+		)
+		if err != nil {
+			log.Printf("Webhook dispatcher: %v", err)
+			return
+		}
+		for _, delivery := range deliveries {
+			if ctx.Err() != nil {
+				// As restantes voltam para a fila quando a reserva expirar
+				return
+			}
+			if err := d.deliver(ctx, delivery); err != nil {
+				log.Printf("Webhook delivery %d: %v", delivery.DeliveryID, err)
+			}
+		}
+		if len(deliveries) < webhookBatchSize {
+			return
+		}
+	}
+}
+
+func (d *WebhookDispatcher) deliver(
+	ctx context.Context, delivery models.WebhookDelivery,
+) error {
+	attempts := delivery.Attempts + 1
+
+	var status int
+	sendErr := fmt.Errorf("assinatura inativa ou removida")
+	if delivery.Subscription.Active {
+		status, sendErr = d.Client.Deliver(ctx, delivery)
+	}
+	if sendErr == nil {
+		return d.Repo.MarkDelivered(
+			delivery.DeliveryID, attempts, status, time.Now(),
+		)
+	}
+
+	var next *time.Time
+	if attempts < d.MaxAttempts && delivery.Subscription.Active {
+		at := time.Now().Add(backoff(d.Backoff, attempts, maxWebhookBackoff))
+		next = &at
+	} else {
+		log.Printf(
+			"Webhook delivery %d: giving up after %d attempts: %v",
+			delivery.DeliveryID, attempts, sendErr,
+		)
+	}
+
+	return d.Repo.MarkAttemptFailed(
+		delivery.DeliveryID, attempts, status, next, sendErr,
+	)
+}
@@ -0,0 +1,60 @@
+// backend/scheduler/webhook_dispatcher_test.go
+package scheduler
+
+import (
+	"ProtocolManager/backend/models"
+	"ProtocolManager/backend/repository"
+	"ProtocolManager/backend/testdb"
+	"ProtocolManager/backend/webhook"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestWebhookDispatcherSendsOutsideTheTransaction(t *testing.T) {
+	db := testdb.Open(t)
+
+	var delivery models.WebhookDelivery
+	var lockErr error
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Durante a requisição a linha da entrega não pode estar travada
+		lockErr = db.Exec(
+			"SELECT 1 FROM webhook_deliveries WHERE delivery_id = ? FOR UPDATE NOWAIT",
+			delivery.DeliveryID,
+		).Error
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	subscription := models.WebhookSubscription{URL: server.URL, Secret: "segredo", Active: true}
+	testdb.Create(t, db, &subscription)
+	delivery = models.WebhookDelivery{
+		SubscriptionID: subscription.SubscriptionID,
+		EventID:        "event-1",
+		Event:          models.EventProtocolCreated,
+		Payload:        "{}",
+		Status:         models.DeliveryPending,
+	}
+	if err := db.Omit("Subscription").Create(&delivery).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	dispatcher := NewWebhookDispatcher(
+		repository.NewWebhookRepository(db), webhook.NewClient(5*time.Second),
+		time.Second, 3, time.Second,
+	)
+	dispatcher.dispatch(context.Background())
+
+	if lockErr != nil {
+		t.Fatalf("delivery row locked during the request: %v", lockErr)
+	}
+	var stored models.WebhookDelivery
+	if err := db.First(&stored, delivery.DeliveryID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if stored.Status != models.DeliveryDelivered || stored.LeaseUntil != nil {
+		t.Errorf("status %q lease %v, want delivered without lease", stored.Status, stored.LeaseUntil)
+	}
+}
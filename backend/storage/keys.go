@@ -0,0 +1,92 @@
+// backend/storage/keys.go
+package storage
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+const (
+	maxFileNameLength  = 255
+	maxExtensionLength = 10
+	// Nome usado quando o enviado não tem nenhum caractere aproveitável
+	defaultFileName = "arquivo"
+)
+
+// NewAttachmentKey gera a chave de um anexo novo. A chave não usa o nome
+// enviado pelo cliente, só a extensão saneada, então dois uploads nunca
+// colidem e nenhum nome consegue sair do diretório do protocolo.
+func NewAttachmentKey(protocolID int, fileName string) string {
+	return fmt.Sprintf(
+		"protocols/%d/%s%s", protocolID, uuid.NewString(), safeExtension(fileName),
+	)
+}
+
+// ThumbnailKey é a chave da miniatura, gravada ao lado do arquivo original
+func ThumbnailKey(key string) string {
+	return key + ".thumb.jpg"
+}
+
+// CleanKey valida uma chave: relativa, separada por "/", sem segmentos
+// vazios, "." ou "..", barras invertidas ou caracteres de controle
+func CleanKey(key string) (string, error) {
+	if key == "" || path.IsAbs(key) || strings.Contains(key, "\\") {
+		return "", ErrInvalidKey
+	}
+	for _, r := range key {
+		if unicode.IsControl(r) {
+			return "", ErrInvalidKey
+		}
+	}
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return "", ErrInvalidKey
+		}
+	}
+	return key, nil
+}
+
+// SafeFileName reduz o nome enviado pelo cliente ao nome do arquivo, sem
+// diretórios nem caracteres de controle. É o nome exibido e usado no
+// download; acentos são mantidos.
+func SafeFileName(name string) string {
+	name = strings.ToValidUTF8(name, "")
+	// Navegadores antigos no Windows enviam o caminho completo
+	name = path.Base(strings.ReplaceAll(name, "\\", "/"))
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, name)
+	name = strings.TrimSpace(name)
+
+	if name == "" || name == "." || name == ".." || name == "/" {
+		return defaultFileName
+	}
+	for len(name) > maxFileNameLength {
+		_, size := utf8.DecodeLastRuneInString(name)
+		name = name[:len(name)-size]
+	}
+	return name
+}
+
+// safeExtension devolve a extensão em minúsculas quando ela é curta e só
+// tem letras e números; caso contrário a chave fica sem extensão
+func safeExtension(fileName string) string {
+	ext := strings.ToLower(path.Ext(SafeFileName(fileName)))
+	if len(ext) < 2 || len(ext) > maxExtensionLength {
+		return ""
+	}
+	for _, r := range ext[1:] {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') {
+			return ""
+		}
+	}
+	return ext
+}
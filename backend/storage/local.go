@@ -0,0 +1,135 @@
+// backend/storage/local.go
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Prefixo das chaves gravadas antes do armazenamento configurável, quando
+// file_path guardava o caminho a partir do diretório de trabalho
+const legacyKeyPrefix = "./uploads/"
+
+// Local grava os arquivos em um diretório do servidor
+type Local struct {
+	Root string
+	// Diretório dos anexos antigos ("./uploads/..."); chaves legadas não
+	// podem sair dele
+	LegacyRoot string
+}
+
+func NewLocal(root string) (*Local, error) {
+	if root == "" {
+		return nil, errors.New("diretório de armazenamento não informado")
+	}
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return nil, err
+	}
+	return &Local{Root: root, LegacyRoot: "./uploads"}, nil
+}
+
+// path converte a chave em caminho no disco, sempre dentro da raiz. O
+// file_path vem do banco, então uma chave que escaparia da raiz é recusada
+// em vez de servida.
+func (l *Local) path(key string) (string, error) {
+	root := l.Root
+	if rest, ok := strings.CutPrefix(key, legacyKeyPrefix); ok {
+		root, key = l.LegacyRoot, rest
+	}
+
+	key, err := CleanKey(key)
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(root, filepath.FromSlash(key))
+
+	// Confere o caminho final também, contra separadores específicos do
+	// sistema que CleanKey não conhece
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == "." || !filepath.IsLocal(rel) {
+		return "", ErrInvalidKey
+	}
+	return path, nil
+}
+
+func (l *Local) Put(
+	_ context.Context, key string, r io.Reader, _ int64, _ string,
+) error {
+	path, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	// Grava em arquivo temporário e renomeia, para que um upload
+	// interrompido não deixe um arquivo pela metade na chave final
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
+
+func (l *Local) Get(
+	_ context.Context, key string,
+) (io.ReadCloser, ObjectInfo, error) {
+	path, err := l.path(key)
+	if err != nil {
+		return nil, ObjectInfo{}, err
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ObjectInfo{}, ErrNotFound
+	}
+	if err != nil {
+		return nil, ObjectInfo{}, err
+	}
+
+	stat, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, ObjectInfo{}, err
+	}
+	if stat.IsDir() {
+		f.Close()
+		return nil, ObjectInfo{}, ErrNotFound
+	}
+
+	return f, ObjectInfo{
+		Size:        stat.Size(),
+		ContentType: mime.TypeByExtension(filepath.Ext(key)),
+		ModTime:     stat.ModTime(),
+	}, nil
+}
+
+func (l *Local) Delete(_ context.Context, key string) error {
+	path, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+// backend/storage/s3.go
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// S3Config configura um armazenamento compatível com S3 (AWS, MinIO, ...)
+type S3Config struct {
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	UseSSL    bool
+	// Endereçamento por caminho (endpoint/bucket/chave), exigido pelo MinIO
+	// e pela maioria dos serviços compatíveis
+	PathStyle bool
+	// Cria o bucket na inicialização se ele não existir
+	CreateBucket bool
+}
+
+// Tamanho das partes do upload multipart. Sem ele, um Put de tamanho
+// desconhecido reserva um buffer de cerca de 512 MiB (5 TiB / 10.000
+// partes) por upload. Com 16 MiB o objeto pode ter até ~156 GiB.
+const s3PartSize = 16 << 20
+
+// S3 grava os arquivos em um bucket compatível com S3
+type S3 struct {
+	Client *minio.Client
+	Bucket string
+}
+
+func NewS3(ctx context.Context, cfg S3Config) (*S3, error) {
+	if cfg.Endpoint == "" || cfg.Bucket == "" {
+		return nil, errors.New("endpoint e bucket do S3 são obrigatórios")
+	}
+
+	lookup := minio.BucketLookupAuto
+	if cfg.PathStyle {
+		lookup = minio.BucketLookupPath
+	}
+	client, err := minio.New(cfg.Endpoint, &minio.Options{
+		Creds:        credentials.NewStaticV4(cfg.AccessKey, cfg.SecretKey, ""),
+		Secure:       cfg.UseSSL,
+		Region:       cfg.Region,
+		BucketLookup: lookup,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	exists, err := client.BucketExists(ctx, cfg.Bucket)
+	if err != nil {
+		return nil, fmt.Errorf("bucket %s: %w", cfg.Bucket, err)
+	}
+	if !exists {
+		if !cfg.CreateBucket {
+			return nil, fmt.Errorf("bucket %s não existe", cfg.Bucket)
+		}
+		if err := client.MakeBucket(ctx, cfg.Bucket, minio.MakeBucketOptions{
+			Region: cfg.Region,
+		}); err != nil {
+			return nil, fmt.Errorf("bucket %s: %w", cfg.Bucket, err)
+		}
+	}
+
+	return &S3{Client: client, Bucket: cfg.Bucket}, nil
+}
+
+func (s *S3) Put(
+	ctx context.Context, key string, r io.Reader, size int64,
+	contentType string,
+) error {
+	key, err := CleanKey(key)
+	if err != nil {
+		return err
+	}
+	_, err = s.Client.PutObject(ctx, s.Bucket, key, r, size, minio.PutObjectOptions{
+		ContentType: contentType,
+		PartSize:    s3PartSize,
+	})
+	return err
+}
+
+func (s *S3) Get(
+	ctx context.Context, key string,
+) (io.ReadCloser, ObjectInfo, error) {
+	key, err := CleanKey(key)
+	if err != nil {
+		return nil, ObjectInfo{}, err
+	}
+	obj, err := s.Client.GetObject(ctx, s.Bucket, key, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, ObjectInfo{}, translateS3Error(err)
+	}
+
+	// GetObject é preguiçoso: o Stat faz a requisição e revela se a chave
+	// existe
+	stat, err := obj.Stat()
+	if err != nil {
+		obj.Close()
+		return nil, ObjectInfo{}, translateS3Error(err)
+	}
+
+	return obj, ObjectInfo{
+		Size:        stat.Size,
+		ContentType: stat.ContentType,
+		ModTime:     stat.LastModified,
+	}, nil
+}
+
+func (s *S3) Delete(ctx context.Context, key string) error {
+	key, err := CleanKey(key)
+	if err != nil {
+		return err
+	}
+	// RemoveObject não falha para chaves inexistentes
+	return translateS3Error(
+		s.Client.RemoveObject(ctx, s.Bucket, key, minio.RemoveObjectOptions{}),
+	)
+}
+
+func translateS3Error(err error) error {
+	if err == nil {
+		return nil
+	}
+	resp := minio.ToErrorResponse(err)
+	if resp.Code == "NoSuchKey" || resp.StatusCode == http.StatusNotFound {
+		return ErrNotFound
+	}
+	return err
+}
@@ -0,0 +1,84 @@
+// backend/storage/s3_test.go
+package storage
+
+import (
+	"ProtocolManager/backend/storage/s3test"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+)
+
+func newTestS3(t *testing.T, server *s3test.Server, createBucket bool) (*S3, error) {
+	t.Helper()
+	return NewS3(context.Background(), S3Config{
+		Endpoint:     server.Endpoint(),
+		Region:       "us-east-1",
+		Bucket:       "attachments",
+		AccessKey:    "test",
+		SecretKey:    "test-secret",
+		PathStyle:    true,
+		CreateBucket: createBucket,
+	})
+}
+
+func TestS3PutUnknownSizeUsesBoundedParts(t *testing.T) {
+	server := s3test.NewServer()
+	defer server.Close()
+
+	s, err := newTestS3(t, server, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Tamanho desconhecido, como no upload de anexos (TeeReader com -1)
+	content := bytes.Repeat([]byte("0123456789abcdef"), (20<<20)/16)
+	ctx := context.Background()
+	err = s.Put(ctx, "protocols/1/file.bin", io.MultiReader(bytes.NewReader(content)), -1, "application/pdf")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parts := server.PartSizes()
+	if len(parts) != 2 {
+		t.Fatalf("got %d parts %v, want 2", len(parts), parts)
+	}
+	for _, size := range parts {
+		if size > s3PartSize {
+			t.Errorf("part of %d bytes exceeds %d", size, s3PartSize)
+		}
+	}
+
+	r, info, err := s.Get(ctx, "protocols/1/file.bin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := io.ReadAll(r)
+	r.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, content) {
+		t.Errorf("read %d bytes back, want the %d written", len(got), len(content))
+	}
+	if info.ContentType != "application/pdf" || info.Size != int64(len(content)) {
+		t.Errorf("info = %+v", info)
+	}
+
+	if err := s.Delete(ctx, "protocols/1/file.bin"); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := s.Get(ctx, "protocols/1/file.bin"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get after Delete: %v, want ErrNotFound", err)
+	}
+}
+
+func TestS3MissingBucket(t *testing.T) {
+	server := s3test.NewServer()
+	defer server.Close()
+
+	if _, err := newTestS3(t, server, false); err == nil {
+		t.Error("expected an error for a missing bucket")
+	}
+}
@@ -0,0 +1,63 @@
+// backend/storage/sniff.go
+package storage
+
+import (
+	"bytes"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// SniffLength é quanto do início do arquivo DetectContentType examina
+const SniffLength = 512
+
+// Assinatura dos documentos OLE (doc, xls, ppt e msg do Office antigo),
+// que http.DetectContentType não reconhece
+var oleMagic = []byte{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1}
+
+const oleContentType = "application/x-ole-storage"
+
+// Formatos que são contêineres genéricos pelo conteúdo e só se distinguem
+// pela extensão
+var containerRefinements = map[string]map[string]string{
+	"application/zip": {
+		".docx": "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+		".xlsx": "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+		".pptx": "application/vnd.openxmlformats-officedocument.presentationml.presentation",
+		".odt":  "application/vnd.oasis.opendocument.text",
+		".ods":  "application/vnd.oasis.opendocument.spreadsheet",
+		".odp":  "application/vnd.oasis.opendocument.presentation",
+	},
+	oleContentType: {
+		".doc": "application/msword",
+		".xls": "application/vnd.ms-excel",
+		".ppt": "application/vnd.ms-powerpoint",
+		".msg": "application/vnd.ms-outlook",
+	},
+	"text/plain": {
+		".csv":  "text/csv",
+		".json": "application/json",
+		".md":   "text/markdown",
+	},
+}
+
+// DetectContentType identifica o tipo pelos primeiros bytes do arquivo. O
+// Content-Type enviado pelo cliente é ignorado; o nome só refina os
+// contêineres genéricos (um .docx é um zip, um .csv é texto), nunca troca
+// um tipo reconhecido por outro.
+func DetectContentType(head []byte, fileName string) string {
+	detected := http.DetectContentType(head)
+	if bytes.HasPrefix(head, oleMagic) {
+		detected = oleContentType
+	}
+	if mediaType, _, err := mime.ParseMediaType(detected); err == nil {
+		detected = mediaType
+	}
+
+	ext := strings.ToLower(path.Ext(fileName))
+	if refined, ok := containerRefinements[detected][ext]; ok {
+		return refined
+	}
+	return detected
+}
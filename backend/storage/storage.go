@@ -0,0 +1,62 @@
+// backend/storage/storage.go
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+var (
+	// ErrNotFound indica que o objeto não existe no armazenamento
+	ErrNotFound = errors.New("arquivo não encontrado no armazenamento")
+	// ErrInvalidKey indica uma chave que sairia da raiz do armazenamento
+	ErrInvalidKey = errors.New("chave de armazenamento inválida")
+)
+
+// ObjectInfo descreve um objeto armazenado
+type ObjectInfo struct {
+	Size        int64
+	ContentType string
+	ModTime     time.Time
+}
+
+// Storage guarda o conteúdo dos anexos. As chaves usam "/" como separador
+// e são gravadas em protocol_attachments.file_path.
+type Storage interface {
+	Put(
+		ctx context.Context, key string, r io.Reader, size int64,
+		contentType string,
+	) error
+	// Get devolve ErrNotFound quando a chave não existe
+	Get(ctx context.Context, key string) (io.ReadCloser, ObjectInfo, error)
+	// Delete não falha quando a chave não existe
+	Delete(ctx context.Context, key string) error
+}
+
+// Drivers disponíveis
+const (
+	DriverLocal = "local"
+	DriverS3    = "s3"
+)
+
+// Config seleciona e configura o driver
+type Config struct {
+	Driver    string
+	LocalPath string
+	S3        S3Config
+}
+
+// New cria o armazenamento do driver configurado
+func New(ctx context.Context, cfg Config) (Storage, error) {
+	switch cfg.Driver {
+	case DriverLocal, "":
+		return NewLocal(cfg.LocalPath)
+	case DriverS3:
+		return NewS3(ctx, cfg.S3)
+	default:
+		return nil, fmt.Errorf("driver de armazenamento desconhecido: %s", cfg.Driver)
+	}
+}
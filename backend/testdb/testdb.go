@@ -0,0 +1,142 @@
+// backend/testdb/testdb.go
+package testdb
+
+import (
+	"ProtocolManager/backend/migrations"
+	"ProtocolManager/backend/models"
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	_ "github.com/lib/pq"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// Variável com a conexão de um PostgreSQL descartável para os testes de
+// integração. Sem ela esses testes são pulados.
+const dsnVariable = "TEST_DATABASE_DSN"
+
+var schemaCounter atomic.Int64
+
+// Open cria um schema novo no banco de testes, aplica as migrações e
+// devolve uma conexão GORM limitada a ele. O schema é apagado ao fim do
+// teste, então cada teste parte de um banco vazio.
+func Open(t testing.TB) *gorm.DB {
+	t.Helper()
+
+	dsn := os.Getenv(dsnVariable)
+	if dsn == "" {
+		t.Skipf("%s not set: skipping database test", dsnVariable)
+	}
+
+	admin, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatalf("open test database: %v", err)
+	}
+	t.Cleanup(func() { admin.Close() })
+
+	schema := fmt.Sprintf(
+		"test_%d_%d_%d",
+		os.Getpid(), time.Now().UnixNano(), schemaCounter.Add(1),
+	)
+	if _, err := admin.Exec("CREATE SCHEMA " + schema); err != nil {
+		t.Fatalf("create schema: %v", err)
+	}
+	t.Cleanup(func() {
+		if _, err := admin.Exec("DROP SCHEMA " + schema + " CASCADE"); err != nil {
+			t.Logf("drop schema %s: %v", schema, err)
+		}
+	})
+
+	scoped := withSearchPath(dsn, schema)
+	sqlDB, err := sql.Open("postgres", scoped)
+	if err != nil {
+		t.Fatalf("open test schema: %v", err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+
+	migrator, err := migrations.NewMigrator(sqlDB)
+	if err != nil {
+		t.Fatalf("load migrations: %v", err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatalf("apply migrations: %v", err)
+	}
+
+	db, err := gorm.Open(postgres.Open(scoped), &gorm.Config{
+		TranslateError: true,
+		Logger:         logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("open gorm: %v", err)
+	}
+	if pool, err := db.DB(); err == nil {
+		t.Cleanup(func() { pool.Close() })
+	}
+	return db
+}
+
+// Create grava value e encerra o teste em caso de erro
+func Create(t testing.TB, db *gorm.DB, value any) {
+	t.Helper()
+	if err := db.Create(value).Error; err != nil {
+		t.Fatalf("create %T: %v", value, err)
+	}
+}
+
+// withSearchPath acrescenta o search_path ao DSN, no formato URL ou
+// chave=valor
+func withSearchPath(dsn, schema string) string {
+	if strings.HasPrefix(dsn, "postgres://") ||
+		strings.HasPrefix(dsn, "postgresql://") {
+		u, err := url.Parse(dsn)
+		if err == nil {
+			query := u.Query()
+			query.Set("search_path", schema)
+			u.RawQuery = query.Encode()
+			return u.String()
+		}
+	}
+	return dsn + " search_path=" + schema
+}
+
+// Refs são os registros mínimos que um protocolo referencia
+type Refs struct {
+	TypeID      int
+	StatusID    int
+	PersonnelID int
+	CustomerID  int
+}
+
+// SeedProtocolRefs cria um tipo, um status, um colaborador e um cliente
+func SeedProtocolRefs(t testing.TB, db *gorm.DB) Refs {
+	t.Helper()
+
+	protocolType := models.ProtocolType{TypeName: "Standard"}
+	Create(t, db, &protocolType)
+	status := models.ProtocolStatus{StatusName: "Novo"}
+	Create(t, db, &status)
+	personnel := models.SalesPersonnel{
+		FirstName: "Ana", LastName: "Souza", Email: "ana@example.com",
+	}
+	Create(t, db, &personnel)
+	customer := models.Customer{
+		FirstName: "Carlos", LastName: "Lima", Email: "carlos@example.com",
+	}
+	Create(t, db, &customer)
+
+	return Refs{
+		TypeID:      protocolType.TypeID,
+		StatusID:    status.StatusID,
+		PersonnelID: personnel.PersonnelID,
+		CustomerID:  customer.CustomerID,
+	}
+}
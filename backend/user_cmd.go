@@ -0,0 +1,39 @@
+// backend/user_cmd.go
+package main
+
+import (
+	"ProtocolManager/backend/models"
+	"ProtocolManager/backend/repository"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+const userUsage = "usage: user promote <email>"
+
+// runUser executa o subcomando "user". "user promote <email>" torna o
+// usuário um administrador ativo; é o caminho para criar o primeiro admin,
+// já que o cadastro sempre atribui read_only e só um admin altera papéis.
+func runUser(repo *repository.UserRepository, args []string) error {
+	if len(args) != 2 || args[0] != "promote" {
+		return errors.New(userUsage)
+	}
+
+	user, err := repo.GetByEmail(args[1])
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("no user registered with e-mail %q", args[1])
+	}
+	if err != nil {
+		return err
+	}
+
+	role, active := models.RoleAdmin, true
+	if err := repo.Update(user.UserID, repository.UserChanges{
+		Role: &role, Active: &active,
+	}); err != nil {
+		return err
+	}
+	fmt.Printf("User %d (%s) is now an active admin\n", user.UserID, user.Email)
+	return nil
+}
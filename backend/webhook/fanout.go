@@ -0,0 +1,27 @@
+// backend/webhook/fanout.go
+package webhook
+
+import (
+	"ProtocolManager/backend/models"
+	"ProtocolManager/backend/repository"
+	"context"
+)
+
+// Fanout consome o outbox e cria uma entrega para cada assinatura
+// interessada no evento. Repetições do mesmo evento são ignoradas pelo
+// índice único (subscription_id, event_id).
+type Fanout struct {
+	Repo *repository.WebhookRepository
+}
+
+func NewFanout(repo *repository.WebhookRepository) *Fanout {
+	return &Fanout{Repo: repo}
+}
+
+func (f *Fanout) Name() string {
+	return "webhooks"
+}
+
+func (f *Fanout) Handle(_ context.Context, event models.OutboxEvent) error {
+	return f.Repo.EnqueueEvent(event)
+}
@@ -8,6 +8,16 @@ import Protocols from './pages/Protocols.tsx';
 import ProtocolDetail from './pages/ProtocolDetail.tsx';
 import Login from './pages/Login.tsx';
 import Navbar from './components/Navbar.tsx';
+import axios from 'axios';
+
+// Envia o token salvo no login em todas as chamadas à API
+axios.interceptors.request.use((config) => {
+    const token = localStorage.getItem('token');
+    if (token) {
+        config.headers.Authorization = `Bearer ${token}`;
+    }
+    return config;
+});
 
 function AppLayout() {
     const location = useLocation();
@@ -0,0 +1,35 @@
+// src/components/AuthImage.tsx
+import React, { useEffect, useState } from 'react';
+import { fetchFileBlob } from '../services/attachmentFiles';
+
+// <img> que busca a imagem pela API com o token; o src comum não envia o
+// cabeçalho Authorization e receberia 401
+interface AuthImageProps extends Omit<React.ImgHTMLAttributes<HTMLImageElement>, 'src'> {
+    // Caminho da API ("/api/..."), como thumbnail_url
+    path: string;
+}
+
+const AuthImage: React.FC<AuthImageProps> = ({ path, alt, ...props }) => {
+    const [url, setUrl] = useState<string>();
+
+    useEffect(() => {
+        let objectUrl: string | undefined;
+        let cancelled = false;
+        fetchFileBlob(path)
+            .then(({ blob }) => {
+                if (cancelled) return;
+                objectUrl = URL.createObjectURL(blob);
+                setUrl(objectUrl);
+            })
+            .catch(error => console.error('Error loading image:', error));
+        return () => {
+            cancelled = true;
+            if (objectUrl) URL.revokeObjectURL(objectUrl);
+        };
+    }, [path]);
+
+    if (!url) return null;
+    return <img src={url} alt={alt} {...props} />;
+};
+
+export default AuthImage;
@@ -21,18 +21,14 @@ const PersonnelPage: React.FC = () => {
         const fetchData = async () => {
             try {
                 setLoading(true);
+                // axios leva o token do interceptor em App.js
                 const [personnelResponse, branchesResponse] = await Promise.all([
-                    fetch(`${API_BASE}/api/personnel`),  // Use full URL with port
-                    fetch(`${API_BASE}/api/branches`)    // Use full URL with port
+                    axios.get(`${API_BASE}/api/personnel`),
+                    axios.get(`${API_BASE}/api/branches`)
                 ]);
 
-                // Check for success before parsing JSON
-                if (!personnelResponse.ok || !branchesResponse.ok) {
-                    throw new Error('One or more API requests failed');
-                }
-
-                const personnelData = await personnelResponse.json();
-                const branchesData = await branchesResponse.json();
+                const personnelData = personnelResponse.data;
+                const branchesData = branchesResponse.data;
 
                 setPersonnel(personnelData);
                 setBranches(branchesData);
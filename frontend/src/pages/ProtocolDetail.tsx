@@ -2,6 +2,7 @@
 import React, { useState, useEffect } from 'react';
 import { useParams, Link } from 'react-router-dom';
 import { Protocol, Customer, Personnel, Branch, ProtocolType, ProtocolStatus } from '../types/types';
+import { subscribeProtocolEvents } from '../services/protocolEvents';
 
 // Additional interfaces for the detail view
 interface Comment {
@@ -60,6 +61,7 @@ const ProtocolDetail: React.FC = () => {
     const [statuses, setStatuses] = useState<ProtocolStatus[]>([]);
     const [loading, setLoading] = useState(true);
     const [personnel, setPersonnel] = useState<Personnel[]>([]);
+    const [remoteNotice, setRemoteNotice] = useState<string | null>(null);
     const API_BASE = process.env.REACT_APP_API_BASE_URL;
     useEffect(() => {
         // In a real app, fetch actual data from your API
@@ -222,6 +224,47 @@ const ProtocolDetail: React.FC = () => {
         }, 500);
     }, [id]);
 
+    // Alterações feitas por colegas chegam pelo stream de eventos
+    useEffect(() => {
+        if (!id) return;
+        return subscribeProtocolEvents({ protocol_id: Number(id) }, (event) => {
+            if (event.type === 'attachment.added') {
+                setRemoteNotice(`Novo anexo: ${event.data.file_name}`);
+                return;
+            }
+            if (event.type === 'attachment.version_added') {
+                setRemoteNotice(`Nova versão de anexo: ${event.data.file_name} (v${event.data.version})`);
+                return;
+            }
+            if (event.type === 'attachment.thumbnail_ready') {
+                return;
+            }
+            if (event.type === 'attachment.scanned') {
+                if (event.data.scan_status === 'infected') {
+                    setRemoteNotice(`Anexo bloqueado pelo antivírus: ${event.data.file_name}`);
+                }
+                return;
+            }
+            const data = event.data;
+            setProtocol(prev => prev && {
+                ...prev,
+                title: data.title,
+                status_id: data.status_id,
+                assigned_to: data.assigned_to,
+                priority: data.priority,
+                deadline: data.deadline,
+            });
+            if (event.type === 'protocol.status_changed') {
+                setCurrentStatus(current =>
+                    statuses.find(s => s.status_id === data.status_id) || current
+                );
+            }
+            setRemoteNotice('Este protocolo foi atualizado por outro usuário.');
+        }, () => {
+            setRemoteNotice('A conexão ficou fora por muito tempo: recarregue a página para ver as alterações.');
+        });
+    }, [id, statuses]);
+
     const handleCommentSubmit = (e: React.FormEvent) => {
         e.preventDefault();
         if (!newComment.trim()) return;
@@ -410,6 +453,12 @@ const ProtocolDetail: React.FC = () => {
                 </div>
             </div>
 
+            {remoteNotice && (
+                <div className="remote-update-notice" onClick={() => setRemoteNotice(null)}>
+                    {remoteNotice}
+                </div>
+            )}
+
             <div className="protocol-content">
                 <div className="main-details">
                     <div className="detail-card">
@@ -1,5 +1,5 @@
 // src/pages/Protocols.tsx
-import React, { useState, useEffect } from 'react';
+import React, { useState, useEffect, useRef } from 'react';
 import {
     Table, Button, Modal, Form, Input, DatePicker,
     Tabs, Upload, message, List, Tag, Card, ConfigProvider, Popconfirm
@@ -11,8 +11,11 @@ import {
 } from '@ant-design/icons';
 import axios from 'axios';
 import moment from 'moment';
-import { Protocol, Customer, Personnel, ProtocolStatus, ProtocolHistory, ProtocolAttachment, ProtocolReminder } from '../types/types';
+import { Protocol, Customer, Personnel, ProtocolStatus, ProtocolHistory, ProtocolAttachment, ProtocolReminder, AttachmentVersion } from '../types/types';
 import '../styles/Protocol.css';
+import { subscribeProtocolEvents } from '../services/protocolEvents';
+import { downloadFile, openFile } from '../services/attachmentFiles';
+import AuthImage from '../components/AuthImage';
 import { Select } from 'antd';
 import ptBR from 'antd/locale/pt_BR';
 import 'dayjs/locale/pt-br';
@@ -22,10 +25,57 @@ const { TabPane } = Tabs;
 const { TextArea } = Input;
 const { Option } = Select;
 
-// Add this function to handle file viewing
-const handleViewFile = (attachmentId: number, fileName: string) => {
-    const fileUrl = `http://localhost:8080/api/attachments/${attachmentId}/download`;
-    window.open(fileUrl, '_blank');
+// O download vai pelo axios para levar o token; 409 indica arquivo ainda em
+// verificação ou bloqueado pelo antivírus
+const handleViewFile = async (attachmentId: number, fileName: string, version?: number) => {
+    const query = version ? `?version=${version}` : '';
+    try {
+        await downloadFile(`/api/attachments/${attachmentId}/download${query}`, fileName);
+    } catch (error) {
+        console.error('Error downloading file:', error);
+        message.error('Falha ao baixar o arquivo');
+    }
+};
+
+// O ZIP é montado enquanto é enviado, então só chega ao navegador depois
+// de completo; o nome vem do Content-Disposition
+const handleDownloadArchive = async (protocolId: number) => {
+    try {
+        await downloadFile(
+            `/api/protocols/${protocolId}/attachments/archive`,
+            `protocolo-${protocolId}-anexos.zip`
+        );
+    } catch (error) {
+        console.error('Error downloading archive:', error);
+        message.error('Falha ao baixar os arquivos');
+    }
+};
+
+// Links de miniatura e visualização vêm da API como caminhos relativos e,
+// como os downloads, são buscados com o token
+const openPreview = async (previewUrl: string) => {
+    try {
+        await openFile(previewUrl);
+    } catch (error) {
+        console.error('Error opening preview:', error);
+        message.error('Falha ao abrir a visualização');
+    }
+};
+
+// 413 (tamanho ou cota) e 415 (tipo) trazem o motivo da recusa
+const uploadErrorMessage = (error: unknown): string => {
+    if (axios.isAxiosError(error)) {
+        const status = error.response?.status;
+        if (status === 413 || status === 415) {
+            return `Arquivo recusado: ${error.response?.data?.error}`;
+        }
+    }
+    return 'Falha ao adicionar arquivo';
+};
+
+const scanStatusTags: Record<string, { color: string; label: string }> = {
+    pending: { color: 'gold', label: 'Verificando' },
+    infected: { color: 'red', label: 'Bloqueado (vírus)' },
 };
 
 const getDefaultColorForStatus = (statusName: string): string => {
@@ -46,6 +96,11 @@ const getDefaultColorForStatus = (statusName: string): string => {
 const ProtocolPage: React.FC = () => {
     // States for protocols and related entities
     const [protocols, setProtocols] = useState<Protocol[]>([]);
+    // Paginação feita pela API: a tabela mostra uma página e o total vem
+    // da resposta
+    const [page, setPage] = useState({ current: 1, pageSize: 10 });
+    const [totalProtocols, setTotalProtocols] = useState<number>(0);
+    const pageRef = useRef(page);
     const [customers, setCustomers] = useState<Customer[]>([]);
     const [personnel, setPersonnel] = useState<Personnel[]>([]);
     const [statuses, setStatuses] = useState<ProtocolStatus[]>([]);
@@ -54,6 +109,8 @@ const ProtocolPage: React.FC = () => {
     const [selectedProtocol, setSelectedProtocol] = useState<Protocol | null>(null);
     const [protocolHistory, setProtocolHistory] = useState<ProtocolHistory[]>([]);
     const [protocolAttachments, setProtocolAttachments] = useState<ProtocolAttachment[]>([]);
+    const [versionsAttachment, setVersionsAttachment] = useState<ProtocolAttachment | null>(null);
+    const [attachmentVersions, setAttachmentVersions] = useState<AttachmentVersion[]>([]);
     const [protocolReminders, setProtocolReminders] = useState<ProtocolReminder[]>([]);
 
     // Modal states
@@ -70,15 +127,38 @@ const ProtocolPage: React.FC = () => {
     const [isEditing, setIsEditing] = useState<boolean>(false);
     const [loading, setLoading] = useState<boolean>(true);
 
+    // Busca uma página; uma página que ficou vazia (ex.: última linha
+    // removida) volta para a anterior
+    const loadProtocols = async (current: number, pageSize: number) => {
+        const res = await axios.get(`${API_BASE}/api/protocols`, {
+            params: { page: current, page_size: pageSize }
+        });
+        if (res.data.data.length === 0 && current > 1) {
+            setPage({ current: current - 1, pageSize });
+            return;
+        }
+        setProtocols(res.data.data as Protocol[]);
+        setTotalProtocols(res.data.total);
+    };
+
+    const reloadProtocols = () =>
+        loadProtocols(pageRef.current.current, pageRef.current.pageSize);
+
+    useEffect(() => {
+        pageRef.current = page;
+        setLoading(true);
+        loadProtocols(page.current, page.pageSize)
+            .catch(error => {
+                console.error('Error fetching protocols:', error);
+                message.error('Falha ao carregar protocolos');
+            })
+            .finally(() => setLoading(false));
+    }, [page]);
+
     // Fetch all necessary data
     useEffect(() => {
         const fetchData = async () => {
-            setLoading(true);
             try {
-                // Get all protocols
-                const protocolsRes = await axios.get(`${API_BASE}/api/protocols`);
-                setProtocols(protocolsRes.data as Protocol[]);
-
                 // Get customers
                 const customersRes = await axios.get(`${API_BASE}/api/customers`);
                 setCustomers(customersRes.data as Customer[]);
@@ -98,14 +178,55 @@ const ProtocolPage: React.FC = () => {
             } catch (error) {
                 console.error('Error fetching data:', error);
                 message.error('Failed to load data');
-            } finally {
-                setLoading(false);
             }
         };
 
         fetchData();
     }, []);
 
+    // Mantém a lista atualizada com as alterações feitas por outros usuários
+    useEffect(() => {
+        return subscribeProtocolEvents({}, async (event) => {
+            const protocolId = event.data.protocol_id;
+            if (event.type.startsWith('attachment.')) {
+                setSelectedProtocol(current => {
+                    if (current && current.protocol_id === protocolId) {
+                        axios.get(`${API_BASE}/api/protocols/${protocolId}/attachments`)
+                            .then(res => setProtocolAttachments(res.data))
+                            .catch(() => {});
+                    }
+                    return current;
+                });
+                return;
+            }
+            try {
+                const res = await axios.get(`${API_BASE}/api/protocols/${protocolId}`);
+                const updated = res.data as Protocol;
+                const { current: currentPage, pageSize } = pageRef.current;
+                if (event.type === 'protocol.created') {
+                    setTotalProtocols(total => total + 1);
+                }
+                setProtocols(current => {
+                    if (current.some(p => p.protocol_id === protocolId)) {
+                        return current.map(p => p.protocol_id === protocolId ? updated : p);
+                    }
+                    // Protocolos novos aparecem no topo da primeira página
+                    // (a lista vem dos mais recentes para os mais antigos)
+                    return event.type === 'protocol.created' && currentPage === 1
+                        ? [updated, ...current].slice(0, pageSize)
+                        : current;
+                });
+            } catch (error) {
+                console.error('Error refreshing protocol:', error);
+            }
+        }, () => {
+            // Eventos perdidos que o servidor não tem mais: recarrega a página
+            reloadProtocols().catch(error => {
+                console.error('Error reloading protocols:', error);
+            });
+        });
+    }, [API_BASE]);
+
     // Load protocol details when a protocol is selected
     const loadProtocolDetails = async (protocol: Protocol) => {
         setSelectedProtocol(protocol);
@@ -151,11 +272,11 @@ const ProtocolPage: React.FC = () => {
                 ));
             } else {
                 // Create new protocol
-                const response = await axios.post(`${API_BASE}/api/protocols`, processedValues);
+                await axios.post(`${API_BASE}/api/protocols`, processedValues);
                 message.success('Protocolo criado com sucesso!');
 
-                // Add to local state
-                setProtocols([...protocols, response.data]);
+                // O novo protocolo entra na ordem da API
+                await reloadProtocols();
             }
             setProtocolModalVisible(false);
             protocolForm.resetFields();
@@ -253,11 +374,48 @@ const ProtocolPage: React.FC = () => {
             onSuccess();
         } catch (error) {
             console.error('Erro ao adicionar arquivo:', error);
-            message.error('Falha ao adicionar arquivo');
+            message.error(uploadErrorMessage(error));
+            onError();
+        }
+    };
+
+    // Envia um documento corrigido como nova versão do anexo
+    const handleVersionUpload = (attachment: ProtocolAttachment) => async (options: any) => {
+        const { file, onSuccess, onError } = options;
+
+        const formData = new FormData();
+        formData.append('file', file);
+
+        try {
+            const response = await axios.post(
+                `${API_BASE}/api/attachments/${attachment.attachment_id}/versions`,
+                formData
+            );
+            setProtocolAttachments(current => current.map(a =>
+                a.attachment_id === attachment.attachment_id ? response.data : a
+            ));
+            message.success(`Versão ${response.data.version} adicionada`);
+            onSuccess();
+        } catch (error) {
+            console.error('Erro ao adicionar versão:', error);
+            message.error(uploadErrorMessage(error));
             onError();
         }
     };
 
+    const openVersions = async (attachment: ProtocolAttachment) => {
+        try {
+            const response = await axios.get(
+                `${API_BASE}/api/attachments/${attachment.attachment_id}/versions`
+            );
+            setAttachmentVersions(response.data);
+            setVersionsAttachment(attachment);
+        } catch (error) {
+            console.error('Erro ao carregar versões:', error);
+            message.error('Falha ao carregar versões');
+        }
+    };
+
     // Handle marking a reminder as sent
     const handleMarkReminderSent = async (reminder: ProtocolReminder) => {
         try {
@@ -303,8 +461,8 @@ const ProtocolPage: React.FC = () => {
     const handleDeleteProtocol = async (id: number) => {
         try {
             await axios.delete(`${API_BASE}/api/protocols/${id}`);
-            setProtocols(protocols.filter(p => p.protocol_id !== id));
             message.success('Protocolo removido com sucesso!');
+            await reloadProtocols();
         } catch (error) {
             console.error('Erro ao remover protocolo:', error);
             message.error('Falha ao remover protocolo');
@@ -454,7 +612,17 @@ const ProtocolPage: React.FC = () => {
                     )
                 }}
                 loading={loading}
-                pagination={{ pageSize: 10 }}
+                pagination={{
+                    current: page.current,
+                    pageSize: page.pageSize,
+                    total: totalProtocols,
+                    showSizeChanger: true,
+                    pageSizeOptions: [10, 20, 50, 100]
+                }}
+                onChange={pagination => setPage({
+                    current: pagination.current ?? 1,
+                    pageSize: pagination.pageSize ?? page.pageSize
+                })}
             />
 
             {/* Protocol Form Modal */}
@@ -666,21 +834,27 @@ const ProtocolPage: React.FC = () => {
                                         <Card style={{ width: '100%' }}>
                                             <div className="history-item">
                                                 <div>
-                                                    <p>
-                                                        Status atualizado de {' '}
-                                                        <Tag color={item.previous_status?.color || 'default'}>
-                                                            {item.previous_status?.status_name || 'N/A'}
-                                                        </Tag>
-                                                        {' '} para {' '}
-                                                        <Tag color={item.new_status?.color || 'default'}>
-                                                            {item.new_status?.status_name || 'N/A'}
-                                                        </Tag>
-                                                    </p>
+                                                    {item.entry_type === 'sla_breach' ? (
+                                                        <p><Tag color="red">SLA violado</Tag></p>
+                                                    ) : (
+                                                        <p>
+                                                            Status atualizado de {' '}
+                                                            <Tag color={item.previous_status?.color || 'default'}>
+                                                                {item.previous_status?.status_name || 'N/A'}
+                                                            </Tag>
+                                                            {' '} para {' '}
+                                                            <Tag color={item.new_status?.color || 'default'}>
+                                                                {item.new_status?.status_name || 'N/A'}
+                                                            </Tag>
+                                                        </p>
+                                                    )}
                                                     {item.notes && <p><strong>Notes:</strong> {item.notes}</p>}
                                                 </div>
                                                 <div>
                                                     <p>
-                                                        Por: {item.created_by_agent?.first_name} {item.created_by_agent?.last_name}
+                                                        {item.entry_type === 'sla_breach'
+                                                            ? 'Registrado pelo sistema'
+                                                            : `Por: ${item.created_by_agent?.first_name ?? ''} ${item.created_by_agent?.last_name ?? ''}`}
                                                     </p>
                                                     <p>
                                                         {moment(item.created_at).format('DD/MM/YYYY HH:mm')}
@@ -702,6 +876,14 @@ const ProtocolPage: React.FC = () => {
                                 >
                                     <Button icon={<UploadOutlined />}>Adicionar Arquivo</Button>
                                 </Upload>
+                                <Button
+                                    icon={<InboxOutlined />}
+                                    style={{ marginLeft: 8 }}
+                                    disabled={protocolAttachments.length === 0}
+                                    onClick={() => selectedProtocol && handleDownloadArchive(selectedProtocol.protocol_id)}
+                                >
+                                    Baixar todos (ZIP)
+                                </Button>
 
                                 <List
                                     dataSource={protocolAttachments}
@@ -720,10 +902,27 @@ const ProtocolPage: React.FC = () => {
                                                     type="primary"
                                                     icon={<FileOutlined />}
                                                     size="small"
+                                                    disabled={item.scan_status !== 'clean'}
                                                     onClick={() => handleViewFile(item.attachment_id, item.file_name)}
                                                 >
                                                     Baixar
                                                 </Button>,
+                                                ...(item.preview_url ? [
+                                                    <Button size="small" onClick={() => openPreview(item.preview_url!)}>
+                                                        Visualizar
+                                                    </Button>
+                                                ] : []),
+                                                <Upload
+                                                    customRequest={handleVersionUpload(item)}
+                                                    showUploadList={false}
+                                                >
+                                                    <Button icon={<UploadOutlined />} size="small">
+                                                        Nova versão
+                                                    </Button>
+                                                </Upload>,
+                                                <Button size="small" onClick={() => openVersions(item)}>
+                                                    Versões
+                                                </Button>,
                                                 <Popconfirm
                                                     title="Você tem certeza que deseja remover este arquivo?"
                                                     onConfirm={() => handleDeleteAttachment(item.attachment_id)}
@@ -736,7 +935,30 @@ const ProtocolPage: React.FC = () => {
                                         >
                                             <List.Item.Meta
                                                 icon={<FileOutlined />}
-                                                title={item.file_name}
+                                                avatar={item.thumbnail_url && (
+                                                    <AuthImage
+                                                        path={item.thumbnail_url}
+                                                        alt={item.file_name}
+                                                        className="attachment-thumbnail"
+                                                        onClick={() => item.preview_url && openPreview(item.preview_url)}
+                                                    />
+                                                )}
+                                                title={
+                                                    <>
+                                                        {item.file_name}
+                                                        {item.version > 1 && (
+                                                            <Tag style={{ marginLeft: 8 }}>v{item.version}</Tag>
+                                                        )}
+                                                        {scanStatusTags[item.scan_status] && (
+                                                            <Tag
+                                                                color={scanStatusTags[item.scan_status].color}
+                                                                style={{ marginLeft: 8 }}
+                                                            >
+                                                                {scanStatusTags[item.scan_status].label}
+                                                            </Tag>
+                                                        )}
+                                                    </>
+                                                }
                                                 description={`Adicionado por ${item.uploaded_by_agent?.first_name} ${item.uploaded_by_agent?.last_name} em ${moment(item.uploaded_at).format('DD/MM/YYYY HH:mm')}`}
                                             />
                                         </List.Item>
@@ -911,6 +1133,49 @@ const ProtocolPage: React.FC = () => {
                     </div>
                 </Form>
             </Modal>
+
+            {/* Versões de um anexo */}
+            <Modal
+                title={`Versões de ${versionsAttachment?.file_name ?? ''}`}
+                open={versionsAttachment !== null}
+                onCancel={() => setVersionsAttachment(null)}
+                footer={null}
+            >
+                <List
+                    dataSource={attachmentVersions}
+                    renderItem={version => (
+                        <List.Item
+                            actions={[
+                                <Button
+                                    icon={<FileOutlined />}
+                                    size="small"
+                                    disabled={version.scan_status !== 'clean'}
+                                    onClick={() => handleViewFile(version.attachment_id, version.file_name, version.version)}
+                                >
+                                    Baixar
+                                </Button>
+                            ]}
+                        >
+                            <List.Item.Meta
+                                title={
+                                    <>
+                                        v{version.version} — {version.file_name}
+                                        {scanStatusTags[version.scan_status] && (
+                                            <Tag
+                                                color={scanStatusTags[version.scan_status].color}
+                                                style={{ marginLeft: 8 }}
+                                            >
+                                                {scanStatusTags[version.scan_status].label}
+                                            </Tag>
+                                        )}
+                                    </>
+                                }
+                                description={`Enviado por ${version.uploaded_by_agent?.first_name} ${version.uploaded_by_agent?.last_name} em ${moment(version.uploaded_at).format('DD/MM/YYYY HH:mm')}`}
+                            />
+                        </List.Item>
+                    )}
+                />
+            </Modal>
         </div>
     );
 };
@@ -0,0 +1,70 @@
+// src/services/attachmentFiles.ts
+import axios from 'axios';
+
+const API_BASE = process.env.REACT_APP_API_BASE_URL;
+
+// Downloads, visualizações e miniaturas exigem o token, que window.open e
+// <img src> não enviam. Os arquivos são buscados pelo axios (interceptor
+// em App.js) e entregues ao navegador como blob.
+
+export interface FileBlob {
+    blob: Blob;
+    fileName?: string;
+}
+
+// Nome do arquivo no Content-Disposition (filename* tem prioridade)
+const fileNameFrom = (disposition?: string): string | undefined => {
+    if (!disposition) return undefined;
+    const encoded = /filename\*=UTF-8''([^;]+)/i.exec(disposition);
+    if (encoded) {
+        try {
+            return decodeURIComponent(encoded[1]);
+        } catch {
+            // cai para o filename simples
+        }
+    }
+    const plain = /filename="?([^";]+)"?/i.exec(disposition);
+    return plain ? plain[1] : undefined;
+};
+
+// Busca um caminho da API ("/api/...") como blob
+export const fetchFileBlob = async (path: string): Promise<FileBlob> => {
+    const response = await axios.get(`${API_BASE}${path}`, { responseType: 'blob' });
+    return {
+        blob: response.data as Blob,
+        fileName: fileNameFrom(response.headers['content-disposition']),
+    };
+};
+
+// Salva o arquivo com o nome enviado pela API ou, sem ele, fallbackName
+export const downloadFile = async (path: string, fallbackName: string) => {
+    const { blob, fileName } = await fetchFileBlob(path);
+    const url = URL.createObjectURL(blob);
+    const link = document.createElement('a');
+    link.href = url;
+    link.download = fileName || fallbackName;
+    document.body.appendChild(link);
+    link.click();
+    link.remove();
+    URL.revokeObjectURL(url);
+};
+
+// Abre o arquivo em outra aba. A aba é aberta antes da requisição para o
+// navegador não tratá-la como pop-up.
+export const openFile = async (path: string) => {
+    const tab = window.open('', '_blank');
+    try {
+        const { blob } = await fetchFileBlob(path);
+        const url = URL.createObjectURL(blob);
+        if (tab) {
+            tab.location.href = url;
+        } else {
+            window.location.href = url;
+        }
+        // A aba já carregou o conteúdo; o blob pode ser liberado depois
+        setTimeout(() => URL.revokeObjectURL(url), 60_000);
+    } catch (error) {
+        tab?.close();
+        throw error;
+    }
+};
@@ -0,0 +1,108 @@
+// src/services/protocolEvents.ts
+const API_BASE = process.env.REACT_APP_API_BASE_URL;
+
+export interface ProtocolEvent {
+    id: number;
+    type: string;
+    data: any;
+}
+
+export interface ProtocolEventFilter {
+    protocol_id?: number;
+    status_id?: number;
+    type_id?: number;
+    assigned_to?: number;
+    branch_id?: number;
+    priority?: string;
+    events?: string[];
+}
+
+const RECONNECT_DELAY_MS = 3000;
+
+// Assina o stream de eventos de protocolos (Server-Sent Events). Usa fetch
+// em vez de EventSource para poder enviar o token no cabeçalho; ao cair, a
+// conexão é refeita com o Last-Event-ID para não perder eventos. Se os
+// eventos perdidos já não puderem ser repetidos, o servidor envia "reset" e
+// onReset deve recarregar os dados.
+// Retorna uma função que encerra a assinatura.
+export const subscribeProtocolEvents = (
+    filter: ProtocolEventFilter,
+    onEvent: (event: ProtocolEvent) => void,
+    onReset?: () => void
+): (() => void) => {
+    const controller = new AbortController();
+    let lastEventId: string | null = null;
+
+    const params = new URLSearchParams();
+    Object.entries(filter).forEach(([key, value]) => {
+        if (value === undefined || value === null || value === '') return;
+        params.set(key, Array.isArray(value) ? value.join(',') : String(value));
+    });
+    const url = `${API_BASE}/api/protocols/events?${params.toString()}`;
+
+    const dispatch = (block: string) => {
+        let id: string | null = null;
+        let type = 'message';
+        const data: string[] = [];
+        block.split('\n').forEach(line => {
+            if (line.startsWith(':')) return;
+            const sep = line.indexOf(':');
+            const field = sep === -1 ? line : line.slice(0, sep);
+            const value = sep === -1 ? '' : line.slice(sep + 1).replace(/^ /, '');
+            if (field === 'id') id = value;
+            else if (field === 'event') type = value;
+            else if (field === 'data') data.push(value);
+        });
+        if (id !== null) lastEventId = id;
+        if (type === 'reset') {
+            onReset?.();
+            return;
+        }
+        if (data.length === 0) return;
+        try {
+            onEvent({ id: Number(id), type, data: JSON.parse(data.join('\n')) });
+        } catch (error) {
+            console.error('Invalid protocol event:', error);
+        }
+    };
+
+    const connect = async () => {
+        while (!controller.signal.aborted) {
+            try {
+                const headers: Record<string, string> = { Accept: 'text/event-stream' };
+                const token = localStorage.getItem('token');
+                if (token) headers.Authorization = `Bearer ${token}`;
+                if (lastEventId) headers['Last-Event-ID'] = lastEventId;
+
+                const response = await fetch(url, { headers, signal: controller.signal });
+                if (response.status === 401 || response.status === 403) {
+                    return; // sem permissão: não adianta reconectar
+                }
+                if (!response.ok || !response.body) {
+                    throw new Error(`HTTP ${response.status}`);
+                }
+
+                const reader = response.body.getReader();
+                const decoder = new TextDecoder();
+                let buffer = '';
+                for (;;) {
+                    const { value, done } = await reader.read();
+                    if (done) break;
+                    buffer += decoder.decode(value, { stream: true }).replace(/\r\n?/g, '\n');
+                    let end;
+                    while ((end = buffer.indexOf('\n\n')) !== -1) {
+                        dispatch(buffer.slice(0, end));
+                        buffer = buffer.slice(end + 2);
+                    }
+                }
+            } catch (error) {
+                if (controller.signal.aborted) return;
+                console.warn('Protocol event stream interrupted:', error);
+            }
+            await new Promise(resolve => setTimeout(resolve, RECONNECT_DELAY_MS));
+        }
+    };
+
+    connect();
+    return () => controller.abort();
+};
@@ -69,7 +69,9 @@ export interface ProtocolHistory {
     previous_status_id: number | null;
     new_status_id: number;
     notes: string | null;
-    created_by: number;
+    // 'sla_breach' é registrada pelo sistema, sem autor
+    entry_type: 'status_change' | 'sla_breach';
+    created_by: number | null;
     created_at: string;
     // Relations
     previous_status?: ProtocolStatus;
@@ -86,10 +88,40 @@ export interface ProtocolAttachment {
     file_type: string;
     uploaded_by: number;
     uploaded_at: string;
+    // SHA-256 conferido a cada download; vazio em anexos antigos
+    checksum: string;
+    // Versão atual; as anteriores são listadas em /attachments/:id/versions
+    version: number;
+    // Só anexos "clean" podem ser baixados
+    scan_status: 'pending' | 'clean' | 'infected';
+    scan_result?: string;
+    // Miniatura (imagens e PDFs) e visualização no navegador (imagens),
+    // presentes só depois da verificação
+    thumbnail_status: 'pending' | 'ready' | 'unavailable';
+    thumbnail_url?: string;
+    preview_url?: string;
     // Relations
     uploaded_by_agent?: Personnel;
 }
 
+export interface AttachmentVersion {
+    version_id: number;
+    attachment_id: number;
+    version: number;
+    file_name: string;
+    file_size: number;
+    content_type: string;
+    uploaded_by: number;
+    uploaded_at: string;
+    checksum: string;
+    scan_status: 'pending' | 'clean' | 'infected';
+    scan_result?: string;
+    thumbnail_status: 'pending' | 'ready' | 'unavailable';
+    thumbnail_url?: string;
+    preview_url?: string;
+    uploaded_by_agent?: Personnel;
+}
+
 export interface ProtocolReminder {
     reminder_id: number;
     protocol_id: number;